@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/config"
+	"github.com/bisand/twingate-tray/internal/control"
+	"github.com/bisand/twingate-tray/internal/history"
+	"github.com/bisand/twingate-tray/internal/launch"
+	"github.com/bisand/twingate-tray/internal/output"
+	"github.com/bisand/twingate-tray/internal/probe"
+	"github.com/bisand/twingate-tray/internal/tui"
+	"github.com/bisand/twingate-tray/internal/twingate"
+)
+
+// outputFormat is the format selected with the global --output flag
+var outputFormat = output.FormatText
+
+func handleCLI(args []string) {
+	format, args, err := parseGlobalFlags(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		printUsage()
+		os.Exit(2)
+	}
+	outputFormat = format
+
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+
+	switch args[0] {
+	case "status":
+		if err := printStatus(); err != nil {
+			exitWithError("Error", err)
+		}
+
+	case "connect":
+		if err := runCLIOperation(app.OpConnect, twingate.Connect); err != nil {
+			exitWithError("Error connecting", err)
+		}
+		printResultStatus()
+
+	case "disconnect":
+		if err := runCLIOperation(app.OpDisconnect, twingate.Disconnect); err != nil {
+			exitWithError("Error disconnecting", err)
+		}
+		printResultStatus()
+
+	case "resources":
+		if err := printResources(args[1:]); err != nil {
+			exitWithError("Error", err)
+		}
+
+	case "open":
+		if err := openResource(args[1:]); err != nil {
+			exitWithError("Error", err)
+		}
+
+	case "exit-nodes":
+		status, err := twingate.GetExitNodeStatus(context.Background())
+		if err != nil {
+			exitWithError("Error", err)
+		}
+		writeDocument(output.NewExitNodes(*status))
+
+	case "history":
+		if err := printHistory(args[1:]); err != nil {
+			exitWithError("Error", err)
+		}
+
+	case "tui":
+		if err := runTUI(); err != nil {
+			exitWithError("Error", err)
+		}
+
+	case "daemon":
+		// Start as daemon with system tray
+		startDaemon()
+
+	case "help", "-h", "--help":
+		printUsage()
+
+	case "version", "-v", "--version":
+		writeDocument(output.NewVersion(app.Version, app.GitCommit, app.BuildDate,
+			app.GetFullVersion()+"\n"+app.GetVersionInfo()))
+
+	default:
+		fmt.Printf("Unknown command: %s\n", args[0])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// parseGlobalFlags extracts --output/-o from anywhere in args and returns the
+// remaining arguments
+func parseGlobalFlags(args []string) (output.Format, []string, error) {
+	format := output.FormatText
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var value string
+		switch {
+		case arg == "--output" || arg == "-o":
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--output="):
+			value = strings.TrimPrefix(arg, "--output=")
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+
+		f, err := output.ParseFormat(value)
+		if err != nil {
+			return "", nil, err
+		}
+		format = f
+	}
+	return format, rest, nil
+}
+
+// writeDocument writes doc to stdout in the selected format
+func writeDocument(doc output.Document) {
+	if err := output.Write(os.Stdout, outputFormat, doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exitWithError reports err in the selected format and exits. prefix is used
+// for text output only.
+func exitWithError(prefix string, err error) {
+	if outputFormat == output.FormatText {
+		fmt.Printf("%s: %v\n", prefix, err)
+	} else {
+		writeDocument(output.NewError(err))
+	}
+	os.Exit(1)
+}
+
+// printProgress prints a progress line in text mode. Structured formats only
+// carry the final result.
+func printProgress(line string) {
+	if outputFormat == output.FormatText {
+		fmt.Println(line)
+	}
+}
+
+// printResultStatus writes the status after connect/disconnect in structured
+// formats; text mode has already printed the progress
+func printResultStatus() {
+	if outputFormat == output.FormatText {
+		return
+	}
+	if err := printStatus(); err != nil {
+		exitWithError("Error", err)
+	}
+}
+
+// printStatus writes the connection status. The daemon is asked when it is
+// running, so the CLI reports the same debounced state as the tray; the
+// Twingate CLI is only asked directly when it is not.
+func printStatus() error {
+	client, err := control.Dial()
+	if err != nil {
+		return printDirectStatus()
+	}
+	defer client.Close()
+
+	status, err := client.Status()
+	if err != nil {
+		return err
+	}
+	if outputFormat == output.FormatText {
+		fmt.Println(status.State)
+		return nil
+	}
+
+	info := twingate.ConnectionInfo{Network: status.Network, UserEmail: status.User, Interface: status.Interface}
+	exitNode := &twingate.ExitNodeStatus{Enabled: status.ExitNode != "", CurrentNode: status.ExitNode}
+	doc := output.NewStatus(status.State, info, exitNode)
+	connectedSince := time.Time{}
+	if status.ConnectedSince > 0 {
+		connectedSince = time.Unix(status.ConnectedSince, 0)
+	}
+	doc.SetConnectedSince(connectedSince)
+	writeDocument(doc)
+	return nil
+}
+
+// printDirectStatus writes the connection status read from the Twingate CLI
+func printDirectStatus() error {
+	ctx := context.Background()
+	status, err := twingate.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+	state := observedState(status).String()
+
+	if outputFormat == output.FormatText {
+		// Text output is the bare state, so skip gathering the details
+		fmt.Println(state)
+		return nil
+	}
+
+	info := twingate.GetConnectionInfo(ctx)
+	exitNodes, err := twingate.GetExitNodeStatus(ctx)
+	if err != nil {
+		exitNodes = nil
+	}
+
+	doc := output.NewStatus(state, info, exitNodes)
+	if state != app.StateConnected.String() {
+		doc.SetConnectedSince(time.Time{})
+	}
+	writeDocument(doc)
+	return nil
+}
+
+// printResources writes the resource list, with --probe checking whether
+// each resource answers
+func printResources(args []string) error {
+	flags := flag.NewFlagSet("resources", flag.ContinueOnError)
+	probeFlag := flags.Bool("probe", false, "check DNS and TCP reachability of each resource")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	resources, err := listResources(ctx)
+	if err != nil {
+		return err
+	}
+	doc := output.NewResources(resources)
+	if *probeFlag {
+		// Probe with the configured timeout and ports; the defaults if the file is invalid
+		cfg, _ := config.Load(config.Path())
+		if cfg == nil {
+			cfg = config.Default()
+		}
+		prober := probe.NewProber()
+		prober.SetTimeout(cfg.Probe.Timeout.Duration)
+		prober.SetPorts(cfg.Probe.Ports)
+
+		addresses := make([]string, 0, len(resources))
+		for _, res := range resources {
+			addresses = append(addresses, res.Address)
+		}
+		doc.SetProbes(prober.ProbeAll(ctx, addresses))
+	}
+	writeDocument(doc)
+	return nil
+}
+
+// listResources returns the resources from the daemon when it is running,
+// and from the Twingate CLI when it is not
+func listResources(ctx context.Context) ([]twingate.Resource, error) {
+	client, err := control.Dial()
+	if err != nil {
+		return twingate.GetResources(ctx)
+	}
+	defer client.Close()
+
+	listed, err := client.ListResources()
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]twingate.Resource, 0, len(listed))
+	for _, res := range listed {
+		resources = append(resources, twingate.Resource{
+			Name:       res.Name,
+			Address:    res.Address,
+			AuthStatus: res.AuthStatus,
+			NeedsAuth:  res.NeedsAuth,
+		})
+	}
+	return resources, nil
+}
+
+// openResource opens a resource with a launcher from the config file: the one
+// named, or else the first that matches. --list shows the launchers instead.
+func openResource(args []string) error {
+	flags := flag.NewFlagSet("open", flag.ContinueOnError)
+	list := flags.Bool("list", false, "list the launchers that open the resource")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return errors.New("usage: twingate-tray open [--list] RESOURCE [LAUNCHER]")
+	}
+
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return err
+	}
+	resources, err := twingate.GetResources(context.Background())
+	if err != nil {
+		return err
+	}
+	res, err := findResource(resources, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	launchers := compileLaunchers(cfg.Launchers)
+	matched := launch.Match(launchers, res.Name, res.Address)
+	if *list {
+		writeDocument(output.NewLaunchers(res, matched))
+		return nil
+	}
+
+	var l launch.Launcher
+	switch name := flags.Arg(1); {
+	case name != "":
+		var ok bool
+		if l, ok = launch.Find(launchers, name); !ok {
+			return fmt.Errorf("no launcher called %q in %s", name, config.Path())
+		}
+	case len(matched) == 0:
+		return fmt.Errorf("no launcher matches %s; add one under [[launchers]] in %s", res.Name, config.Path())
+	default:
+		l = matched[0]
+	}
+	if res.NeedsAuth {
+		printProgress(fmt.Sprintf("%s is locked; authenticate it from the tray if the connection fails", res.Name))
+	}
+
+	// From a terminal, terminal launchers run right here
+	if stat, err := os.Stdin.Stat(); l.Terminal && err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		argv, err := l.Args(res.Name, res.Address)
+		if err != nil {
+			return fmt.Errorf("%s: %w", l.Name, err)
+		}
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		var exitErr *exec.ExitError
+		if err := cmd.Run(); errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if err := l.Open(res.Name, res.Address); err != nil {
+		return fmt.Errorf("%s: %w", l.Name, err)
+	}
+	printProgress(fmt.Sprintf("Opened %s with %s", res.Name, l.Name))
+	return nil
+}
+
+// findResource looks a resource up by name, ignoring case if no name matches
+// exactly, or by address
+func findResource(resources []twingate.Resource, query string) (twingate.Resource, error) {
+	for _, match := range []func(twingate.Resource) bool{
+		func(r twingate.Resource) bool { return r.Name == query },
+		func(r twingate.Resource) bool { return strings.EqualFold(r.Name, query) },
+		func(r twingate.Resource) bool { return r.Address == query },
+	} {
+		for _, res := range resources {
+			if match(res) {
+				return res, nil
+			}
+		}
+	}
+	return twingate.Resource{}, fmt.Errorf("no resource called %q", query)
+}
+
+// defaultHistoryDays is how many days `history` shows by default
+const defaultHistoryDays = 7
+
+// printHistory writes the connected time and drops per day from the journal
+func printHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	days := flags.Int("days", defaultHistoryDays, "number of days to show, including today")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+
+	// Read everything so sessions that started before the window still count
+	events, err := history.NewStore(history.Path()).Read(time.Time{})
+	if err != nil {
+		return err
+	}
+	// Only a running tray keeps a session open; otherwise it was left by a crash
+	if client, err := control.Dial(); err == nil {
+		client.Close()
+	} else {
+		events = history.EndOpenSession(events)
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, 1-*days)
+	writeDocument(output.NewHistory(history.Summarize(events, from, now)))
+	return nil
+}
+
+// cliOperationTimeout bounds how long the CLI waits for a connect/disconnect to settle
+const cliOperationTimeout = 30 * time.Second
+
+// runCLIOperation runs op and prints each transition until the connection
+// settles or the wait times out. A running daemon performs the operation;
+// otherwise it runs locally through a private state machine.
+func runCLIOperation(op app.Operation, run func(context.Context) error) error {
+	if client, err := control.Dial(); err == nil {
+		defer client.Close()
+		return runDaemonOperation(client, op)
+	}
+
+	ctx := context.Background()
+	machine := app.NewStateMachine()
+	machine.Subscribe(func(t app.Transition) {
+		if !t.Initial {
+			printProgress(t.To.Label())
+		}
+	})
+
+	status, err := twingate.GetStatus(ctx)
+	machine.Observe(observedState(status), err)
+
+	machine.BeginOperation(op)
+	err = run(ctx)
+	machine.EndOperation(op, err)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(cliOperationTimeout)
+	for time.Now().Before(deadline) {
+		state := machine.State()
+		if state == app.StateConnected || state == app.StateDisconnected {
+			return nil
+		}
+		time.Sleep(app.StatusPollInterval)
+		status, err := twingate.GetStatus(ctx)
+		machine.Observe(observedState(status), err)
+	}
+
+	if op == app.OpConnect {
+		printProgress("Connection initiated")
+	} else {
+		printProgress("Disconnection initiated")
+	}
+	return nil
+}
+
+// runDaemonOperation asks the daemon to perform op and follows its StateChanged signals
+func runDaemonOperation(client *control.Client, op app.Operation) error {
+	changes, err := client.WatchState()
+	if err != nil {
+		return err
+	}
+
+	target := app.StateConnected
+	if op == app.OpDisconnect {
+		target = app.StateDisconnected
+		err = client.Disconnect()
+	} else {
+		err = client.Connect()
+	}
+	if err != nil {
+		return err
+	}
+
+	timeout := time.After(cliOperationTimeout)
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return fmt.Errorf("lost connection to daemon")
+			}
+			state, _ := app.ParseConnectionState(change.State)
+			printProgress(state.Label())
+			if state == target {
+				return nil
+			}
+			if state == app.StateError {
+				return errors.New(change.Reason)
+			}
+		case <-timeout:
+			if op == app.OpConnect {
+				printProgress("Connection initiated")
+			} else {
+				printProgress("Disconnection initiated")
+			}
+			return nil
+		}
+	}
+}
+
+// runTUI shows the terminal dashboard. A running daemon performs connect
+// and disconnect, as with the connect and disconnect commands.
+func runTUI() error {
+	handlers := tui.Handlers{
+		State: func(ctx context.Context) (app.ConnectionState, error) {
+			status, err := twingate.GetStatus(ctx)
+			return observedState(status), err
+		},
+		Connect:    twingate.Connect,
+		Disconnect: twingate.Disconnect,
+		Privileged: true,
+	}
+
+	if client, err := control.Dial(); err == nil {
+		defer client.Close()
+		handlers = tui.Handlers{
+			State: func(context.Context) (app.ConnectionState, error) {
+				status, err := client.Status()
+				if err != nil {
+					return app.StateDisconnected, err
+				}
+				state, _ := app.ParseConnectionState(status.State)
+				return state, nil
+			},
+			Connect:    func(context.Context) error { return client.Connect() },
+			Disconnect: func(context.Context) error { return client.Disconnect() },
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	return tui.Run(ctx, handlers)
+}
+
+func printUsage() {
+	fmt.Println(`Twingate Tray - System tray indicator for Twingate
+
+Usage:
+  twingate-tray                    # Run with system tray
+  twingate-tray status             # Check connection status
+  twingate-tray connect            # Connect to Twingate
+  twingate-tray disconnect         # Disconnect from Twingate
+  twingate-tray resources          # List Twingate resources
+  twingate-tray resources --probe  # Also check that each resource answers
+  twingate-tray open RESOURCE      # Open a resource with its first launcher
+  twingate-tray open RES LAUNCHER  # Open a resource with the named launcher
+  twingate-tray open --list RES    # Show the launchers that open a resource
+  twingate-tray exit-nodes         # List exit nodes (* marks the active one)
+  twingate-tray history [--days N] # Connected time and drops per day (default 7)
+  twingate-tray tui                # Terminal dashboard for sessions without a tray
+  twingate-tray daemon             # Start as daemon with system tray
+  twingate-tray version            # Show version information
+  twingate-tray help               # Show this help message
+
+Global options:
+  -o, --output FORMAT              # Output format: text (default), json or tsv`)
+}
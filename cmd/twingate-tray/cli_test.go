@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/control"
+	"github.com/bisand/twingate-tray/internal/output"
+	"github.com/bisand/twingate-tray/internal/twingate"
+	"github.com/godbus/dbus/v5"
+)
+
+// startControlDaemon stands in for a running tray: it makes a private
+// dbus-daemon the session bus and exports the control interface on it
+func startControlDaemon(t *testing.T, handlers control.Handlers) *control.Server {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the bus address: %v", err)
+	}
+	address = strings.TrimSpace(address)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect to the private bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	server := control.NewServer(conn, handlers)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return server
+}
+
+// captureJSON runs fn with JSON output and decodes what it wrote to stdout
+func captureJSON(t *testing.T, fn func() error, v any) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout, oldFormat := os.Stdout, outputFormat
+	os.Stdout, outputFormat = w, output.FormatJSON
+	defer func() { os.Stdout, outputFormat = oldStdout, oldFormat }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	runErr := fn()
+	w.Close()
+	data := <-done
+	if runErr != nil {
+		t.Fatalf("failed: %v", runErr)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to decode %q: %v", data, err)
+	}
+}
+
+// useRunner points the twingate package at runner for the test
+func useRunner(t *testing.T, runner *twingate.FakeRunner) {
+	old := twingate.Default
+	twingate.Default = twingate.NewClient(runner)
+	t.Cleanup(func() { twingate.Default = old })
+}
+
+func TestPrintStatusAsksDaemon(t *testing.T) {
+	runner := twingate.NewFakeRunner()
+	useRunner(t, runner)
+	server := startControlDaemon(t, control.Handlers{})
+	since := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	server.SetNetwork("acme")
+	server.SetUser("ada@acme.example")
+	server.SetInterface("sdwan0")
+	server.SetExitNode("Oslo")
+	server.SetState("connected", "", since)
+
+	var got struct {
+		State          string    `json:"state"`
+		Network        string    `json:"network"`
+		User           string    `json:"user"`
+		Interface      string    `json:"interface"`
+		ExitNode       string    `json:"exit_node"`
+		ConnectedSince time.Time `json:"connected_since"`
+	}
+	captureJSON(t, printStatus, &got)
+
+	if got.State != "connected" || got.Network != "acme" || got.User != "ada@acme.example" ||
+		got.Interface != "sdwan0" || got.ExitNode != "Oslo" || !got.ConnectedSince.Equal(since) {
+		t.Errorf("status = %+v, want the daemon's properties", got)
+	}
+	if calls := runner.Calls(); len(calls) != 0 {
+		t.Errorf("ran %q while the daemon was running", calls)
+	}
+}
+
+func TestPrintResourcesAsksDaemon(t *testing.T) {
+	runner := twingate.NewFakeRunner()
+	useRunner(t, runner)
+	startControlDaemon(t, control.Handlers{
+		ListResources: func() ([]control.Resource, error) {
+			return []control.Resource{{Name: "Database", Address: "db.internal:5432", AuthStatus: "locked", NeedsAuth: true}}, nil
+		},
+	})
+
+	var got struct {
+		Resources []struct {
+			Name      string `json:"name"`
+			Address   string `json:"address"`
+			NeedsAuth bool   `json:"needs_auth"`
+		} `json:"resources"`
+	}
+	captureJSON(t, func() error { return printResources(nil) }, &got)
+
+	if len(got.Resources) != 1 || got.Resources[0].Name != "Database" || !got.Resources[0].NeedsAuth {
+		t.Errorf("resources = %+v, want the daemon's list", got.Resources)
+	}
+	if calls := runner.Calls(); len(calls) != 0 {
+		t.Errorf("ran %q while the daemon was running", calls)
+	}
+}
+
+func TestPrintResourcesWithoutDaemon(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: resourceList}, "twingate", "resources", "-d")
+	useRunner(t, runner)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+t.TempDir()+"/no-bus")
+
+	var got struct {
+		Resources []struct {
+			Name string `json:"name"`
+		} `json:"resources"`
+	}
+	captureJSON(t, func() error { return printResources(nil) }, &got)
+
+	if len(got.Resources) != 2 || got.Resources[0].Name != "Database" {
+		t.Errorf("resources = %+v, want the Twingate CLI's list", got.Resources)
+	}
+}
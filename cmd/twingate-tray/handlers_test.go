@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/config"
+	"github.com/bisand/twingate-tray/internal/dialog"
+	"github.com/bisand/twingate-tray/internal/twingate"
+)
+
+const (
+	exitNodeList = "Name\tLocation\tActive\nOslo\tNO\tfalse\nFrankfurt\tDE\ttrue\n"
+	resourceList = "Resource Name\tAddress\tAlias\tAuth Status\nDatabase\tdb.internal:5432\t\tlocked\nWiki\twiki.internal\t\tauthenticated\n"
+)
+
+// setupHandlers points the tray handlers at a fake twingate CLI and fake
+// dialogs, with the configuration in configTOML
+func setupHandlers(t *testing.T, runner *twingate.FakeRunner, dialogs *dialog.Fake, configTOML string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), config.FileName)
+	if err := os.WriteFile(path, []byte(configTOML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := config.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldSettings, oldState, oldClient := settings, appState, twingate.Default
+	settings, appState, twingate.Default = store, app.NewAppState(), twingate.NewClient(runner)
+	useDialogs(dialogs)
+	t.Cleanup(func() {
+		settings, appState, twingate.Default = oldSettings, oldState, oldClient
+	})
+}
+
+func useDialogs(fake *dialog.Fake) {
+	var backend dialog.Backend = fake
+	dialogs.Store(&backend)
+}
+
+// called reports whether the runner ran the command line
+func called(runner *twingate.FakeRunner, command string) bool {
+	return slices.Contains(runner.Calls(), command)
+}
+
+func TestHandleExitNodeListSwitches(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: exitNodeList}, "twingate", "exit-node", "list", "-d").
+		On(twingate.FakeResponse{}, "pkexec", "twingate", "exit-node", "switch", "Oslo")
+	fake := dialog.NewFake(dialog.Answer{Value: "Oslo", OK: true})
+	setupHandlers(t, runner, fake, "[dialogs.exit_nodes]\nwidth = 300\nheight = 200\n")
+
+	handleExitNodeList()
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].Kind != "list" {
+		t.Fatalf("dialogs = %+v, want one list", calls)
+	}
+	want := []string{"Stop Exit Node", "---", "Oslo", "Frankfurt (active)"}
+	if !slices.Equal(calls[0].Items, want) {
+		t.Errorf("items = %q, want %q", calls[0].Items, want)
+	}
+	if size := calls[0].Options.Size; size != (dialog.Size{Width: 300, Height: 200}) {
+		t.Errorf("size = %+v, want the configured 300x200", size)
+	}
+	if !called(runner, "pkexec twingate exit-node switch Oslo") {
+		t.Errorf("calls = %q, want a switch to Oslo", runner.Calls())
+	}
+}
+
+func TestHandleExitNodeListStripsActiveSuffix(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: exitNodeList}, "twingate", "exit-node", "list", "-d").
+		On(twingate.FakeResponse{}, "pkexec", "twingate", "exit-node", "switch", "Frankfurt")
+	setupHandlers(t, runner, dialog.NewFake(dialog.Answer{Value: "Frankfurt (active)", OK: true}), "")
+
+	handleExitNodeList()
+
+	if !called(runner, "pkexec twingate exit-node switch Frankfurt") {
+		t.Errorf("calls = %q, want a switch to Frankfurt", runner.Calls())
+	}
+}
+
+func TestHandleExitNodeListNoNodes(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: "No exit nodes available\n", ExitCode: 1}, "twingate", "exit-node", "list", "-d")
+	fake := dialog.NewFake()
+	setupHandlers(t, runner, fake, "")
+
+	handleExitNodeList()
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].Kind != "info" || !strings.Contains(calls[0].Text, "No exit nodes") {
+		t.Errorf("dialogs = %+v, want the no exit nodes message", calls)
+	}
+}
+
+func TestHandleExitNodeSwitchCancelled(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: exitNodeList}, "twingate", "exit-node", "list", "-d")
+	fake := dialog.NewFake() // Cancels
+	setupHandlers(t, runner, fake, "")
+
+	handleExitNodeSwitch()
+
+	calls := fake.Calls()
+	if len(calls) != 1 || !slices.Equal(calls[0].Items, []string{"Oslo", "Frankfurt"}) {
+		t.Errorf("dialogs = %+v, want the node list", calls)
+	}
+	if got := runner.Calls(); len(got) != 1 {
+		t.Errorf("calls = %q, want only the list after cancelling", got)
+	}
+}
+
+func TestHandleResourcesShowAuthenticates(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: resourceList}, "twingate", "resources", "-d").
+		On(twingate.FakeResponse{}, "twingate", "auth", "Database")
+	fake := dialog.NewFake(dialog.Answer{Value: "Database | db.internal:5432 [Locked]", OK: true})
+	setupHandlers(t, runner, fake, "")
+
+	handleResourcesShow()
+
+	// Authenticating is the only action, so it runs without asking
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("dialogs = %+v, want only the resource list", calls)
+	}
+	want := []string{"Database | db.internal:5432 [Locked]", "Wiki | wiki.internal"}
+	if !slices.Equal(calls[0].Items, want) {
+		t.Errorf("items = %q, want %q", calls[0].Items, want)
+	}
+	if !called(runner, "twingate auth Database") {
+		t.Errorf("calls = %q, want twingate auth Database", runner.Calls())
+	}
+}
+
+func TestHandleResourcesShowAsksForLauncher(t *testing.T) {
+	runner := twingate.NewFakeRunner().
+		On(twingate.FakeResponse{Output: resourceList}, "twingate", "resources", "-d")
+	fake := dialog.NewFake(dialog.Answer{Value: "Database | db.internal:5432 [Locked]", OK: true})
+	setupHandlers(t, runner, fake, "[[launchers]]\nname = \"psql\"\naddress = \"*:5432\"\ncommand = \"psql -h {host}\"\n")
+
+	handleResourcesShow()
+
+	calls := fake.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("dialogs = %+v, want the resource list and the actions", calls)
+	}
+	if got, want := calls[1].Items, []string{"Authenticate", "psql"}; !slices.Equal(got, want) {
+		t.Errorf("actions = %q, want %q", got, want)
+	}
+	if calls[1].Options.Title != "Database" {
+		t.Errorf("actions title = %q, want Database", calls[1].Options.Title)
+	}
+	// The second dialog was cancelled
+	if called(runner, "twingate auth Database") {
+		t.Error("authenticated after the action dialog was cancelled")
+	}
+}
+
+func TestShowConnectionInfo(t *testing.T) {
+	fake := dialog.NewFake() // Closes without copying
+	setupHandlers(t, twingate.NewFakeRunner(), fake, "[dialogs.connection_info]\nwidth = 640\nheight = 480\n")
+
+	showConnectionInfo(twingate.ConnectionInfo{Status: "Online", Network: "acme"})
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].Kind != "textinfo" || calls[0].Extra != "Copy to Clipboard" {
+		t.Fatalf("dialogs = %+v, want one text dialog with a copy button", calls)
+	}
+	if size := calls[0].Options.Size; size != (dialog.Size{Width: 640, Height: 480}) {
+		t.Errorf("size = %+v, want the configured 640x480", size)
+	}
+	if !strings.Contains(calls[0].Text, "Network:          acme") {
+		t.Errorf("text = %q, want the network", calls[0].Text)
+	}
+}
@@ -1,24 +1,63 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
-	"os/exec"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/config"
+	"github.com/bisand/twingate-tray/internal/control"
+	"github.com/bisand/twingate-tray/internal/dialog"
+	"github.com/bisand/twingate-tray/internal/history"
+	"github.com/bisand/twingate-tray/internal/launch"
+	"github.com/bisand/twingate-tray/internal/logind"
+	"github.com/bisand/twingate-tray/internal/netif"
+	"github.com/bisand/twingate-tray/internal/netmon"
+	"github.com/bisand/twingate-tray/internal/notify"
+	"github.com/bisand/twingate-tray/internal/portal"
+	"github.com/bisand/twingate-tray/internal/probe"
+	"github.com/bisand/twingate-tray/internal/systemd"
 	"github.com/bisand/twingate-tray/internal/tray"
 	"github.com/bisand/twingate-tray/internal/twingate"
+	"github.com/godbus/dbus/v5"
 )
 
 var (
 	appState   *app.AppState
 	systemTray *tray.SystemTray
 	lockFile   *app.LockFile
+	notifier   *notify.Notifier
+	controlSrv *control.Server
+	settings   *config.Store
+	journal    *history.Store
+	reconnect  *app.Reconnector
+	netWatcher *netmon.Watcher
+	poller     *app.Poller
+	linkWatch  *netif.LinkWatcher
+	scheme     *portal.ColorSchemeWatcher
+	probes     *probe.Monitor
+	traffic    = netif.NewMeter()
+
+	// dialogs shows every dialog; applyConfig picks the backend
+	dialogs atomic.Pointer[dialog.Backend]
+
+	// trayHandlers are the menu callbacks; notification actions reuse them
+	trayHandlers tray.CallbackHandlers
+
+	// daemonCtx is cancelled on shutdown to abort in-flight commands
+	daemonCtx    context.Context    = context.Background()
+	cancelDaemon context.CancelFunc = func() {}
 )
 
 func main() {
@@ -43,15 +82,45 @@ func startDaemon() {
 		os.Exit(1)
 	}
 
+	// Load user configuration; an invalid file falls back to the defaults
+	var err error
+	settings, err = config.NewStore(config.Path())
+	if err != nil {
+		log.Printf("Warning: %v - using default settings", err)
+	}
+
+	// Connection history; old entries are dropped once per start
+	journal = history.NewStore(history.Path())
+	if err := journal.Prune(time.Now().Add(-history.Retention)); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if closed, err := journal.CloseOpenSession(); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if closed {
+		log.Println("Closed the session left open by the previous run")
+	}
+
+	daemonCtx, cancelDaemon = context.WithCancel(context.Background())
+
 	appState = app.NewAppState()
+	reconnect = app.NewReconnector(daemonCtx, reconnectOnce)
+	poller = app.NewPoller(appState.Machine(), readStatus)
+	probes = probe.NewMonitor(probe.NewProber())
+	probes.Subscribe(func(map[string]probe.Result) { publishResources() })
+	applyConfig(settings.Get())
+	settings.OnChange(applyConfig)
+	appState.Machine().Subscribe(onStateTransition)
+	// Before notifyTransition, which stays quiet while a reconnect is running
+	appState.Machine().Subscribe(reconnect.HandleTransition)
+	appState.Machine().Subscribe(notifyTransition)
+	reconnect.Subscribe(onReconnectStatus)
 
 	// Detect auto-connect status from systemd
-	autoConnectEnabled := twingate.IsAutoConnectEnabled()
+	autoConnectEnabled := twingate.IsAutoConnectEnabled(daemonCtx)
 	log.Printf("Auto-connect detected: %v", autoConnectEnabled)
 
 	// Initialize system tray with all callback handlers
-	var err error
-	systemTray, err = tray.NewSystemTray(tray.CallbackHandlers{
+	trayHandlers = tray.CallbackHandlers{
 		OnConnect:          handleConnect,
 		OnDisconnect:       handleDisconnect,
 		OnConnectionInfo:   handleConnectionInfo,
@@ -61,6 +130,14 @@ func startDaemon() {
 		OnExitNodeList:     handleExitNodeList,
 		OnExitNodeSwitch:   handleExitNodeSwitch,
 		OnResourcesShow:    handleResourcesShow,
+		OnResourcesProbe:   handleResourcesProbe,
+		OnExitNodeSelect:   handleExitNodeSelect,
+		OnResourceSelect:   handleResourceSelect,
+		OnResourcePin:      handleResourcePin,
+		OnFavorite:         handleFavorite,
+		OnResourceLaunch:   handleResourceLaunch,
+		OnExitNodesOpening: refreshExitNodeMenu,
+		OnResourcesOpening: refreshResourcesMenu,
 		OnOpenWebAdmin:     handleOpenWebAdmin,
 		OnDiagReport:       handleDiagnosticReport,
 		OnAutoConnToggle:   handleAutoConnectToggle,
@@ -68,13 +145,20 @@ func startDaemon() {
 		OnAbout:            handleAbout,
 		OnQuit:             handleQuit,
 		InitialAutoConnect: autoConnectEnabled,
-	})
+	}
+
+	systemTray, err = tray.NewSystemTray(trayHandlers)
 
 	if err != nil {
 		log.Printf("Error: Could not initialize system tray: %v", err)
 		os.Exit(1)
 	}
 
+	// Pick icon colours and click actions before the host first reads the item
+	startColorSchemeWatcher()
+	systemTray.SetBindings(trayBindings(settings.Get().Tray))
+	systemTray.SetFavorites(settings.Get().Favorites.Resources)
+
 	err = systemTray.Start()
 	if err != nil {
 		log.Printf("Error: Could not start system tray: %v", err)
@@ -83,29 +167,80 @@ func startDaemon() {
 
 	log.Println("System tray initialized")
 
+	// Desktop notifications share the tray's session bus connection
+	notifier, err = notify.New(systemTray.Conn(), app.AppName, "twingate-tray",
+		settings.Get().Notifications.Timeout.Duration)
+	if err != nil {
+		log.Printf("Warning: Desktop notifications unavailable: %v", err)
+	}
+
+	// Export the control interface for the CLI and scripts
+	controlSrv = control.NewServer(systemTray.Conn(), control.Handlers{
+		Connect:        handleConnect,
+		Disconnect:     handleDisconnect,
+		Refresh:        handleControlRefresh,
+		SwitchExitNode: handleExitNodeSelect,
+		ListResources:  handleControlListResources,
+	})
+	if err := controlSrv.Start(); err != nil {
+		log.Printf("Warning: Control interface unavailable: %v", err)
+		controlSrv = nil
+	}
+
+	// Read the status once before the network watcher reports the current
+	// network, so its rules see the real state and not the initial default
+	updateStatus()
+
+	// Follow NetworkManager for the connect/disconnect network rules
+	startNetworkWatcher()
+
+	// Pause polling across suspend and resync on resume
+	startSleepWatcher()
+
+	// Poll immediately when the interface or service changes
+	startChangeWatchers()
+
 	// Setup signal handling for clean shutdown
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
 
 	go func() {
-		sig := <-sigChan
-		log.Printf("Received signal: %v - shutting down...", sig)
-		cleanup()
-		os.Exit(0)
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				log.Println("Received SIGHUP - reloading configuration...")
+				if err := settings.Reload(); err != nil {
+					log.Printf("Failed to reload configuration: %v", err)
+				}
+				continue
+			}
+			log.Printf("Received signal: %v - shutting down...", sig)
+			cleanup()
+			os.Exit(0)
+		}
 	}()
 
+	// Pick up edits to the configuration file without a restart
+	go settings.Watch(daemonCtx, configWatchInterval)
+
 	// Start status monitor in background
-	go monitorStatus()
+	log.Println("Starting status monitor...")
+	go poller.Run(daemonCtx)
+	go probes.Run(daemonCtx)
 
 	// Start connection timer updater
 	go updateConnectionTimer()
 
+	// Sample throughput for the menu, tooltip and history
+	go monitorTraffic()
+
 	// Keep running
 	select {}
 }
 
 func cleanup() {
 	log.Println("Cleaning up...")
+	cancelDaemon()
+	recordDaemonStop()
 	if systemTray != nil {
 		systemTray.Stop()
 	}
@@ -115,160 +250,478 @@ func cleanup() {
 	log.Println("Cleanup complete")
 }
 
-func handleCLI(args []string) {
-	if len(args) == 0 {
-		printUsage()
-		return
-	}
-
-	switch args[0] {
-	case "status":
-		connected, err := twingate.CheckStatus()
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
-		}
-		if connected {
-			fmt.Println("connected")
-		} else {
-			fmt.Println("disconnected")
-		}
-
-	case "connect":
-		if err := twingate.Connect(); err != nil {
-			fmt.Printf("Error connecting: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Println("Connection initiated")
+// configWatchInterval is how often the configuration file is checked for changes
+const configWatchInterval = 2 * time.Second
 
-	case "disconnect":
-		if err := twingate.Disconnect(); err != nil {
-			fmt.Printf("Error disconnecting: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Println("Disconnection initiated")
+// applyConfig pushes the configuration to every component that uses it.
+// It runs at startup and after each successful reload.
+func applyConfig(cfg *config.Config) {
+	poller.SetStabilityThreshold(cfg.Status.StabilityThreshold)
+	poller.SetIntervals(cfg.Status.PollInterval.Duration, cfg.Status.IdlePollInterval.Duration)
+	if linkWatch != nil {
+		linkWatch.SetInterface(cfg.Network.Interface)
+	}
+	reconnect.SetPolicy(reconnectPolicy(cfg.Reconnect))
 
-	case "daemon":
-		// Start as daemon with system tray
-		startDaemon()
+	twingate.Default.SetInterface(cfg.Network.Interface)
+	twingate.Default.SetDefaultTimeout(cfg.Commands.Timeout.Duration)
+	twingate.Default.SetTimeout("pkexec", cfg.Commands.PrivilegedTimeout.Duration)
+	twingate.Default.SetTimeout("sudo", cfg.Commands.PrivilegedTimeout.Duration)
 
-	case "help", "-h", "--help":
-		printUsage()
+	if notifier != nil {
+		notifier.SetTimeout(cfg.Notifications.Timeout.Duration)
+	}
+	probes.Prober().SetTimeout(cfg.Probe.Timeout.Duration)
+	probes.Prober().SetPorts(cfg.Probe.Ports)
+	scheduleProbes(cfg)
 
-	case "version", "-v", "--version":
-		fmt.Println(app.GetFullVersion())
-		fmt.Println(app.GetVersionInfo())
+	backend, err := dialog.Select(cfg.Dialogs.Backend, dialog.NewFallback(sendNotification))
+	if err != nil {
+		log.Printf("Warning: %v - showing messages as notifications", err)
+	}
+	if old := dialogs.Swap(&backend); old == nil || (*old).Name() != backend.Name() {
+		log.Printf("Using %s for dialogs", backend.Name())
+	}
+	if systemTray != nil {
+		systemTray.SetIconStyle(iconStyle(cfg))
+		systemTray.SetBindings(trayBindings(cfg.Tray))
+		systemTray.SetFavorites(cfg.Favorites.Resources)
+	}
+	publishResources() // Launchers may have changed
+}
 
-	default:
-		fmt.Printf("Unknown command: %s\n", args[0])
-		printUsage()
-		os.Exit(1)
+// iconStyle resolves the icon settings. With color_scheme "auto" the panel is
+// assumed dark unless the desktop portal reports a light preference.
+func iconStyle(cfg *config.Config) tray.IconStyle {
+	light := cfg.Icon.ColorScheme == config.ColorSchemeLight
+	if cfg.Icon.ColorScheme == config.ColorSchemeAuto && scheme != nil {
+		light = scheme.Scheme() == portal.PreferLight
+	}
+	colors, names := cfg.Icon.Colors, cfg.Icon.Names
+	return tray.IconStyle{
+		LightPanel:   light,
+		Connected:    colors.Connected.RGBA,
+		Disconnected: colors.Disconnected.RGBA,
+		Connecting:   colors.Connecting.RGBA,
+		Error:        colors.Error.RGBA,
+		ThemeIcons:   cfg.Icon.ThemeIcons,
+		ThemePath:    cfg.Icon.ThemePath,
+		Names: tray.IconNames{
+			Connected:    names.Connected,
+			Disconnected: names.Disconnected,
+			Connecting:   names.Connecting,
+			Error:        names.Error,
+		},
 	}
 }
 
-func printUsage() {
-	fmt.Println(`Twingate Tray - System tray indicator for Twingate
+// startColorSchemeWatcher follows the desktop's colour scheme so the icon
+// stays visible when it switches between light and dark
+func startColorSchemeWatcher() {
+	scheme = portal.NewColorSchemeWatcher(systemTray.Conn())
+	scheme.Subscribe(func(s portal.ColorScheme) {
+		log.Printf("Desktop colour scheme changed to %s", s)
+		systemTray.SetIconStyle(iconStyle(settings.Get()))
+	})
+	if err := scheme.Start(daemonCtx); err != nil {
+		log.Printf("Warning: Colour scheme detection unavailable: %v", err)
+		scheme = nil
+	}
+	systemTray.SetIconStyle(iconStyle(settings.Get()))
+}
 
-Usage:
-  twingate-tray                    # Run with system tray
-  twingate-tray status             # Check connection status
-  twingate-tray connect            # Connect to Twingate
-  twingate-tray disconnect         # Disconnect from Twingate
-  twingate-tray daemon             # Start as daemon with system tray
-  twingate-tray version            # Show version information
-  twingate-tray help               # Show this help message`)
+// dialogBackend returns the backend chosen by the current configuration
+func dialogBackend() dialog.Backend {
+	return *dialogs.Load()
 }
 
-func monitorStatus() {
-	log.Println("Starting status monitor...")
-	ticker := time.NewTicker(app.StatusPollInterval)
-	defer ticker.Stop()
+// showMessageDialog shows an info dialog
+func showMessageDialog(title, text string) {
+	opts := dialog.Options{Title: title, Size: dialog.Size(settings.Get().Dialogs.Message)}
+	if err := dialogBackend().Info(opts, text); err != nil {
+		log.Printf("Failed to show %q dialog: %v", title, err)
+	}
+}
 
-	var prevConnected *bool
-	var stableCount int
-	const stabilityThreshold = 3 // Require 3 consistent readings before changing state
+// startChangeWatchers triggers an immediate status poll when the Twingate
+// interface or service changes. Without them the poller still catches every
+// change on its idle interval.
+func startChangeWatchers() {
+	linkWatch = netif.NewLinkWatcher(settings.Get().Network.Interface)
+	linkWatch.Subscribe(poller.Trigger)
+	if err := linkWatch.Start(daemonCtx); err != nil {
+		log.Printf("Warning: Interface change detection unavailable: %v", err)
+		linkWatch = nil
+	}
 
-	for range ticker.C {
-		updateStatus()
-
-		// Get current connection status
-		connected := appState.IsConnected()
-
-		// Debounce: Only change state if we get consistent readings
-		if prevConnected == nil {
-			// First reading - initialize and log the initial state
-			log.Printf("Initial status: connected=%v", connected)
-			prevConnected = &connected
-			stableCount = 1
-
-			// Log and notify initial state
-			if connected {
-				log.Println("Status: Connected to Twingate")
-				updateNetworkInfo()
-			} else {
-				log.Println("Status: Disconnected from Twingate")
-			}
-		} else if *prevConnected == connected {
-			// Status unchanged - reset stability counter
-			stableCount = 0
-		} else {
-			// Status changed - increment stability counter
-			stableCount++
-
-			if stableCount >= stabilityThreshold {
-				// Status has been consistent for threshold readings - accept the change
-				log.Printf("Status change detected after %d stable readings: %v -> %v",
-					stableCount, *prevConnected, connected)
-
-				if connected {
-					log.Println("Status: Connected to Twingate")
-					sendNotification("Twingate Connected", "You are now connected to Twingate")
-
-					// Fetch and update network info on connect
-					updateNetworkInfo()
-				} else {
-					log.Println("Status: Disconnected from Twingate")
-					sendNotification("Twingate Disconnected", "You are now disconnected from Twingate")
-				}
-				prevConnected = &connected
-				stableCount = 0
-			} else {
-				log.Printf("Status fluctuation detected (%d/%d): current=%v, stable=%v",
-					stableCount, stabilityThreshold, connected, *prevConnected)
-			}
-		}
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		log.Printf("Warning: Service change detection unavailable: %v", err)
+		return
+	}
+	unitWatch := systemd.NewUnitWatcher(conn, twingate.ServiceUnit)
+	unitWatch.Subscribe(func(activeState string) {
+		poller.Trigger(twingate.ServiceUnit + " " + activeState)
+	})
+	if err := unitWatch.Start(daemonCtx); err != nil {
+		log.Printf("Warning: Service change detection unavailable: %v", err)
 	}
 }
 
-// updateStatus checks current Twingate status and updates the app state
+// updateStatus polls the status right away, e.g. for a manual refresh
 func updateStatus() {
-	connected, err := twingate.CheckStatus()
+	poller.Poll(daemonCtx)
+}
 
-	appState.SetConnected(connected)
+// readStatus checks current Twingate status for the poller
+func readStatus(ctx context.Context) (app.ConnectionState, error) {
+	status, err := twingate.GetStatus(ctx)
 
 	if err != nil {
-		appState.SetLastError(err.Error())
 		// Only log errors occasionally to avoid log spam
 		// We expect some transient failures during connection changes
 		if appState.GetLastError() != err.Error() {
-			log.Printf("Status check error: %v", err)
+			if twingate.IsTimeout(err) {
+				log.Printf("Status check timed out: %v", err)
+			} else {
+				log.Printf("Status check error: %v", err)
+			}
 		}
+		appState.SetLastError(err.Error())
 	} else {
 		if appState.GetLastError() != "" {
 			log.Println("Status check recovered from error")
 		}
 		appState.SetLastError("")
 	}
+	refreshLastError()
+
+	return observedState(status), err
+}
+
+// refreshLastError shows the status check error in the tooltip, or the error
+// that put the connection into the Error state
+func refreshLastError() {
+	if systemTray == nil {
+		return
+	}
+	lastErr := appState.GetLastError()
+	if lastErr == "" && appState.State() == app.StateError {
+		lastErr = appState.Machine().LastError()
+	}
+	systemTray.SetLastError(lastErr)
+}
+
+// observedState maps a `twingate status` reading to a connection state
+func observedState(status twingate.Status) app.ConnectionState {
+	switch status {
+	case twingate.StatusOnline:
+		return app.StateConnected
+	case twingate.StatusConnecting:
+		return app.StateConnecting
+	case twingate.StatusAuthenticating:
+		return app.StateAuthenticating
+	default:
+		return app.StateDisconnected
+	}
+}
+
+// onStateTransition logs transitions and keeps the tray in sync
+func onStateTransition(t app.Transition) {
+	if t.Initial {
+		log.Printf("Initial status: %s", t.To)
+	} else {
+		log.Printf("Status change: %s -> %s (%s)", t.From, t.To, t.Reason)
+	}
 
-	// Update system tray icon based on current status
 	if systemTray != nil {
-		systemTray.UpdateStatus(connected)
+		systemTray.UpdateState(t.To)
+		if t.To != app.StateConnected {
+			systemTray.SetTraffic("")
+		}
+		refreshLastError()
+	}
+
+	if controlSrv != nil {
+		reason := t.Reason
+		if t.To == app.StateError {
+			reason = t.Err
+		}
+		// The exit node is read again once connected
+		if t.To == app.StateConnected {
+			controlSrv.SetInterface(settings.Get().Network.Interface)
+		} else {
+			controlSrv.SetInterface("")
+			controlSrv.SetExitNode("")
+		}
+		controlSrv.SetState(t.To.String(), reason, appState.GetConnectedSince())
+	}
+
+	event, record := historyEvent(t)
+
+	// Fetch and update network info and submenus on connect
+	if t.To == app.StateConnected && t.From != app.StateConnected {
+		refreshConnectionTime()
+		go func() {
+			updateNetworkInfo()
+			refreshExitNodeMenu()
+			if record {
+				// Record once the network and exit node are known
+				event.Network = appState.GetNetworkName()
+				event.ExitNode = appState.GetExitNode()
+				appendHistory(event)
+			}
+		}()
+		go func() {
+			refreshResourcesMenu()
+			if settings.Get().Probe.Enabled {
+				probes.Trigger()
+			}
+		}()
+	} else if record {
+		appendHistory(event)
+	}
+
+	if t.From == app.StateConnected || t.To == app.StateConnected {
+		scheduleProbes(settings.Get())
 	}
 }
 
+// historySession tracks whether the journal has an open connected session
+var (
+	historyMu      sync.Mutex
+	historySession bool
+)
+
+// historyEvent builds the journal entry for a settled connection change.
+// It returns false for transitional states and for exit-node changes, which
+// pass through Connecting without ending the session.
+func historyEvent(t app.Transition) (history.Event, bool) {
+	event := history.Event{Time: t.At, Reason: t.Reason, Error: t.Err}
+	switch t.To {
+	case app.StateConnected:
+		event.Kind = history.KindConnected
+	case app.StateDisconnected:
+		event.Kind = history.KindDisconnected
+	case app.StateError:
+		event.Kind = history.KindError
+	default:
+		return event, false
+	}
+	if !t.Initial {
+		event.From = t.From.String()
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if event.Kind == history.KindConnected {
+		if historySession {
+			return event, false
+		}
+		historySession = true
+		traffic.Reset()
+		return event, true
+	}
+
+	if historySession {
+		totals := sessionTraffic()
+		event.RxBytes, event.TxBytes = totals.RxTotal, totals.TxTotal
+	}
+	event.Drop = historySession && !strings.HasPrefix(t.Reason, app.OpDisconnect.String())
+	event.Network = appState.GetNetworkName()
+	event.ExitNode = appState.GetExitNode()
+	historySession = false
+	return event, true
+}
+
+// recordSleep closes the open session so sleep time is not counted as connected
+func recordSleep() {
+	historyMu.Lock()
+	open := historySession
+	historySession = false
+	historyMu.Unlock()
+
+	event := history.Event{
+		Time:     time.Now(),
+		Kind:     history.KindSleep,
+		Network:  appState.GetNetworkName(),
+		ExitNode: appState.GetExitNode(),
+	}
+	if open {
+		totals := sessionTraffic()
+		event.RxBytes, event.TxBytes = totals.RxTotal, totals.TxTotal
+	}
+	appendHistory(event)
+}
+
+// recordSessionResumed opens a new session when the connection survived a
+// suspend, which causes no state transition
+func recordSessionResumed() {
+	historyMu.Lock()
+	resumed := !historySession
+	historySession = true
+	historyMu.Unlock()
+
+	if resumed {
+		traffic.Reset()
+		appendHistory(history.Event{
+			Time:     time.Now(),
+			Kind:     history.KindConnected,
+			Reason:   "resumed",
+			Network:  appState.GetNetworkName(),
+			ExitNode: appState.GetExitNode(),
+		})
+	}
+}
+
+// recordDaemonStop closes the open session when the tray exits
+func recordDaemonStop() {
+	historyMu.Lock()
+	open := historySession
+	historySession = false
+	historyMu.Unlock()
+
+	if open {
+		totals := sessionTraffic()
+		appendHistory(history.Event{
+			Time:    time.Now(),
+			Kind:    history.KindDaemonStop,
+			RxBytes: totals.RxTotal,
+			TxBytes: totals.TxTotal,
+		})
+	}
+}
+
+// trafficSampleInterval is how often the interface counters are sampled
+const trafficSampleInterval = 2 * time.Second
+
+// monitorTraffic samples the Twingate interface counters while connected
+func monitorTraffic() {
+	ticker := time.NewTicker(trafficSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-daemonCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !appState.IsConnected() {
+			continue
+		}
+		stats, err := netif.ReadStats(twingate.Default.Interface())
+		if err != nil {
+			// The interface goes away while disconnecting
+			continue
+		}
+		current := traffic.Add(stats, time.Now())
+		if systemTray != nil {
+			systemTray.SetTraffic(current.String())
+		}
+	}
+}
+
+// sessionTraffic takes a final sample and returns the session's traffic
+func sessionTraffic() netif.Traffic {
+	if stats, err := netif.ReadStats(twingate.Default.Interface()); err == nil {
+		traffic.Add(stats, time.Now())
+	}
+	return traffic.Traffic()
+}
+
+func appendHistory(event history.Event) {
+	if journal == nil {
+		return
+	}
+	if err := journal.Append(event); err != nil {
+		log.Printf("Failed to record history: %v", err)
+	}
+}
+
+// notifyTransition sends a desktop notification for settled state changes
+func notifyTransition(t app.Transition) {
+	if t.Initial {
+		return
+	}
+	switch t.To {
+	case app.StateConnected:
+		// Exit-node changes pass through Connecting; they notify on their own
+		if strings.HasPrefix(t.Reason, app.OpExitNode.String()) {
+			return
+		}
+		// The reconnect supervisor reports its own outcome
+		if strings.HasPrefix(t.Reason, app.OpReconnect.String()) {
+			go notifyLockedResources()
+			return
+		}
+		go notifyUser(notify.Notification{
+			Title: "Twingate Connected",
+			Body:  "You are now connected to Twingate",
+			Tag:   tagStatus,
+		})
+		go notifyLockedResources()
+	case app.StateDisconnected:
+		if reconnect.Running() {
+			return // The supervisor reports the lost connection
+		}
+		go notifyUser(notify.Notification{
+			Title:   "Twingate Disconnected",
+			Body:    "You are now disconnected from Twingate",
+			Tag:     tagStatus,
+			Actions: []notify.Action{{Key: "reconnect", Label: "Reconnect", Run: trayHandlers.OnConnect}},
+		})
+	case app.StateError:
+		if reconnect.Running() {
+			return // The supervisor retries or gives up
+		}
+		go notifyUser(notify.Notification{
+			Title:   "Twingate Error",
+			Body:    t.Err,
+			Tag:     tagStatus,
+			Urgency: notify.UrgencyCritical,
+			Actions: []notify.Action{{Key: "reconnect", Label: "Reconnect", Run: trayHandlers.OnConnect}},
+		})
+	}
+}
+
+// notifyLockedResources tells the user about resources that need authentication
+func notifyLockedResources() {
+	resources, err := twingate.GetResources(daemonCtx)
+	if err != nil {
+		log.Printf("Failed to get resources: %v", err)
+		return
+	}
+
+	var locked []twingate.Resource
+	for _, res := range resources {
+		if res.NeedsAuth {
+			locked = append(locked, res)
+		}
+	}
+	if len(locked) == 0 {
+		return
+	}
+
+	body := fmt.Sprintf("%d resources require authentication", len(locked))
+	authenticate := trayHandlers.OnResourcesShow
+	if len(locked) == 1 {
+		res := locked[0]
+		body = fmt.Sprintf("%s requires authentication", res.Name)
+		authenticate = func() { authenticateResource(res) }
+	}
+
+	notifyUser(notify.Notification{
+		Title:   "Authentication Required",
+		Body:    body,
+		Tag:     tagResources,
+		Actions: []notify.Action{{Key: "authenticate", Label: "Authenticate", Run: authenticate}},
+	})
+}
+
 // updateNetworkInfo fetches and updates network information in the tray
 func updateNetworkInfo() {
-	info, err := twingate.GetNetworkInfo()
+	info, err := twingate.GetNetworkInfo(daemonCtx)
 	if err != nil {
 		log.Printf("Failed to get network info: %v", err)
 		return
@@ -279,6 +732,11 @@ func updateNetworkInfo() {
 
 	if systemTray != nil {
 		systemTray.UpdateNetworkInfo(info.Name, info.URL)
+		systemTray.SetUser(info.User)
+	}
+	if controlSrv != nil {
+		controlSrv.SetNetwork(info.Name)
+		controlSrv.SetUser(info.User)
 	}
 }
 
@@ -288,21 +746,26 @@ func updateConnectionTimer() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		if !appState.IsConnected() {
-			continue
-		}
+		refreshConnectionTime()
+	}
+}
 
-		duration := appState.GetConnectionDuration()
-		if duration == 0 {
-			continue
-		}
+// refreshConnectionTime shows how long the connection has been up
+func refreshConnectionTime() {
+	if !appState.IsConnected() {
+		return
+	}
 
-		// Format duration nicely
-		timeStr := formatDuration(duration)
+	duration := appState.GetConnectionDuration()
+	if duration == 0 {
+		return
+	}
 
-		if systemTray != nil {
-			systemTray.UpdateConnectionTime(timeStr)
-		}
+	// Format duration nicely
+	timeStr := formatDuration(duration)
+
+	if systemTray != nil {
+		systemTray.UpdateConnectionTime(timeStr)
 	}
 }
 
@@ -330,66 +793,632 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dd", days)
 }
 
+// Notification tags - notifications sharing a tag update in place
+const (
+	tagStatus    = "status"
+	tagExitNode  = "exit-node"
+	tagResources = "resources"
+)
+
 func sendNotification(title, body string) {
-	// Try to send via notify-send if available
-	cmd := exec.Command("notify-send", "-a", "Twingate Tray", "-t", fmt.Sprintf("%d", app.NotificationTimeout), title, body)
-	// Ignore errors - notification is optional
-	_ = cmd.Run()
+	notifyUser(notify.Notification{Title: title, Body: body})
+}
+
+// notifyExitNode shows an exit node notification, replacing the previous one
+func notifyExitNode(title, body string) {
+	notifyUser(notify.Notification{Title: title, Body: body, Tag: tagExitNode})
+}
+
+// notifyUser sends a notification through the desktop notification service
+func notifyUser(n notify.Notification) {
+	if notifier == nil {
+		log.Printf("Notification: %s - %s", n.Title, n.Body)
+		return
+	}
+	if _, err := notifier.Send(n); err != nil {
+		log.Printf("Failed to send notification %q: %v", n.Title, err)
+	}
 }
 
 // Handler functions for tray callbacks
 
 func handleConnect() {
-	if err := twingate.Connect(); err != nil {
+	reconnect.SetIntent(true)
+	machine := appState.Machine()
+	machine.BeginOperation(app.OpConnect)
+	err := twingate.Connect(daemonCtx)
+	machine.EndOperation(app.OpConnect, err)
+	if err != nil {
 		log.Printf("Connect failed: %v", err)
-		sendNotification("Connection Failed", fmt.Sprintf("Failed to connect: %v", err))
+		notifyUser(notify.Notification{
+			Title:   "Connection Failed",
+			Body:    fmt.Sprintf("Failed to connect: %v", err),
+			Tag:     tagStatus,
+			Urgency: notify.UrgencyCritical,
+			Actions: []notify.Action{{Key: "reconnect", Label: "Retry", Run: trayHandlers.OnConnect}},
+		})
 	}
 }
 
 func handleDisconnect() {
-	if err := twingate.Disconnect(); err != nil {
+	// Record the intent first so the drop is not mistaken for a lost connection
+	reconnect.SetIntent(false)
+	machine := appState.Machine()
+	machine.BeginOperation(app.OpDisconnect)
+	err := twingate.Disconnect(daemonCtx)
+	machine.EndOperation(app.OpDisconnect, err)
+	if err != nil {
 		log.Printf("Disconnect failed: %v", err)
-		sendNotification("Disconnection Failed", fmt.Sprintf("Failed to disconnect: %v", err))
+		notifyUser(notify.Notification{
+			Title:   "Disconnection Failed",
+			Body:    fmt.Sprintf("Failed to disconnect: %v", err),
+			Tag:     tagStatus,
+			Urgency: notify.UrgencyCritical,
+		})
+	}
+}
+
+// startNetworkWatcher follows NetworkManager on the system bus. Without it the
+// network rules simply never fire.
+func startNetworkWatcher() {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		log.Printf("Warning: Network rules unavailable: %v", err)
+		return
+	}
+	netWatcher = netmon.NewWatcher(conn)
+	netWatcher.Subscribe(onNetworkChanged)
+	if err := netWatcher.Start(daemonCtx); err != nil {
+		log.Printf("Warning: Network rules unavailable: %v", err)
+		netWatcher = nil
+	}
+}
+
+// startSleepWatcher follows systemd-logind's suspend/resume signal
+func startSleepWatcher() {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		log.Printf("Warning: Suspend/resume handling unavailable: %v", err)
+		return
+	}
+	watcher := logind.NewSleepWatcher(conn, app.AppName)
+	watcher.Subscribe(onSleep)
+	if err := watcher.Start(daemonCtx); err != nil {
+		log.Printf("Warning: Suspend/resume handling unavailable: %v", err)
+	}
+}
+
+// connectedBeforeSleep is the connection state recorded before the last suspend
+var connectedBeforeSleep atomic.Bool
+
+// onSleep records the state and pauses polling before suspend, and forces a
+// fresh status after resume instead of waiting for the debounce
+func onSleep(sleeping bool) {
+	if sleeping {
+		state := appState.State()
+		connectedBeforeSleep.Store(state == app.StateConnected)
+		poller.SetPaused(true)
+		log.Printf("System is going to sleep while %s - pausing status polling", state)
+		recordSleep()
+		return
+	}
+
+	log.Println("System resumed - refreshing status")
+	appendHistory(history.Event{Time: time.Now(), Kind: history.KindWake})
+	appState.Machine().Resync()
+	poller.SetPaused(false)
+	updateStatus()
+	go updateNetworkInfo()
+
+	if appState.State() == app.StateConnected {
+		recordSessionResumed()
+		return
+	}
+	if connectedBeforeSleep.Load() && settings.Get().Sleep.ReconnectOnWake {
+		log.Println("Reconnecting after resume")
+		go handleConnect()
+	}
+}
+
+// onNetworkChanged applies the first matching network rule when the primary
+// connection changes. Rules only act on changes, so the user can still
+// override them from the menu afterwards.
+func onNetworkChanged(network netmon.Network) {
+	log.Printf("Primary network: %s", network)
+
+	cfg := settings.Get().NetworkRules
+	if !cfg.Enabled {
+		return
+	}
+	rule, ok := netmon.Evaluate(networkRules(cfg), network)
+	if !ok || rule.Action == netmon.ActionNone {
+		return
+	}
+
+	state := appState.State()
+	if state.IsTransitional() {
+		log.Printf("Network rule %s skipped: connection is %s", rule, state)
+		return
+	}
+
+	switch rule.Action {
+	case netmon.ActionConnect:
+		if state == app.StateConnected {
+			return
+		}
+		log.Printf("Network rule %s: connecting", rule)
+		go notifyUser(notify.Notification{
+			Title: "Twingate Network Rule",
+			Body:  fmt.Sprintf("Connecting on %s", network),
+			Tag:   tagStatus,
+		})
+		go handleConnect()
+	case netmon.ActionDisconnect:
+		if state != app.StateConnected {
+			return
+		}
+		log.Printf("Network rule %s: disconnecting", rule)
+		go notifyUser(notify.Notification{
+			Title: "Twingate Network Rule",
+			Body:  fmt.Sprintf("Disconnecting on %s", network),
+			Tag:   tagStatus,
+		})
+		go handleDisconnect()
+	}
+}
+
+// reconnectOnce is a single attempt of the reconnect supervisor
+func reconnectOnce(ctx context.Context) error {
+	machine := appState.Machine()
+	machine.BeginOperation(app.OpReconnect)
+	err := twingate.Connect(ctx)
+	machine.EndOperation(app.OpReconnect, err)
+	if err != nil {
+		log.Printf("Reconnect attempt failed: %v", err)
+	}
+	return err
+}
+
+// onReconnectStatus shows reconnect progress in the tooltip and notifications
+func onReconnectStatus(s app.ReconnectStatus) {
+	if systemTray != nil {
+		systemTray.SetStatusDetail(s.Summary())
+	}
+
+	switch s.Phase {
+	case app.ReconnectWaiting:
+		wait := time.Until(s.NextAttempt).Round(time.Second)
+		body := fmt.Sprintf("Reconnecting in %s (attempt %d of %d)", wait, s.Attempt, s.MaxAttempts)
+		if s.Attempt > 1 && s.Err != "" {
+			body = fmt.Sprintf("Attempt %d failed: %s\n%s", s.Attempt-1, s.Err, body)
+		}
+		log.Printf("Reconnect attempt %d of %d in %s", s.Attempt, s.MaxAttempts, wait)
+		go notifyUser(notify.Notification{
+			Title:   "Twingate Connection Lost",
+			Body:    body,
+			Tag:     tagStatus,
+			Urgency: notify.UrgencyLow,
+			Actions: []notify.Action{{Key: "disconnect", Label: "Stop", Run: trayHandlers.OnDisconnect}},
+		})
+	case app.ReconnectAttempting:
+		log.Printf("Reconnect attempt %d of %d", s.Attempt, s.MaxAttempts)
+		go notifyUser(notify.Notification{
+			Title:   "Twingate Reconnecting",
+			Body:    fmt.Sprintf("Attempt %d of %d…", s.Attempt, s.MaxAttempts),
+			Tag:     tagStatus,
+			Urgency: notify.UrgencyLow,
+		})
+	case app.ReconnectSucceeded:
+		log.Printf("Reconnected after %d attempt(s)", s.Attempt)
+		go notifyUser(notify.Notification{
+			Title: "Twingate Reconnected",
+			Body:  fmt.Sprintf("Connection restored after %d attempt(s)", s.Attempt),
+			Tag:   tagStatus,
+		})
+	case app.ReconnectGaveUp:
+		log.Printf("Gave up reconnecting after %d attempts: %s", s.Attempt, s.Err)
+		go notifyUser(notify.Notification{
+			Title:   "Twingate Reconnect Failed",
+			Body:    fmt.Sprintf("Gave up after %d attempts: %s", s.Attempt, s.Err),
+			Tag:     tagStatus,
+			Urgency: notify.UrgencyCritical,
+			Actions: []notify.Action{{Key: "reconnect", Label: "Retry", Run: trayHandlers.OnConnect}},
+		})
 	}
 }
 
 func handleConnectionInfo() {
-	twingate.ShowConnectionInfo()
+	showConnectionInfo(twingate.GetConnectionInfo(daemonCtx))
+}
+
+// showConnectionInfo displays the connection information as scrollable,
+// selectable text with a Copy button
+func showConnectionInfo(info twingate.ConnectionInfo) {
+	text := info.PlainText()
+	opts := dialog.Options{
+		Title: "Twingate Connection Information",
+		Size:  dialog.Size(settings.Get().Dialogs.ConnectionInfo),
+	}
+
+	for {
+		copyPressed, err := dialogBackend().TextInfo(opts, text, "Copy to Clipboard")
+		if err != nil {
+			log.Printf("Status dialog error: %v", err)
+		}
+		if !copyPressed {
+			return
+		}
+		if err := twingate.CopyToClipboard(text); err != nil {
+			log.Printf("Failed to copy to clipboard: %v", err)
+			continue
+		}
+		sendNotification("Twingate", "Connection info copied to clipboard")
+		// Re-show the dialog so the user can dismiss with OK
+	}
 }
 
 func handleRefreshStatus() {
 	log.Println("Refreshing status...")
 	updateStatus()
 	updateNetworkInfo()
-	sendNotification("Status Refreshed", "Twingate status has been refreshed")
+	notifyUser(notify.Notification{
+		Title:   "Status Refreshed",
+		Body:    "Twingate status has been refreshed",
+		Tag:     tagStatus,
+		Urgency: notify.UrgencyLow,
+	})
 }
 
 func handleExitNodeStart() {
 	log.Println("Starting exit node...")
-	if err := twingate.StartExitNode(); err != nil {
+	if err := runExitNodeOperation(twingate.StartExitNode); err != nil {
 		log.Printf("Failed to start exit node: %v", err)
-		sendNotification("Exit Node Failed", fmt.Sprintf("Failed to start exit node: %v", err))
+		notifyExitNode("Exit Node Failed", fmt.Sprintf("Failed to start exit node: %v", err))
 	} else {
-		sendNotification("Exit Node Started", "All traffic is now routed through Twingate")
+		notifyExitNode("Exit Node Started", "All traffic is now routed through Twingate")
 	}
+	refreshExitNodeMenu()
 }
 
 func handleExitNodeStop() {
 	log.Println("Stopping exit node...")
-	if err := twingate.StopExitNode(); err != nil {
+	if err := runExitNodeOperation(twingate.StopExitNode); err != nil {
 		log.Printf("Failed to stop exit node: %v", err)
-		sendNotification("Exit Node Failed", fmt.Sprintf("Failed to stop exit node: %v", err))
+		notifyExitNode("Exit Node Failed", fmt.Sprintf("Failed to stop exit node: %v", err))
+	} else {
+		notifyExitNode("Exit Node Stopped", "Split tunnel mode restored")
+	}
+	refreshExitNodeMenu()
+}
+
+// runExitNodeOperation runs an exit-node command while the state machine
+// reports the tunnel as reconnecting
+func runExitNodeOperation(op func(context.Context) error) error {
+	machine := appState.Machine()
+	machine.BeginOperation(app.OpExitNode)
+	err := op(daemonCtx)
+	machine.EndOperation(app.OpExitNode, err)
+	return err
+}
+
+// refreshExitNodeMenu reloads the exit node list shown in the tray submenu
+func refreshExitNodeMenu() {
+	status, err := twingate.GetExitNodeStatus(daemonCtx)
+	if err != nil {
+		log.Printf("Failed to get exit node status: %v", err)
+		return
+	}
+	node := ""
+	if status.Enabled {
+		node = status.CurrentNode
+	}
+	appState.SetExitNode(node)
+	if controlSrv != nil {
+		controlSrv.SetExitNode(node)
+	}
+	if systemTray != nil {
+		systemTray.SetExitNodes(status.Enabled, status.CurrentNode, status.AvailableNodes)
+	}
+}
+
+// resourceCache is the resource list last shown in the tray submenu
+var resourceCache struct {
+	sync.Mutex
+	loaded    bool
+	resources []twingate.Resource
+}
+
+// refreshResourcesMenu reloads the resource list shown in the tray submenu
+// and the addresses the prober checks
+func refreshResourcesMenu() {
+	resources, err := twingate.GetResources(daemonCtx)
+	if err != nil {
+		log.Printf("Failed to get resources: %v", err)
+		return
+	}
+	resourceCache.Lock()
+	resourceCache.loaded = true
+	resourceCache.resources = resources
+	resourceCache.Unlock()
+
+	addresses := make([]string, 0, len(resources))
+	for _, res := range resources {
+		addresses = append(addresses, res.Address)
+	}
+	probes.SetTargets(addresses)
+	publishResources()
+}
+
+// publishResources shows the cached resources with their latest probe results
+func publishResources() {
+	if systemTray == nil {
+		return
+	}
+	resourceCache.Lock()
+	loaded, resources := resourceCache.loaded, resourceCache.resources
+	resourceCache.Unlock()
+	if !loaded {
+		return // Keep showing "Loading…"
+	}
+
+	launchers := compileLaunchers(settings.Get().Launchers)
+	items := make([]tray.ResourceItem, 0, len(resources))
+	for _, res := range resources {
+		item := tray.ResourceItem{Name: res.Name, Address: res.Address, Locked: res.NeedsAuth}
+		if target, err := probe.ParseAddress(res.Address); err == nil {
+			item.Host, _ = target.SingleHost()
+		}
+		for _, l := range launch.Match(launchers, res.Name, res.Address) {
+			item.Launchers = append(item.Launchers, l.Name)
+		}
+		// Ranges and wildcards cannot be probed; say nothing rather than "not probed"
+		if result, ok := probes.Result(res.Address); ok && result.Status != probe.StatusSkipped {
+			item.Health = result.Summary()
+		}
+		items = append(items, item)
+	}
+	systemTray.SetResources(items)
+}
+
+// scheduleProbes probes resources periodically while connected, if enabled.
+// Leaving the connected state drops the results, which no longer apply.
+func scheduleProbes(cfg *config.Config) {
+	connected := appState.Machine().State() == app.StateConnected
+	if connected && cfg.Probe.Enabled {
+		probes.SetInterval(cfg.Probe.Interval.Duration)
+	} else {
+		probes.SetInterval(0)
+	}
+	if !connected {
+		probes.SetTargets(nil)
+		publishResources()
+	}
+}
+
+// handleResourcesProbe checks every resource now; the submenu shows the results
+func handleResourcesProbe() {
+	if appState.Machine().State() != app.StateConnected {
+		notifyUser(notify.Notification{
+			Title: "Resources",
+			Body:  "Connect to Twingate to check resource reachability",
+			Tag:   tagResources,
+		})
+		return
+	}
+	refreshResourcesMenu()
+	probes.Trigger()
+}
+
+// handleExitNodeSelect switches to the exit node picked in the tray submenu
+func handleExitNodeSelect(nodeName string) {
+	log.Printf("Switching to exit node: %s", nodeName)
+	if err := runExitNodeOperation(func(ctx context.Context) error {
+		return twingate.SwitchExitNode(ctx, nodeName)
+	}); err != nil {
+		log.Printf("Failed to switch exit node: %v", err)
+		notifyExitNode("Exit Node Failed", fmt.Sprintf("Failed to switch to %s: %v", nodeName, err))
+	} else {
+		notifyExitNode("Exit Node Switched", fmt.Sprintf("Now using exit node: %s", nodeName))
+	}
+	refreshExitNodeMenu()
+}
+
+// handleResourceSelect authenticates a resource picked in the tray submenu
+func handleResourceSelect(name string) {
+	resources, err := twingate.GetResources(daemonCtx)
+	if err != nil {
+		log.Printf("Failed to get resources: %v", err)
+		notifyUser(notify.Notification{
+			Title: "Resources Error",
+			Body:  fmt.Sprintf("Failed to get resources: %v", err),
+			Tag:   tagResources,
+		})
+		return
+	}
+	for _, res := range resources {
+		if res.Name != name {
+			continue
+		}
+		if res.NeedsAuth {
+			authenticateResource(res)
+			refreshResourcesMenu()
+		} else {
+			log.Printf("Resource %s is already authenticated", name)
+		}
+		return
+	}
+	log.Printf("Resource %s no longer available", name)
+}
+
+// favoritesMu serializes changes to the pinned resources, which read and
+// rewrite the config file
+var favoritesMu sync.Mutex
+
+// handleResourcePin pins a resource to the top-level menu or unpins it,
+// saving the change in the config file
+func handleResourcePin(name string, pinned bool) {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+
+	favorites := slices.Clone(settings.Get().Favorites.Resources)
+	if slices.Contains(favorites, name) == pinned {
+		return
+	}
+	if pinned {
+		favorites = append(favorites, name)
 	} else {
-		sendNotification("Exit Node Stopped", "Split tunnel mode restored")
+		favorites = slices.DeleteFunc(favorites, func(f string) bool { return f == name })
+	}
+	if err := settings.SetFavorites(favorites); err != nil {
+		log.Printf("Failed to save favorites: %v", err)
+		notifyUser(notify.Notification{
+			Title: "Favorites Error",
+			Body:  fmt.Sprintf("Failed to save favorites: %v", err),
+			Tag:   tagResources,
+		})
+		return
+	}
+	if pinned {
+		log.Printf("Pinned %s to the menu", name)
+	} else {
+		log.Printf("Unpinned %s from the menu", name)
+	}
+}
+
+// handleFavorite runs an action from a pinned resource's submenu
+func handleFavorite(name string, action tray.FavoriteAction) {
+	res, ok := cachedResource(name)
+	if !ok {
+		log.Printf("Resource %s no longer available", name)
+		return
+	}
+
+	var err error
+	switch action {
+	case tray.FavoriteCopyAddress:
+		if err = twingate.CopyToClipboard(res.Address); err == nil {
+			notifyUser(notify.Notification{
+				Title: "Address Copied",
+				Body:  res.Address,
+				Tag:   tagResources,
+			})
+		}
+	case tray.FavoriteOpenBrowser:
+		var url string
+		if url, err = resourceURL(res.Address); err == nil {
+			log.Printf("Opening URL: %s", url)
+			err = launch.Start([]string{"xdg-open", url})
+		}
+	case tray.FavoriteOpenSSH:
+		err = openSSH(res.Address)
+	case tray.FavoriteAuthenticate:
+		if res.NeedsAuth {
+			authenticateResource(res)
+			refreshResourcesMenu()
+		}
+	}
+	if err != nil {
+		log.Printf("Pinned resource %s: failed to %s: %v", name, action, err)
+		notifyUser(notify.Notification{
+			Title: "Resources Error",
+			Body:  fmt.Sprintf("%s: %v", name, err),
+			Tag:   tagResources,
+		})
+	}
+}
+
+// cachedResource returns the resource called name from the last refresh
+func cachedResource(name string) (twingate.Resource, bool) {
+	resourceCache.Lock()
+	defer resourceCache.Unlock()
+	for _, res := range resourceCache.resources {
+		if res.Name == name {
+			return res, true
+		}
 	}
+	return twingate.Resource{}, false
+}
+
+// resourceURL returns the web address of a resource. An address naming
+// one port other than 443 is opened over plain HTTP on that port.
+func resourceURL(address string) (string, error) {
+	target, err := probe.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	host, err := target.SingleHost()
+	if err != nil {
+		return "", err
+	}
+	if len(target.Ports) == 1 && target.Ports[0].First == target.Ports[0].Last {
+		switch port := target.Ports[0].First; port {
+		case 443:
+		case 80:
+			return "http://" + hostForURL(host), nil
+		default:
+			return "http://" + net.JoinHostPort(host, strconv.Itoa(port)), nil
+		}
+	}
+	return "https://" + hostForURL(host), nil
+}
+
+// hostForURL brackets IPv6 addresses
+func hostForURL(host string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// openSSH runs ssh to the resource in a new terminal window
+func openSSH(address string) error {
+	target, err := probe.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+	host, err := target.SingleHost()
+	if err != nil {
+		return err
+	}
+	term, err := launch.FindTerminal()
+	if err != nil {
+		return err
+	}
+	log.Printf("Opening SSH to %s in %s", host, term.Name())
+	return launch.Start(term.Command("ssh", host))
+}
+
+// handleControlRefresh re-reads the status for the control interface
+func handleControlRefresh() error {
+	updateStatus()
+	updateNetworkInfo()
+	return nil
+}
+
+// handleControlListResources returns the resources for the control interface
+func handleControlListResources() ([]control.Resource, error) {
+	resources, err := twingate.GetResources(daemonCtx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]control.Resource, 0, len(resources))
+	for _, res := range resources {
+		result = append(result, control.Resource{
+			Name:       res.Name,
+			Address:    res.Address,
+			AuthStatus: res.AuthStatus,
+			NeedsAuth:  res.NeedsAuth,
+		})
+	}
+	return result, nil
 }
 
 func handleExitNodeList() {
 	log.Println("Showing exit node list...")
-	status, err := twingate.GetExitNodeStatus()
+	status, err := twingate.GetExitNodeStatus(daemonCtx)
 	if err != nil {
 		log.Printf("Failed to get exit node status: %v", err)
-		sendNotification("Exit Node Error", fmt.Sprintf("Failed to get exit nodes: %v", err))
+		notifyExitNode("Exit Node Error", fmt.Sprintf("Failed to get exit nodes: %v", err))
 		return
 	}
 
@@ -397,11 +1426,10 @@ func handleExitNodeList() {
 
 	if len(status.AvailableNodes) == 0 {
 		log.Println("No exit nodes available, showing info dialog")
-		exec.Command("zenity", "--info", "--title=Exit Nodes", "--text=No exit nodes available for your network", "--width=300").Run()
+		showMessageDialog("Exit Nodes", "No exit nodes available for your network")
 		return
 	}
 
-	// Build menu options for zenity
 	var options []string
 	if status.Enabled {
 		options = append(options, "Stop Exit Node")
@@ -419,19 +1447,12 @@ func handleExitNodeList() {
 		options = append(options, label)
 	}
 
-	// Show zenity menu
-	cmd := exec.Command("zenity", "--list", "--title=Exit Nodes", "--text=Select an action:",
-		"--column=Option", "--width=400", "--height=300")
-	cmd.Args = append(cmd.Args, options...)
-
-	output, err := cmd.Output()
+	opts := dialog.Options{Title: "Exit Nodes", Size: dialog.Size(settings.Get().Dialogs.ExitNodes)}
+	selected, ok, err := dialogBackend().List(opts, "Select an action:", options)
 	if err != nil {
-		// User cancelled or closed dialog
-		return
+		log.Printf("Failed to show exit node list: %v", err)
 	}
-
-	selected := strings.TrimSpace(string(output))
-	if selected == "" {
+	if !ok {
 		return // User cancelled or selected nothing
 	}
 
@@ -445,55 +1466,52 @@ func handleExitNodeList() {
 		log.Printf("Switching to exit node: %s", nodeName)
 
 		// Switch to the selected node
-		if err := twingate.SwitchExitNode(nodeName); err != nil {
+		if err := runExitNodeOperation(func(ctx context.Context) error {
+			return twingate.SwitchExitNode(ctx, nodeName)
+		}); err != nil {
 			log.Printf("Failed to switch exit node: %v", err)
-			sendNotification("Exit Node Failed", fmt.Sprintf("Failed to switch to %s: %v", nodeName, err))
+			notifyExitNode("Exit Node Failed", fmt.Sprintf("Failed to switch to %s: %v", nodeName, err))
 		} else {
-			sendNotification("Exit Node Switched", fmt.Sprintf("Now using exit node: %s", nodeName))
+			notifyExitNode("Exit Node Switched", fmt.Sprintf("Now using exit node: %s", nodeName))
 		}
 	}
 }
 
 func handleExitNodeSwitch() {
 	log.Println("Switching exit node...")
-	status, err := twingate.GetExitNodeStatus()
+	status, err := twingate.GetExitNodeStatus(daemonCtx)
 	if err != nil {
 		log.Printf("Failed to get exit node status: %v", err)
 		return
 	}
 
 	if len(status.AvailableNodes) == 0 {
-		sendNotification("Exit Node Error", "No exit nodes available")
+		notifyExitNode("Exit Node Error", "No exit nodes available")
 		return
 	}
 
-	// Show zenity dialog to select node
-	cmd := exec.Command("zenity", "--list", "--title=Switch Exit Node",
-		"--text=Select an exit node:", "--column=Node", "--width=400", "--height=300")
-	cmd.Args = append(cmd.Args, status.AvailableNodes...)
-
-	output, err := cmd.Output()
+	opts := dialog.Options{Title: "Switch Exit Node", Size: dialog.Size(settings.Get().Dialogs.ExitNodes)}
+	nodeName, ok, err := dialogBackend().List(opts, "Select an exit node:", status.AvailableNodes)
 	if err != nil {
-		// User cancelled
-		return
+		log.Printf("Failed to show exit node list: %v", err)
 	}
-
-	nodeName := strings.TrimSpace(string(output))
-	if nodeName == "" {
+	if !ok {
 		return // User cancelled or selected nothing
 	}
 
-	if err := twingate.SwitchExitNode(nodeName); err != nil {
+	if err := runExitNodeOperation(func(ctx context.Context) error {
+		return twingate.SwitchExitNode(ctx, nodeName)
+	}); err != nil {
 		log.Printf("Failed to switch exit node: %v", err)
-		sendNotification("Exit Node Failed", fmt.Sprintf("Failed to switch to %s: %v", nodeName, err))
+		notifyExitNode("Exit Node Failed", fmt.Sprintf("Failed to switch to %s: %v", nodeName, err))
 	} else {
-		sendNotification("Exit Node Switched", fmt.Sprintf("Now using exit node: %s", nodeName))
+		notifyExitNode("Exit Node Switched", fmt.Sprintf("Now using exit node: %s", nodeName))
 	}
 }
 
 func handleResourcesShow() {
 	log.Println("Showing resources...")
-	resources, err := twingate.GetResources()
+	resources, err := twingate.GetResources(daemonCtx)
 	if err != nil {
 		log.Printf("Failed to get resources: %v", err)
 		sendNotification("Resources Error", fmt.Sprintf("Failed to get resources: %v", err))
@@ -501,48 +1519,116 @@ func handleResourcesShow() {
 	}
 
 	if len(resources) == 0 {
-		exec.Command("zenity", "--info", "--title=Resources", "--text=No resources available", "--width=300").Run()
+		showMessageDialog("Resources", "No resources available")
 		return
 	}
 
-	// Build list for zenity
-	var options []string
+	// Labels map back to their resource, so names may contain anything
+	options := make([]string, 0, len(resources))
+	byLabel := make(map[string]twingate.Resource, len(resources))
 	for _, res := range resources {
 		label := fmt.Sprintf("%s | %s", res.Name, res.Address)
 		if res.NeedsAuth {
 			label += " [Locked]"
 		}
+		if _, dup := byLabel[label]; dup {
+			continue
+		}
 		options = append(options, label)
+		byLabel[label] = res
 	}
 
-	// Show zenity list
-	cmd := exec.Command("zenity", "--list", "--title=Twingate Resources",
-		"--text=Available resources (select to authenticate locked resources):",
-		"--column=Resource", "--width=600", "--height=400")
-	cmd.Args = append(cmd.Args, options...)
-
-	output, err := cmd.Output()
+	opts := dialog.Options{Title: "Twingate Resources", Size: dialog.Size(settings.Get().Dialogs.Resources)}
+	selected, ok, err := dialogBackend().List(opts,
+		"Available resources (select to open or authenticate):", options)
 	if err != nil {
-		// User cancelled
+		log.Printf("Failed to show resources: %v", err)
+	}
+	if !ok {
+		return // User cancelled
+	}
+	res, found := byLabel[selected]
+	if !found {
 		return
 	}
 
-	selected := string(output)
-	selected = selected[:len(selected)-1] // Remove trailing newline
-
-	// Find the selected resource
-	for _, res := range resources {
-		if fmt.Sprintf("%s | %s", res.Name, res.Address) == selected[:len(fmt.Sprintf("%s | %s", res.Name, res.Address))] {
-			if res.NeedsAuth {
-				if err := twingate.AuthenticateResource(res.Name); err != nil {
-					log.Printf("Failed to authenticate resource: %v", err)
-					sendNotification("Authentication Failed", fmt.Sprintf("Failed to authenticate %s: %v", res.Name, err))
-				} else {
-					sendNotification("Authentication Started", fmt.Sprintf("Authentication initiated for %s", res.Name))
-				}
-			}
-			break
+	// Authenticate and the matching launchers; one choice runs right away
+	const authenticate = "Authenticate"
+	var actions []string
+	if res.NeedsAuth {
+		actions = append(actions, authenticate)
+	}
+	for _, l := range launch.Match(compileLaunchers(settings.Get().Launchers), res.Name, res.Address) {
+		actions = append(actions, l.Name)
+	}
+	var action string
+	switch len(actions) {
+	case 0:
+		log.Printf("Resource %s has no launchers", res.Name)
+		return
+	case 1:
+		action = actions[0]
+	default:
+		opts.Title = res.Name
+		action, ok, err = dialogBackend().List(opts, fmt.Sprintf("Open %s with:", res.Name), actions)
+		if err != nil {
+			log.Printf("Failed to show launchers: %v", err)
 		}
+		if !ok {
+			return
+		}
+	}
+
+	if action == authenticate && res.NeedsAuth {
+		authenticateResource(res)
+		return
+	}
+	launchResource(res, action)
+}
+
+// handleResourceLaunch opens a resource with a launcher picked in the tray menu
+func handleResourceLaunch(name, launcher string) {
+	res, ok := cachedResource(name)
+	if !ok {
+		log.Printf("Resource %s no longer available", name)
+		return
+	}
+	launchResource(res, launcher)
+}
+
+// launchResource runs the named launcher for res
+func launchResource(res twingate.Resource, name string) {
+	l, ok := launch.Find(compileLaunchers(settings.Get().Launchers), name)
+	if !ok {
+		log.Printf("Launcher %s no longer configured", name)
+		return
+	}
+	log.Printf("Opening %s with %s", res.Name, l.Name)
+	if err := l.Open(res.Name, res.Address); err != nil {
+		log.Printf("Failed to open %s with %s: %v", res.Name, l.Name, err)
+		notifyUser(notify.Notification{
+			Title: "Launch Failed",
+			Body:  fmt.Sprintf("Failed to open %s with %s: %v", res.Name, l.Name, err),
+			Tag:   tagResources,
+		})
+	}
+}
+
+// authenticateResource starts authentication for a locked resource
+func authenticateResource(res twingate.Resource) {
+	if err := twingate.AuthenticateResource(daemonCtx, res.Name); err != nil {
+		log.Printf("Failed to authenticate resource: %v", err)
+		notifyUser(notify.Notification{
+			Title: "Authentication Failed",
+			Body:  fmt.Sprintf("Failed to authenticate %s: %v", res.Name, err),
+			Tag:   tagResources,
+		})
+	} else {
+		notifyUser(notify.Notification{
+			Title: "Authentication Started",
+			Body:  fmt.Sprintf("Authentication initiated for %s", res.Name),
+			Tag:   tagResources,
+		})
 	}
 }
 
@@ -554,7 +1640,7 @@ func handleOpenWebAdmin() {
 	if networkURL == "" || networkURL == "-" {
 		// Try to fetch it
 		log.Println("Network URL not in state, fetching...")
-		info, err := twingate.GetNetworkInfo()
+		info, err := twingate.GetNetworkInfo(daemonCtx)
 		if err != nil {
 			log.Printf("Failed to get network info: %v", err)
 			sendNotification("Web Admin Error", fmt.Sprintf("Failed to get network info: %v", err))
@@ -576,8 +1662,7 @@ func handleOpenWebAdmin() {
 	log.Printf("Opening URL: %s", networkURL)
 
 	// Open URL in default browser
-	cmd := exec.Command("xdg-open", networkURL)
-	if err := cmd.Start(); err != nil {
+	if err := launch.Start([]string{"xdg-open", networkURL}); err != nil {
 		log.Printf("Failed to open web admin: %v", err)
 		sendNotification("Web Admin Error", fmt.Sprintf("Failed to open browser: %v", err))
 	} else {
@@ -587,7 +1672,7 @@ func handleOpenWebAdmin() {
 
 func handleDiagnosticReport() {
 	log.Println("Generating diagnostic report...")
-	if err := twingate.GenerateDiagnosticReport(); err != nil {
+	if err := twingate.GenerateDiagnosticReport(daemonCtx); err != nil {
 		log.Printf("Failed to generate diagnostic report: %v", err)
 		sendNotification("Diagnostic Report Failed", fmt.Sprintf("Failed to generate report: %v", err))
 	} else {
@@ -599,7 +1684,7 @@ func handleAutoConnectToggle(enabled bool) {
 	log.Printf("Auto-connect toggled: %v", enabled)
 
 	// Enable/disable the Twingate systemd service
-	if err := twingate.SetAutoConnect(enabled); err != nil {
+	if err := twingate.SetAutoConnect(daemonCtx, enabled); err != nil {
 		log.Printf("Failed to set auto-connect: %v", err)
 		sendNotification("Auto-connect Error", fmt.Sprintf("Failed to change auto-connect: %v", err))
 		return
@@ -625,8 +1710,13 @@ func handleMenuOpening() {
 		}
 	}
 
+	// Pinned resources show the resource list in the top-level menu
+	if len(settings.Get().Favorites.Resources) > 0 {
+		refreshResourcesMenu()
+	}
+
 	// Also refresh auto-connect status from systemd
-	autoConnectEnabled := twingate.IsAutoConnectEnabled()
+	autoConnectEnabled := twingate.IsAutoConnectEnabled(daemonCtx)
 	if systemTray != nil {
 		systemTray.SetAutoConnect(autoConnectEnabled)
 	}
@@ -634,7 +1724,7 @@ func handleMenuOpening() {
 
 func handleAbout() {
 	log.Println("Showing About dialog...")
-	if err := app.ShowAbout(); err != nil {
+	if err := app.ShowAbout(dialogBackend()); err != nil {
 		log.Printf("Failed to show About dialog: %v", err)
 	}
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/config"
+	"github.com/bisand/twingate-tray/internal/dialog"
+	"github.com/bisand/twingate-tray/internal/launch"
+	"github.com/bisand/twingate-tray/internal/netmon"
+	"github.com/bisand/twingate-tray/internal/tray"
+)
+
+// The config package holds plain data; the settings are converted to the
+// feature packages' types here, and checked against them when loaded
+func init() {
+	config.AddCheck(checkSettings)
+}
+
+// checkSettings validates the settings only the feature packages understand
+func checkSettings(c *config.Config) error {
+	var errs []error
+
+	for i, r := range c.NetworkRules.Rules {
+		if _, err := netmon.ParseAction(r.Action); err != nil {
+			errs = append(errs, fmt.Errorf("network_rules.rules[%d]: %w", i, err))
+		}
+	}
+
+	bindings := []struct {
+		name    string
+		binding config.Binding
+	}{
+		{"left_click", c.Tray.LeftClick},
+		{"middle_click", c.Tray.MiddleClick},
+		{"scroll", c.Tray.Scroll},
+	}
+	for _, b := range bindings {
+		action, err := tray.ParseClickAction(b.binding.Action)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("tray.%s: %w", b.name, err))
+		case action == tray.ActionMenu && b.name != "left_click":
+			errs = append(errs, fmt.Errorf("tray.%s: the menu can only be bound to left_click", b.name))
+		case action == tray.ActionCommand && len(b.binding.Command) == 0:
+			errs = append(errs, fmt.Errorf("tray.%s: command must not be empty", b.name))
+		}
+	}
+
+	switch c.Dialogs.Backend {
+	case dialog.Auto, dialog.Zenity, dialog.Yad, dialog.KDialog, dialog.Builtin:
+	default:
+		errs = append(errs, fmt.Errorf("dialogs.backend must be %q, %q, %q, %q or %q",
+			dialog.Auto, dialog.Zenity, dialog.Yad, dialog.KDialog, dialog.Builtin))
+	}
+
+	for i, l := range c.Launchers {
+		if _, err := launch.ParseTemplate(l.Command); err != nil {
+			errs = append(errs, fmt.Errorf("launchers[%d]: command: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reconnectPolicy converts the settings to the supervisor's policy
+func reconnectPolicy(r config.ReconnectConfig) app.ReconnectPolicy {
+	return app.ReconnectPolicy{
+		Enabled:      r.Enabled,
+		InitialDelay: r.InitialDelay.Duration,
+		MaxDelay:     r.MaxDelay.Duration,
+		Multiplier:   r.Multiplier,
+		Jitter:       r.Jitter,
+		MaxAttempts:  r.MaxAttempts,
+	}
+}
+
+// networkRules converts the rules for evaluation. checkSettings has checked
+// the actions.
+func networkRules(n config.NetworkRulesConfig) []netmon.Rule {
+	rules := make([]netmon.Rule, 0, len(n.Rules))
+	for _, r := range n.Rules {
+		action, _ := netmon.ParseAction(r.Action)
+		rules = append(rules, netmon.Rule{SSID: r.SSID, Connection: r.Connection, Type: r.Type, Action: action})
+	}
+	return rules
+}
+
+// compileLaunchers parses the command templates. checkSettings has checked them.
+func compileLaunchers(l config.LaunchersConfig) []launch.Launcher {
+	compiled := make([]launch.Launcher, 0, len(l))
+	for _, c := range l {
+		command, _ := launch.ParseTemplate(c.Command)
+		compiled = append(compiled, launch.Launcher{
+			Name:     c.Name,
+			Resource: c.Resource,
+			Address:  c.Address,
+			Command:  command,
+			Terminal: c.Terminal,
+		})
+	}
+	return compiled
+}
+
+// trayBindings converts the settings for the tray. checkSettings has checked
+// the actions.
+func trayBindings(t config.TrayConfig) tray.Bindings {
+	compile := func(b config.Binding) tray.Binding {
+		action, _ := tray.ParseClickAction(b.Action)
+		return tray.Binding{Action: action, Command: b.Command}
+	}
+	return tray.Bindings{
+		LeftClick:   compile(t.LeftClick),
+		MiddleClick: compile(t.MiddleClick),
+		Scroll:      compile(t.Scroll),
+	}
+}
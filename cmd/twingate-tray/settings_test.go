@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/config"
+	"github.com/bisand/twingate-tray/internal/dialog"
+	"github.com/bisand/twingate-tray/internal/probe"
+	"github.com/bisand/twingate-tray/internal/tray"
+	"github.com/bisand/twingate-tray/internal/twingate"
+)
+
+// The config package spells out its defaults; they must stay those of the
+// packages the settings are handed to
+func TestDefaultsMatchPackages(t *testing.T) {
+	cfg := config.Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Default().Validate: %v", err)
+	}
+
+	durations := []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"status.poll_interval", cfg.Status.PollInterval.Duration, app.StatusPollInterval},
+		{"status.idle_poll_interval", cfg.Status.IdlePollInterval.Duration, app.IdlePollInterval},
+		{"notifications.timeout", cfg.Notifications.Timeout.Duration, app.NotificationTimeout * time.Millisecond},
+		{"commands.timeout", cfg.Commands.Timeout.Duration, twingate.DefaultCommandTimeout},
+		{"commands.privileged_timeout", cfg.Commands.PrivilegedTimeout.Duration, twingate.PrivilegedCommandTimeout},
+		{"probe.timeout", cfg.Probe.Timeout.Duration, probe.DefaultTimeout},
+	}
+	for _, d := range durations {
+		if d.got != d.want {
+			t.Errorf("%s = %s, want %s", d.name, d.got, d.want)
+		}
+	}
+	if cfg.Status.StabilityThreshold != app.DefaultStabilityThreshold {
+		t.Errorf("status.stability_threshold = %d, want %d", cfg.Status.StabilityThreshold, app.DefaultStabilityThreshold)
+	}
+	if cfg.Network.Interface != twingate.DefaultInterface {
+		t.Errorf("network.interface = %q, want %q", cfg.Network.Interface, twingate.DefaultInterface)
+	}
+	if cfg.Dialogs.Backend != dialog.Auto {
+		t.Errorf("dialogs.backend = %q, want %q", cfg.Dialogs.Backend, dialog.Auto)
+	}
+	if got, want := reconnectPolicy(cfg.Reconnect), app.DefaultReconnectPolicy(); got != want {
+		t.Errorf("reconnect = %+v, want %+v", got, want)
+	}
+	if got, want := trayBindings(cfg.Tray), tray.DefaultBindings; !bindingsEqual(got, want) {
+		t.Errorf("tray = %+v, want %+v", got, want)
+	}
+	if !slices.Equal(cfg.Probe.Ports, probe.DefaultPorts) {
+		t.Errorf("probe.ports = %v, want %v", cfg.Probe.Ports, probe.DefaultPorts)
+	}
+}
+
+func bindingsEqual(a, b tray.Bindings) bool {
+	equal := func(x, y tray.Binding) bool {
+		return x.Action == y.Action && slices.Equal(x.Command, y.Command)
+	}
+	return equal(a.LeftClick, b.LeftClick) && equal(a.MiddleClick, b.MiddleClick) && equal(a.Scroll, b.Scroll)
+}
+
+func TestLoadChecksFeatureSettings(t *testing.T) {
+	tests := []struct {
+		toml string
+		err  string
+	}{
+		{"[[network_rules.rules]]\ntype = \"wifi\"\naction = \"reboot\"\n", `network_rules.rules[0]: unknown action "reboot"`},
+		{"[tray]\nleft_click = \"launch\"\n", `tray.left_click: unknown action "launch"`},
+		{"[tray]\nscroll = \"menu\"\n", "tray.scroll: the menu can only be bound to left_click"},
+		{"[tray]\nmiddle_click = \"command\"\n", "tray.middle_click: command must not be empty"},
+		{"[dialogs]\nbackend = \"gtk\"\n", "dialogs.backend must be"},
+		{"[[launchers]]\nname = \"ssh\"\ncommand = \"ssh {host\"\n", "launchers[0]: command:"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), config.FileName)
+		if err := os.WriteFile(path, []byte(tt.toml), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := config.Load(path)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Load(%q) = %v, want an error containing %q", tt.toml, err, tt.err)
+		}
+	}
+}
+
+func TestConvertSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	text := `[tray]
+left_click = "toggle"
+scroll = { action = "command", command = ["notify-send", "hi"] }
+
+[[network_rules.rules]]
+ssid = "Office"
+action = "disconnect"
+
+[[launchers]]
+name = "psql"
+address = "*:5432"
+command = "psql -h {host}"
+terminal = true
+`
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	bindings := trayBindings(cfg.Tray)
+	if bindings.LeftClick.Action != tray.ActionToggle || bindings.MiddleClick.Action != tray.ActionNone {
+		t.Errorf("bindings = %+v, want toggle on left click and nothing on middle click", bindings)
+	}
+	if bindings.Scroll.Action != tray.ActionCommand || !slices.Equal(bindings.Scroll.Command, []string{"notify-send", "hi"}) {
+		t.Errorf("scroll = %+v, want the notify-send command", bindings.Scroll)
+	}
+
+	rules := networkRules(cfg.NetworkRules)
+	if len(rules) != 1 || rules[0].SSID != "Office" || rules[0].Action != "disconnect" {
+		t.Errorf("rules = %+v, want disconnect on Office", rules)
+	}
+
+	compiled := compileLaunchers(cfg.Launchers)
+	if len(compiled) != 1 || compiled[0].Name != "psql" || !compiled[0].Terminal {
+		t.Fatalf("launchers = %+v, want psql in a terminal", compiled)
+	}
+	if !compiled[0].Matches("Database", "db.internal:5432") {
+		t.Error("psql does not match db.internal:5432")
+	}
+}
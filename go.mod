@@ -3,6 +3,7 @@ module github.com/bisand/twingate-tray
 go 1.24
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/godbus/dbus/v5 v5.1.0
 	golang.design/x/clipboard v0.7.1
 )
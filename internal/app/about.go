@@ -2,87 +2,20 @@ package app
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
-)
-
-// ShowAbout displays the About dialog
-func ShowAbout() error {
-	aboutText := GetAboutText()
-
-	// Try yad first (supports large body images with --image)
-	if _, err := exec.LookPath("yad"); err == nil {
-		return showAboutWithYad(aboutText)
-	}
 
-	// Fall back to zenity (smaller icon only)
-	return showAboutWithZenity(aboutText)
-}
-
-// showAboutWithYad shows About dialog using yad (supports large images)
-func showAboutWithYad(aboutText string) error {
-	args := []string{
-		"--info",
-		"--title=About",
-		"--text=" + aboutText,
-		"--width=480",
-		"--height=400",
-		"--image-on-top",
-		"--center",
-		"--fixed",
-		"--buttons-layout=spread",
-		"--button=    OK    :0",
-	}
-
-	// Find icon for display (prefers 96x96 SVG)
-	iconPath := findAboutIconPath()
-	if iconPath != "" {
-		args = append(args, "--image="+iconPath)
-	}
-
-	cmd := exec.Command("yad", args...)
-	return cmd.Run()
-}
-
-// showAboutWithZenity shows About dialog using zenity (small icon only)
-func showAboutWithZenity(aboutText string) error {
-	args := []string{
-		"--info",
-		"--title=About",
-		"--text=" + aboutText,
-		"--width=500",
-		"--height=350",
-	}
-
-	// Add window icon (titlebar only, zenity doesn't support large body images)
-	if isIconInstalled() {
-		args = append(args, "--window-icon=twingate-tray")
-	} else {
-		iconPath := findAboutIconPath()
-		if iconPath != "" {
-			args = append(args, "--window-icon="+iconPath)
-		}
-	}
-
-	cmd := exec.Command("zenity", args...)
-	return cmd.Run()
-}
-
-// isIconInstalled checks if the icon is installed in the system theme
-func isIconInstalled() bool {
-	// Check if icon exists in common icon theme locations
-	iconPaths := []string{
-		"/usr/share/icons/hicolor/scalable/apps/twingate-tray.svg",
-		"/usr/share/icons/hicolor/128x128/apps/twingate-tray.png",
-		"/usr/share/icons/hicolor/256x256/apps/twingate-tray.png",
-	}
+	"github.com/bisand/twingate-tray/internal/dialog"
+)
 
-	for _, path := range iconPaths {
-		if _, err := os.Stat(path); err == nil {
-			return true
-		}
+// ShowAbout displays the About dialog. yad shows the icon beside the text;
+// zenity and kdialog only put it in the title bar.
+func ShowAbout(backend dialog.Backend) error {
+	opts := dialog.Options{
+		Title: "About",
+		Size:  dialog.Size{Width: 480, Height: 400},
+		Icon:  findAboutIconPath(),
 	}
-	return false
+	return backend.Info(opts, GetAboutText())
 }
 
 // findAboutIconPath tries to locate the icon for About dialog
@@ -4,7 +4,8 @@ import "time"
 
 // Application constants
 const (
-	// StatusPollInterval is how often we check Twingate status
+	// StatusPollInterval is how often we check Twingate status while a change
+	// is in flight
 	StatusPollInterval = 500 * time.Millisecond
 
 	// NotificationTimeout is the default notification display duration (milliseconds)
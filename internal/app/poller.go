@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultStabilityThreshold is the number of consistent status readings
+// required before a settled state change is accepted
+const DefaultStabilityThreshold = 3
+
+// IdlePollInterval is how often the status is read while nothing is changing.
+// Link and service events trigger a poll in between.
+const IdlePollInterval = 15 * time.Second
+
+// Poller reads the status on an adaptive schedule and feeds it to a
+// StateMachine. It polls fast while a transition is in flight or a settled
+// change is being confirmed, slowly otherwise, and immediately on Trigger.
+type Poller struct {
+	machine *StateMachine
+	read    func(ctx context.Context) (ConnectionState, error)
+	pollMu  sync.Mutex // Serializes reads so a triggered poll never overlaps the loop
+	trigger chan string
+	wake    chan struct{}
+
+	mu         sync.Mutex
+	fast       time.Duration
+	idle       time.Duration
+	threshold  int
+	paused     bool
+	confirming bool
+	candidate  ConnectionState
+	count      int
+}
+
+// NewPoller creates a poller that reads the status with read. It subscribes
+// to machine so that an operation starting switches to fast polling at once.
+func NewPoller(machine *StateMachine, read func(ctx context.Context) (ConnectionState, error)) *Poller {
+	p := &Poller{
+		machine:   machine,
+		read:      read,
+		trigger:   make(chan string, 1),
+		wake:      make(chan struct{}, 1),
+		fast:      StatusPollInterval,
+		idle:      IdlePollInterval,
+		threshold: DefaultStabilityThreshold,
+	}
+	machine.Subscribe(func(Transition) { p.reschedule() })
+	return p
+}
+
+// SetIntervals sets the poll interval while a change is in flight and while
+// the state is settled
+func (p *Poller) SetIntervals(fast, idle time.Duration) {
+	p.mu.Lock()
+	p.fast, p.idle = fast, idle
+	p.mu.Unlock()
+	p.reschedule()
+}
+
+// SetStabilityThreshold sets how many consistent readings are required
+// before a settled state change is accepted
+func (p *Poller) SetStabilityThreshold(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	p.threshold = n
+}
+
+// SetPaused stops or resumes polling, e.g. while the system sleeps. Triggers
+// received while paused are dropped.
+func (p *Poller) SetPaused(paused bool) {
+	p.mu.Lock()
+	p.paused = paused
+	p.confirming = false
+	p.mu.Unlock()
+	p.reschedule()
+}
+
+// Trigger requests an immediate poll. It never blocks; triggers that arrive
+// while one is queued are merged.
+func (p *Poller) Trigger(reason string) {
+	select {
+	case p.trigger <- reason:
+	default:
+	}
+}
+
+// Run polls until ctx is cancelled
+func (p *Poller) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+			// The schedule changed; wait out the new interval from the last poll
+			timer.Reset(time.Until(last.Add(p.interval())))
+			continue
+		case reason := <-p.trigger:
+			log.Printf("Status poll triggered: %s", reason)
+		case <-timer.C:
+		}
+
+		if !p.isPaused() {
+			p.Poll(ctx)
+		}
+		last = time.Now()
+		timer.Reset(p.interval())
+	}
+}
+
+// Poll reads the status once and feeds it to the machine. A settled change
+// is only passed on after the configured number of consistent readings.
+func (p *Poller) Poll(ctx context.Context) {
+	p.pollMu.Lock()
+	defer p.pollMu.Unlock()
+
+	state, err := p.read(ctx)
+	observed := state
+	if err != nil {
+		observed = StateError
+	}
+
+	p.mu.Lock()
+	if p.machine.NeedsConfirmation(observed) {
+		if !p.confirming || observed != p.candidate {
+			p.confirming = true
+			p.candidate = observed
+			p.count = 0
+		}
+		p.count++
+		if p.count < p.threshold {
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.confirming = false
+	p.count = 0
+	p.mu.Unlock()
+
+	p.machine.Observe(state, err)
+}
+
+// interval returns the wait before the next poll
+func (p *Poller) interval() time.Duration {
+	p.mu.Lock()
+	fast, idle, confirming := p.fast, p.idle, p.confirming
+	p.mu.Unlock()
+
+	if confirming || p.machine.InFlight() {
+		return fast
+	}
+	return idle
+}
+
+func (p *Poller) isPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// reschedule makes Run recompute its wait
+func (p *Poller) reschedule() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,314 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReconnectPolicy controls automatic reconnection after the connection was
+// lost without the user asking
+type ReconnectPolicy struct {
+	Enabled      bool
+	InitialDelay time.Duration // Wait before the first attempt
+	MaxDelay     time.Duration // Upper bound for the wait between attempts
+	Multiplier   float64       // Growth factor of the wait per attempt
+	Jitter       float64       // Fraction of each wait randomized in both directions, 0-1
+	MaxAttempts  int
+}
+
+// DefaultReconnectPolicy returns the built-in policy. Reconnecting is opt-in.
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		Enabled:      false,
+		InitialDelay: 2 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		Multiplier:   2,
+		Jitter:       0.2,
+		MaxAttempts:  8,
+	}
+}
+
+// Delay returns the wait before attempt n (1-based), without jitter
+func (p ReconnectPolicy) Delay(attempt int) time.Duration {
+	d := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if d > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(d)
+}
+
+// ReconnectConfirmTimeout is how long an accepted connect may take to show up
+// as Connected before the attempt counts as failed
+const ReconnectConfirmTimeout = DefaultOperationSettleTime
+
+// ReconnectPhase is the supervisor's progress through a reconnect run
+type ReconnectPhase int
+
+// Reconnect phases
+const (
+	ReconnectIdle       ReconnectPhase = iota
+	ReconnectWaiting                   // Backing off before the next attempt
+	ReconnectAttempting                // A connect is in flight or being confirmed
+	ReconnectSucceeded                 // The connection came back
+	ReconnectGaveUp                    // The attempt budget ran out
+	ReconnectCancelled                 // The user disconnected or reconnecting was disabled
+)
+
+// ReconnectStatus is delivered to subscribers whenever the supervisor's
+// progress changes
+type ReconnectStatus struct {
+	Phase       ReconnectPhase
+	Attempt     int // Current or last attempt, 1-based
+	MaxAttempts int
+	NextAttempt time.Time // When the next attempt starts; set while waiting
+	Err         string    // Error of the last failed attempt
+}
+
+// Summary returns a one-line description for the tooltip, or "" when there
+// is nothing to show
+func (s ReconnectStatus) Summary() string {
+	switch s.Phase {
+	case ReconnectWaiting:
+		return fmt.Sprintf("Reconnect attempt %d of %d at %s",
+			s.Attempt, s.MaxAttempts, s.NextAttempt.Format("15:04:05"))
+	case ReconnectAttempting:
+		return fmt.Sprintf("Reconnecting (attempt %d of %d)…", s.Attempt, s.MaxAttempts)
+	case ReconnectGaveUp:
+		return fmt.Sprintf("Reconnect failed after %d attempts", s.Attempt)
+	default:
+		return ""
+	}
+}
+
+// Reconnector re-establishes the connection after an unexpected drop. It
+// follows the user's intent: once the user disconnects, it stays idle until
+// the connection is up again.
+type Reconnector struct {
+	ctx     context.Context
+	connect func(context.Context) error
+	jitter  func() float64 // Returns a value in [0, 1)
+
+	mu            sync.Mutex
+	policy        ReconnectPolicy
+	wantConnected bool // The user wants to be connected
+	established   bool // A session was up before the latest drop
+	cancel        context.CancelFunc
+	run           int // Incremented per reconnect run so stale runs stay quiet
+	status        ReconnectStatus
+	subscribers   []func(ReconnectStatus)
+}
+
+// NewReconnector creates an idle supervisor. connect performs one attempt;
+// it is cancelled through its context when the run ends.
+func NewReconnector(ctx context.Context, connect func(context.Context) error) *Reconnector {
+	return &Reconnector{
+		ctx:     ctx,
+		connect: connect,
+		jitter:  rand.Float64,
+		policy:  DefaultReconnectPolicy(),
+	}
+}
+
+// SetPolicy replaces the policy. Disabling it stops a running reconnect; other
+// changes apply from the next run.
+func (r *Reconnector) SetPolicy(p ReconnectPolicy) {
+	r.mu.Lock()
+	r.policy = p
+	var status *ReconnectStatus
+	if !p.Enabled {
+		status = r.stopLocked(ReconnectCancelled)
+	}
+	r.unlockAndNotify(status)
+}
+
+// Subscribe registers fn to be called on every status change. Callbacks may
+// run on the supervisor's goroutine and must not block.
+func (r *Reconnector) Subscribe(fn func(ReconnectStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Status returns the current status
+func (r *Reconnector) Status() ReconnectStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// SetIntent records a user connect or disconnect request. Either one takes
+// over from a running reconnect.
+func (r *Reconnector) SetIntent(connected bool) {
+	r.mu.Lock()
+	r.wantConnected = connected
+	if !connected {
+		r.established = false
+	}
+	r.unlockAndNotify(r.stopLocked(ReconnectCancelled))
+}
+
+// Running reports whether a reconnect run is in progress
+func (r *Reconnector) Running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancel != nil
+}
+
+// HandleTransition follows the state machine. It starts a reconnect run
+// synchronously, so later subscribers see Running for the drop.
+func (r *Reconnector) HandleTransition(t Transition) {
+	r.mu.Lock()
+
+	switch t.To {
+	case StateConnected:
+		// Connecting outside the tray also counts as wanting to be connected
+		r.wantConnected = true
+		r.established = true
+		r.unlockAndNotify(r.stopLocked(ReconnectSucceeded))
+		return
+
+	case StateDisconnected, StateError:
+		lost := r.established && !strings.HasPrefix(t.Reason, OpDisconnect.String())
+		r.established = false
+		if r.cancel != nil || !lost || !r.wantConnected || !r.policy.Enabled {
+			// A running reconnect handles its own failed attempts
+			r.unlockAndNotify(nil)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.ctx)
+		r.cancel = cancel
+		r.run++
+		r.status = ReconnectStatus{MaxAttempts: r.policy.MaxAttempts}
+		go r.loop(ctx, r.run, r.policy)
+	}
+	r.unlockAndNotify(nil)
+}
+
+// loop performs up to MaxAttempts connects with exponential backoff. It ends
+// when ctx is cancelled by a Connected transition or a user disconnect.
+func (r *Reconnector) loop(ctx context.Context, run int, policy ReconnectPolicy) {
+	var lastErr string
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		delay := r.jittered(policy.Delay(attempt), policy.Jitter)
+		if !r.update(run, ReconnectStatus{
+			Phase:       ReconnectWaiting,
+			Attempt:     attempt,
+			MaxAttempts: policy.MaxAttempts,
+			NextAttempt: time.Now().Add(delay),
+			Err:         lastErr,
+		}) || !sleepContext(ctx, delay) {
+			return
+		}
+
+		if !r.update(run, ReconnectStatus{
+			Phase:       ReconnectAttempting,
+			Attempt:     attempt,
+			MaxAttempts: policy.MaxAttempts,
+			Err:         lastErr,
+		}) {
+			return
+		}
+
+		if err := r.connect(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			lastErr = err.Error()
+			continue
+		}
+
+		// The connect was accepted; wait for the Connected transition to
+		// cancel ctx before trying again
+		if !sleepContext(ctx, ReconnectConfirmTimeout) {
+			return
+		}
+		lastErr = "connection was not confirmed"
+	}
+
+	r.mu.Lock()
+	if r.run != run {
+		r.mu.Unlock()
+		return
+	}
+	r.status.Err = lastErr
+	r.unlockAndNotify(r.stopLocked(ReconnectGaveUp))
+}
+
+// update publishes status if run is still the active run
+func (r *Reconnector) update(run int, status ReconnectStatus) bool {
+	r.mu.Lock()
+	if r.run != run || r.cancel == nil {
+		r.mu.Unlock()
+		return false
+	}
+	r.status = status
+	r.unlockAndNotify(&status)
+	return true
+}
+
+// stopLocked ends a running reconnect with the given phase and returns the
+// status to publish. Without a running reconnect it clears a kept outcome,
+// returning nil if there was none. Caller holds mu.
+func (r *Reconnector) stopLocked(phase ReconnectPhase) *ReconnectStatus {
+	if r.cancel == nil {
+		if r.status.Phase == ReconnectIdle {
+			return nil
+		}
+		r.status = ReconnectStatus{Phase: ReconnectIdle}
+		status := r.status
+		return &status
+	}
+	r.cancel()
+	r.cancel = nil
+	r.run++
+
+	status := r.status
+	status.Phase = phase
+	status.NextAttempt = time.Time{}
+	r.status = ReconnectStatus{Phase: ReconnectIdle}
+	if phase == ReconnectGaveUp {
+		// Keep the outcome visible until the connection comes back
+		r.status = status
+	}
+	return &status
+}
+
+// unlockAndNotify releases mu and delivers status, if any, to subscribers
+func (r *Reconnector) unlockAndNotify(status *ReconnectStatus) {
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	if status == nil {
+		return
+	}
+	for _, fn := range subscribers {
+		fn(*status)
+	}
+}
+
+// jittered spreads d by ±fraction so clients do not retry in lockstep
+func (r *Reconnector) jittered(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	factor := 1 + fraction*(2*r.jitter()-1)
+	return time.Duration(float64(d) * factor)
+}
+
+// sleepContext waits for d and reports false if ctx ended first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
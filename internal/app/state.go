@@ -8,42 +8,52 @@ import (
 // AppState tracks the current Twingate connection status
 type AppState struct {
 	mu             sync.RWMutex
-	connected      bool
+	machine        *StateMachine
 	lastErr        string
 	networkName    string
 	networkURL     string
+	exitNode       string
 	connectedSince time.Time
 }
 
 // NewAppState creates a new application state
 func NewAppState() *AppState {
-	return &AppState{
-		connected:   false,
+	a := &AppState{
+		machine:     NewStateMachine(),
 		lastErr:     "",
 		networkName: "",
 		networkURL:  "",
 	}
+	a.machine.Subscribe(a.onTransition)
+	return a
+}
+
+// Machine returns the connection state machine
+func (a *AppState) Machine() *StateMachine {
+	return a.machine
+}
+
+// State returns the current connection state
+func (a *AppState) State() ConnectionState {
+	return a.machine.State()
 }
 
 // IsConnected returns the current connection status
 func (a *AppState) IsConnected() bool {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-	return a.connected
+	return a.machine.State() == StateConnected
 }
 
-// SetConnected updates the connection status
-func (a *AppState) SetConnected(connected bool) {
+// onTransition keeps the connection timer in sync with the state machine
+func (a *AppState) onTransition(t Transition) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	if connected && !a.connected {
+	if t.To == StateConnected && a.connectedSince.IsZero() {
 		// Just connected - record the time
-		a.connectedSince = time.Now()
-	} else if !connected && a.connected {
+		a.connectedSince = t.At
+	} else if t.To == StateDisconnected || t.To == StateError {
 		// Just disconnected - reset the time
 		a.connectedSince = time.Time{}
 	}
-	a.connected = connected
 }
 
 // GetLastError returns the last error message
@@ -88,6 +98,20 @@ func (a *AppState) SetNetworkURL(url string) {
 	a.networkURL = url
 }
 
+// GetExitNode returns the active exit node, or "" if none
+func (a *AppState) GetExitNode() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.exitNode
+}
+
+// SetExitNode updates the active exit node
+func (a *AppState) SetExitNode(node string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.exitNode = node
+}
+
 // GetConnectedSince returns the time when connection was established
 func (a *AppState) GetConnectedSince() time.Time {
 	a.mu.RLock()
@@ -99,7 +123,7 @@ func (a *AppState) GetConnectedSince() time.Time {
 func (a *AppState) GetConnectionDuration() time.Duration {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	if !a.connected || a.connectedSince.IsZero() {
+	if a.connectedSince.IsZero() {
 		return 0
 	}
 	return time.Since(a.connectedSince)
@@ -0,0 +1,345 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionState is the tray's view of the Twingate connection
+type ConnectionState int
+
+// Connection states
+const (
+	StateDisconnected ConnectionState = iota
+	StateConnecting
+	StateAuthenticating
+	StateConnected
+	StateDisconnecting
+	StateError
+)
+
+// String returns the lower-case state name used in logs and scripts
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateAuthenticating:
+		return "authenticating"
+	case StateConnected:
+		return "connected"
+	case StateDisconnecting:
+		return "disconnecting"
+	case StateError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseConnectionState returns the state whose String() is name
+func ParseConnectionState(name string) (ConnectionState, bool) {
+	for s := StateDisconnected; s <= StateError; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return StateDisconnected, false
+}
+
+// Label returns the human-readable state name shown in the menu and tooltip
+func (s ConnectionState) Label() string {
+	switch s {
+	case StateDisconnected:
+		return "Disconnected"
+	case StateConnecting:
+		return "Connecting…"
+	case StateAuthenticating:
+		return "Authenticating…"
+	case StateConnected:
+		return "Connected"
+	case StateDisconnecting:
+		return "Disconnecting…"
+	case StateError:
+		return "Error"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsTransitional reports whether the state is expected to settle on its own
+func (s ConnectionState) IsTransitional() bool {
+	return s == StateConnecting || s == StateAuthenticating || s == StateDisconnecting
+}
+
+// Operation is a user- or daemon-initiated action that changes the connection
+type Operation int
+
+// Operations that drive the state machine while in flight
+const (
+	OpConnect Operation = iota
+	OpDisconnect
+	OpExitNode
+	OpReconnect // Automatic connect after the connection was lost
+)
+
+// String returns the operation name
+func (o Operation) String() string {
+	switch o {
+	case OpConnect:
+		return "connect"
+	case OpDisconnect:
+		return "disconnect"
+	case OpExitNode:
+		return "exit-node"
+	case OpReconnect:
+		return "reconnect"
+	default:
+		return "unknown"
+	}
+}
+
+// Transition describes a single state change delivered to subscribers
+type Transition struct {
+	From    ConnectionState
+	To      ConnectionState
+	Reason  string
+	Err     string // Set when To is StateError
+	Initial bool   // True for the first observed state after startup
+	At      time.Time
+}
+
+// DefaultOperationSettleTime is how long a finished operation keeps
+// contradicting status readings from reverting the state
+const DefaultOperationSettleTime = 30 * time.Second
+
+// pendingOperation tracks an in-flight Connect/Disconnect/exit-node action
+type pendingOperation struct {
+	op       Operation
+	target   ConnectionState
+	finished bool
+	deadline time.Time
+}
+
+// StateMachine derives the connection state from parsed `twingate status`
+// readings and in-flight operations, and notifies subscribers of every
+// transition.
+type StateMachine struct {
+	mu          sync.Mutex
+	state       ConnectionState
+	initialized bool
+	resync      bool
+	lastErr     string
+	pending     *pendingOperation
+	settleTime  time.Duration
+	subscribers []func(Transition)
+	now         func() time.Time
+}
+
+// NewStateMachine creates a state machine in the Disconnected state
+func NewStateMachine() *StateMachine {
+	return &StateMachine{
+		state:      StateDisconnected,
+		settleTime: DefaultOperationSettleTime,
+		now:        time.Now,
+	}
+}
+
+// Subscribe registers fn to be called for every transition. Callbacks run
+// synchronously in the order they were registered and must not block.
+func (m *StateMachine) Subscribe(fn func(Transition)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// State returns the current state
+func (m *StateMachine) State() ConnectionState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// LastError returns the error that caused the most recent Error state
+func (m *StateMachine) LastError() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+// InFlight reports whether an operation is pending or the state is expected
+// to settle on its own
+func (m *StateMachine) InFlight() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pending != nil || m.state.IsTransitional()
+}
+
+// NeedsConfirmation reports whether Observe would apply observed as a settled
+// state change. Such readings can be noise while the client restarts, so the
+// Poller only passes them on once they are consistent.
+func (m *StateMachine) NeedsConfirmation(observed ConnectionState) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.initialized && !m.resync && m.pending == nil &&
+		observed != m.state && !observed.IsTransitional()
+}
+
+// Observe feeds a status reading into the machine. observed must be one of
+// Disconnected, Connecting, Authenticating, Connected or Error; err carries
+// the failure when the status could not be read. Settled changes are applied
+// as-is; debouncing them is the Poller's job.
+func (m *StateMachine) Observe(observed ConnectionState, err error) {
+	m.mu.Lock()
+
+	errText := ""
+	if err != nil {
+		observed = StateError
+		errText = err.Error()
+	}
+
+	// First reading - accept it as-is
+	if !m.initialized {
+		m.initialized = true
+		t := m.setLocked(observed, "initial status", errText)
+		t.Initial = true
+		m.unlockAndNotify(&t)
+		return
+	}
+
+	// After a resume the previous state is stale - accept the reading as-is
+	if m.resync {
+		m.resync = false
+		m.pending = nil
+		var t *Transition
+		if observed != m.state {
+			tr := m.setLocked(observed, "resync", errText)
+			t = &tr
+		}
+		m.unlockAndNotify(t)
+		return
+	}
+
+	if p := m.pending; p != nil {
+		// Exit-node changes happen while connected, so only a finished
+		// operation can be confirmed by a Connected reading
+		if observed == p.target && (p.finished || p.op != OpExitNode) {
+			// The operation is confirmed - no need to debounce
+			m.pending = nil
+			var t *Transition
+			if observed != m.state {
+				tr := m.setLocked(observed, p.op.String()+" completed", "")
+				t = &tr
+			}
+			m.unlockAndNotify(t)
+			return
+		}
+		if p.finished && m.now().After(p.deadline) {
+			m.pending = nil
+		} else {
+			// An operation is in flight. Progress reports from the client are
+			// shown, anything else is noise until the operation settles.
+			var t *Transition
+			if observed == StateAuthenticating && p.target == StateConnected && observed != m.state {
+				tr := m.setLocked(observed, "twingate status", "")
+				t = &tr
+			}
+			m.unlockAndNotify(t)
+			return
+		}
+	}
+
+	if observed == m.state {
+		if observed == StateError {
+			m.lastErr = errText
+		}
+		m.unlockAndNotify(nil)
+		return
+	}
+
+	t := m.setLocked(observed, "twingate status", errText)
+	m.unlockAndNotify(&t)
+}
+
+// Resync makes the next reading apply immediately, skipping confirmation and
+// dropping any pending operation. Used when the current state is known to be
+// stale, e.g. after the system resumes from sleep.
+func (m *StateMachine) Resync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resync = true
+}
+
+// BeginOperation records that op has started and moves to the matching
+// transitional state
+func (m *StateMachine) BeginOperation(op Operation) {
+	m.mu.Lock()
+
+	p := &pendingOperation{op: op, target: StateConnected}
+	next := StateConnecting
+	if op == OpDisconnect {
+		p.target = StateDisconnected
+		next = StateDisconnecting
+	}
+	m.pending = p
+
+	var t *Transition
+	if m.state != next {
+		tr := m.setLocked(next, op.String()+" requested", "")
+		t = &tr
+	}
+	m.unlockAndNotify(t)
+}
+
+// EndOperation records that op has finished. A failed operation moves to the
+// Error state; a successful one waits for status readings to confirm it.
+func (m *StateMachine) EndOperation(op Operation, err error) {
+	m.mu.Lock()
+
+	p := m.pending
+	if p == nil || p.op != op {
+		m.unlockAndNotify(nil)
+		return
+	}
+
+	if err != nil {
+		m.pending = nil
+		t := m.setLocked(StateError, op.String()+" failed", err.Error())
+		m.unlockAndNotify(&t)
+		return
+	}
+
+	p.finished = true
+	p.deadline = m.now().Add(m.settleTime)
+	m.unlockAndNotify(nil)
+}
+
+// setLocked changes the state and returns the transition. Caller holds mu.
+func (m *StateMachine) setLocked(to ConnectionState, reason, errText string) Transition {
+	t := Transition{
+		From:   m.state,
+		To:     to,
+		Reason: reason,
+		Err:    errText,
+		At:     m.now(),
+	}
+	m.state = to
+	if to == StateError {
+		m.lastErr = errText
+	}
+	return t
+}
+
+// unlockAndNotify releases mu and delivers t, if any, to subscribers
+func (m *StateMachine) unlockAndNotify(t *Transition) {
+	subscribers := m.subscribers
+	m.mu.Unlock()
+
+	if t == nil {
+		return
+	}
+	for _, fn := range subscribers {
+		fn(*t)
+	}
+}
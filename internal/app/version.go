@@ -34,7 +34,7 @@ const (
 	Credits = `Built with Go and D-Bus
 StatusNotifierItem protocol
 Integrates with Twingate CLI
-System dialogs via zenity
+System dialogs via zenity, yad or kdialog
 Desktop notifications`
 )
 
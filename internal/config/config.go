@@ -0,0 +1,478 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FileName is the name of the configuration file inside the config directory
+const FileName = "config.toml"
+
+// Duration is a time.Duration that decodes from strings such as "500ms" or "2m"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText parses a Go duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+// Color is an RGB colour that decodes from "#rrggbb" or "#rgb". The zero
+// value means unset.
+type Color struct {
+	color.RGBA
+}
+
+// UnmarshalText parses a hex colour
+func (c *Color) UnmarshalText(text []byte) error {
+	s := string(text)
+	if s == "" {
+		c.RGBA = color.RGBA{}
+		return nil
+	}
+	var r, g, b uint8
+	var err error
+	switch len(s) {
+	case 7:
+		_, err = fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	case 4:
+		_, err = fmt.Sscanf(s, "#%1x%1x%1x", &r, &g, &b)
+		r, g, b = r*17, g*17, b*17
+	default:
+		err = errors.New("wrong length")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid colour %q, expected #rrggbb", s)
+	}
+	c.RGBA = color.RGBA{r, g, b, 255}
+	return nil
+}
+
+// MarshalText formats the colour as "#rrggbb", or "" when unset
+func (c Color) MarshalText() ([]byte, error) {
+	if c.RGBA == (color.RGBA{}) {
+		return []byte{}, nil
+	}
+	return []byte(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), nil
+}
+
+// Config is the user configuration loaded from config.toml.
+// See docs/CONFIG.md for the documented schema.
+type Config struct {
+	Status        StatusConfig        `toml:"status"`
+	Notifications NotificationsConfig `toml:"notifications"`
+	Network       NetworkConfig       `toml:"network"`
+	Commands      CommandsConfig      `toml:"commands"`
+	Dialogs       DialogsConfig       `toml:"dialogs"`
+	Reconnect     ReconnectConfig     `toml:"reconnect"`
+	NetworkRules  NetworkRulesConfig  `toml:"network_rules"`
+	Sleep         SleepConfig         `toml:"sleep"`
+	Icon          IconConfig          `toml:"icon"`
+	Tray          TrayConfig          `toml:"tray"`
+	Probe         ProbeConfig         `toml:"probe"`
+	Favorites     FavoritesConfig     `toml:"favorites"`
+	Launchers     LaunchersConfig     `toml:"launchers"`
+}
+
+// StatusConfig controls the status monitor
+type StatusConfig struct {
+	PollInterval       Duration `toml:"poll_interval"`      // While a change is in flight
+	IdlePollInterval   Duration `toml:"idle_poll_interval"` // While the state is settled
+	StabilityThreshold int      `toml:"stability_threshold"`
+}
+
+// NotificationsConfig controls desktop notifications
+type NotificationsConfig struct {
+	Timeout Duration `toml:"timeout"`
+}
+
+// NetworkConfig describes the Twingate network interface
+type NetworkConfig struct {
+	Interface string `toml:"interface"`
+}
+
+// CommandsConfig bounds external command execution
+type CommandsConfig struct {
+	Timeout           Duration `toml:"timeout"`
+	PrivilegedTimeout Duration `toml:"privileged_timeout"`
+}
+
+// ReconnectConfig controls automatic reconnection after a dropped connection
+type ReconnectConfig struct {
+	Enabled      bool     `toml:"enabled"`
+	InitialDelay Duration `toml:"initial_delay"`
+	MaxDelay     Duration `toml:"max_delay"`
+	Multiplier   float64  `toml:"multiplier"`
+	Jitter       float64  `toml:"jitter"`
+	MaxAttempts  int      `toml:"max_attempts"`
+}
+
+// NetworkRulesConfig connects or disconnects Twingate when NetworkManager's
+// primary connection changes
+type NetworkRulesConfig struct {
+	Enabled bool          `toml:"enabled"`
+	Rules   []NetworkRule `toml:"rules"`
+}
+
+// NetworkRule is one entry of [[network_rules.rules]]
+type NetworkRule struct {
+	SSID       string `toml:"ssid"`
+	Connection string `toml:"connection"`
+	Type       string `toml:"type"`
+	Action     string `toml:"action"`
+}
+
+// ProbeConfig controls resource reachability checks
+type ProbeConfig struct {
+	Enabled  bool     `toml:"enabled"`  // Probe periodically while connected
+	Interval Duration `toml:"interval"` // Between periodic rounds
+	Timeout  Duration `toml:"timeout"`  // For the DNS lookup and TCP connects of one resource
+	Ports    []int    `toml:"ports"`    // Tried for addresses that name no ports
+}
+
+// FavoritesConfig lists the resources pinned to the top level of the tray menu.
+// Store.SetFavorites rewrites it when resources are pinned from the menu.
+type FavoritesConfig struct {
+	Resources []string `toml:"resources"` // Resource names, in menu order
+}
+
+// LaunchersConfig lists the [[launchers]] entries, which open resources with
+// a command
+type LaunchersConfig []LauncherConfig
+
+// LauncherConfig is one entry of [[launchers]]
+type LauncherConfig struct {
+	Name     string `toml:"name"`
+	Resource string `toml:"resource"` // Glob on the resource name
+	Address  string `toml:"address"`  // Glob on the address or its host
+	Command  string `toml:"command"`
+	Terminal bool   `toml:"terminal"`
+}
+
+// SleepConfig controls suspend/resume handling
+type SleepConfig struct {
+	ReconnectOnWake bool `toml:"reconnect_on_wake"`
+}
+
+// Icon colour schemes
+const (
+	ColorSchemeAuto  = "auto"  // Follow the desktop portal's color-scheme setting
+	ColorSchemeDark  = "dark"  // Light icon for a dark panel
+	ColorSchemeLight = "light" // Dark icon for a light panel
+)
+
+// IconConfig controls how the tray icon looks
+type IconConfig struct {
+	ColorScheme string         `toml:"color_scheme"`
+	ThemeIcons  bool           `toml:"theme_icons"`
+	ThemePath   string         `toml:"theme_path"`
+	Colors      IconColors     `toml:"colors"`
+	Names       IconNameConfig `toml:"names"`
+}
+
+// IconColors overrides the lock colour per state
+type IconColors struct {
+	Connected    Color `toml:"connected"`
+	Disconnected Color `toml:"disconnected"`
+	Connecting   Color `toml:"connecting"`
+	Error        Color `toml:"error"`
+}
+
+// IconNameConfig overrides the theme icon name per state
+type IconNameConfig struct {
+	Connected    string `toml:"connected"`
+	Disconnected string `toml:"disconnected"`
+	Connecting   string `toml:"connecting"`
+	Error        string `toml:"error"`
+}
+
+// TrayConfig binds clicks and scrolling on the tray icon to actions
+type TrayConfig struct {
+	LeftClick   Binding `toml:"left_click"`
+	MiddleClick Binding `toml:"middle_click"`
+	Scroll      Binding `toml:"scroll"`
+}
+
+// Binding is an action name such as "toggle", or a table with an action
+// and the command to run for "command"
+type Binding struct {
+	Action  string   `toml:"action"`
+	Command []string `toml:"command"`
+}
+
+// UnmarshalTOML accepts either form of a binding
+func (b *Binding) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*b = Binding{Action: v}
+		return nil
+	case map[string]interface{}:
+		*b = Binding{}
+		for key, value := range v {
+			switch key {
+			case "action":
+				action, ok := value.(string)
+				if !ok {
+					return errors.New("action must be a string")
+				}
+				b.Action = action
+			case "command":
+				args, ok := value.([]interface{})
+				if !ok {
+					return errors.New("command must be an array of strings")
+				}
+				for _, arg := range args {
+					s, ok := arg.(string)
+					if !ok {
+						return errors.New("command must be an array of strings")
+					}
+					b.Command = append(b.Command, s)
+				}
+			default:
+				return fmt.Errorf("unknown key %q", key)
+			}
+		}
+		return nil
+	}
+	return errors.New("must be an action name or a table with action and command")
+}
+
+// DialogSize is the width and height of a dialog window in pixels
+type DialogSize struct {
+	Width  int `toml:"width"`
+	Height int `toml:"height"`
+}
+
+// DialogsConfig picks the program that shows dialogs and the size of each
+// dialog window
+type DialogsConfig struct {
+	Backend        string     `toml:"backend"`
+	ConnectionInfo DialogSize `toml:"connection_info"`
+	ExitNodes      DialogSize `toml:"exit_nodes"`
+	Resources      DialogSize `toml:"resources"`
+	Message        DialogSize `toml:"message"`
+}
+
+// Default returns the built-in configuration. The values match the defaults
+// of the packages the settings are handed to.
+func Default() *Config {
+	return &Config{
+		Status: StatusConfig{
+			PollInterval:       Duration{500 * time.Millisecond},
+			IdlePollInterval:   Duration{15 * time.Second},
+			StabilityThreshold: 3,
+		},
+		Notifications: NotificationsConfig{
+			Timeout: Duration{5 * time.Second},
+		},
+		Network: NetworkConfig{
+			Interface: "sdwan0",
+		},
+		Commands: CommandsConfig{
+			Timeout:           Duration{10 * time.Second},
+			PrivilegedTimeout: Duration{2 * time.Minute},
+		},
+		Dialogs: DialogsConfig{
+			Backend:        "auto",
+			ConnectionInfo: DialogSize{Width: 550, Height: 500},
+			ExitNodes:      DialogSize{Width: 400, Height: 300},
+			Resources:      DialogSize{Width: 600, Height: 400},
+			Message:        DialogSize{Width: 300},
+		},
+		Reconnect: ReconnectConfig{
+			Enabled:      false,
+			InitialDelay: Duration{2 * time.Second},
+			MaxDelay:     Duration{5 * time.Minute},
+			Multiplier:   2,
+			Jitter:       0.2,
+			MaxAttempts:  8,
+		},
+		Icon: IconConfig{
+			ColorScheme: ColorSchemeAuto,
+		},
+		Tray: TrayConfig{
+			LeftClick:   Binding{Action: "menu"},
+			MiddleClick: Binding{Action: "none"},
+			Scroll:      Binding{Action: "none"},
+		},
+		Probe: ProbeConfig{
+			Interval: Duration{5 * time.Minute},
+			Timeout:  Duration{3 * time.Second},
+			Ports:    []int{443, 80, 22},
+		},
+	}
+}
+
+// checks validate settings that only the packages they are handed to
+// understand. See AddCheck.
+var checks []func(*Config) error
+
+// AddCheck makes Validate also run fn. The program registers checks for the
+// action names and command templates it converts for other packages, so
+// this package does not have to import them. Call it before loading.
+func AddCheck(fn func(*Config) error) {
+	checks = append(checks, fn)
+}
+
+// Path returns the location of the configuration file, honouring XDG_CONFIG_HOME
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "twingate-tray", FileName)
+}
+
+// Load reads the configuration at path. Settings missing from the file keep
+// their defaults, and a missing file yields the defaults.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	md, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		log.Printf("Warning: Unknown settings in %s: %s", path, strings.Join(keys, ", "))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that every setting is within its allowed range
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Status.PollInterval.Duration < 100*time.Millisecond {
+		errs = append(errs, errors.New("status.poll_interval must be at least 100ms"))
+	}
+	if c.Status.IdlePollInterval.Duration < c.Status.PollInterval.Duration {
+		errs = append(errs, errors.New("status.idle_poll_interval must not be shorter than status.poll_interval"))
+	}
+	if c.Status.StabilityThreshold < 1 {
+		errs = append(errs, errors.New("status.stability_threshold must be at least 1"))
+	}
+	if c.Notifications.Timeout.Duration < 0 {
+		errs = append(errs, errors.New("notifications.timeout must not be negative"))
+	}
+	if c.Network.Interface == "" {
+		errs = append(errs, errors.New("network.interface must not be empty"))
+	}
+	if c.Commands.Timeout.Duration <= 0 {
+		errs = append(errs, errors.New("commands.timeout must be positive"))
+	}
+	if c.Commands.PrivilegedTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("commands.privileged_timeout must be positive"))
+	}
+
+	if c.Reconnect.InitialDelay.Duration <= 0 {
+		errs = append(errs, errors.New("reconnect.initial_delay must be positive"))
+	}
+	if c.Reconnect.MaxDelay.Duration < c.Reconnect.InitialDelay.Duration {
+		errs = append(errs, errors.New("reconnect.max_delay must not be less than reconnect.initial_delay"))
+	}
+	if c.Reconnect.Multiplier < 1 {
+		errs = append(errs, errors.New("reconnect.multiplier must be at least 1"))
+	}
+	if c.Reconnect.Jitter < 0 || c.Reconnect.Jitter > 1 {
+		errs = append(errs, errors.New("reconnect.jitter must be between 0 and 1"))
+	}
+	if c.Reconnect.MaxAttempts < 1 {
+		errs = append(errs, errors.New("reconnect.max_attempts must be at least 1"))
+	}
+
+	switch c.Icon.ColorScheme {
+	case ColorSchemeAuto, ColorSchemeDark, ColorSchemeLight:
+	default:
+		errs = append(errs, fmt.Errorf("icon.color_scheme must be %q, %q or %q", ColorSchemeAuto, ColorSchemeDark, ColorSchemeLight))
+	}
+
+	if c.Probe.Interval.Duration < 10*time.Second {
+		errs = append(errs, errors.New("probe.interval must be at least 10s"))
+	}
+	if c.Probe.Timeout.Duration <= 0 {
+		errs = append(errs, errors.New("probe.timeout must be positive"))
+	}
+	if len(c.Probe.Ports) == 0 {
+		errs = append(errs, errors.New("probe.ports must not be empty"))
+	}
+	for _, port := range c.Probe.Ports {
+		if port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("probe.ports: %d is not a TCP port", port))
+		}
+	}
+
+	for i, name := range c.Favorites.Resources {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("favorites.resources[%d] must not be empty", i))
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, l := range c.Launchers {
+		switch {
+		case strings.TrimSpace(l.Name) == "":
+			errs = append(errs, fmt.Errorf("launchers[%d]: name must not be empty", i))
+		case names[strings.ToLower(l.Name)]:
+			errs = append(errs, fmt.Errorf("launchers[%d]: name %q is used twice", i, l.Name))
+		}
+		names[strings.ToLower(l.Name)] = true
+	}
+
+	sizes := []struct {
+		name string
+		size DialogSize
+	}{
+		{"connection_info", c.Dialogs.ConnectionInfo},
+		{"exit_nodes", c.Dialogs.ExitNodes},
+		{"resources", c.Dialogs.Resources},
+		{"message", c.Dialogs.Message},
+	}
+	for _, d := range sizes {
+		if d.size.Width < 0 || d.size.Height < 0 {
+			errs = append(errs, fmt.Errorf("dialogs.%s size must not be negative", d.name))
+		}
+	}
+
+	for _, check := range checks {
+		if err := check(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
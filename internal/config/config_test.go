@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadProbePortsLeavesDefaultsAlone(t *testing.T) {
+	want := []int{443, 80, 22}
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("[probe]\nports = [8080]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Probe.Ports; !slices.Equal(got, []int{8080}) {
+		t.Errorf("loaded probe.ports = %v, want [8080]", got)
+	}
+	if got := Default().Probe.Ports; !slices.Equal(got, want) {
+		t.Errorf("Default().Probe.Ports = %v after loading an override, want %v", got, want)
+	}
+
+	// Removing the override brings the defaults back on reload
+	if err := os.WriteFile(path, []byte("[probe]\nenabled = true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Probe.Ports; !slices.Equal(got, want) {
+		t.Errorf("probe.ports after removing the override = %v, want %v", got, want)
+	}
+}
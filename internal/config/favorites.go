@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	tableHeader     = regexp.MustCompile(`^\s*\[`)
+	favoritesHeader = regexp.MustCompile(`^\s*\[\s*favorites\s*\]\s*(#.*)?$`)
+	resourcesKey    = regexp.MustCompile(`^\s*resources\s*=`)
+)
+
+// SetFavorites saves names as favorites.resources and reloads the file. Only
+// that key is rewritten, so comments and the other settings stay as written.
+func (s *Store) SetFavorites(names []string) error {
+	s.editMu.Lock()
+	defer s.editMu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	text, err := setFavorites(string(data), names)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", s.path, err)
+	}
+
+	// Never write a file that would not load, or that sets the favorites in a
+	// form the edit above missed, such as an inline table
+	cfg := Default()
+	if _, err := toml.Decode(text, cfg); err != nil {
+		return fmt.Errorf("failed to update %s: %w", s.path, err)
+	}
+	if !slices.Equal(cfg.Favorites.Resources, names) {
+		return fmt.Errorf("cannot update favorites in %s; set favorites.resources by hand", s.path)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration in %s: %w", s.path, err)
+	}
+
+	if err := writeFile(s.path, text); err != nil {
+		return err
+	}
+	return s.Reload()
+}
+
+// setFavorites replaces the resources key of the [favorites] table in text,
+// adding the key or the table when missing
+func setFavorites(text string, names []string) (string, error) {
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(map[string][]string{"resources": append([]string{}, names...)}); err != nil {
+		return "", err
+	}
+	line := strings.TrimSuffix(b.String(), "\n")
+
+	lines := strings.Split(text, "\n")
+	start := slices.IndexFunc(lines, favoritesHeader.MatchString)
+	if start < 0 {
+		text = strings.TrimRight(text, "\n")
+		if text != "" {
+			text += "\n\n"
+		}
+		return text + "[favorites]\n" + line + "\n", nil
+	}
+
+	end := start + 1
+	for end < len(lines) && !tableHeader.MatchString(lines[end]) {
+		end++
+	}
+	for i := start + 1; i < end; i++ {
+		if !resourcesKey.MatchString(lines[i]) {
+			continue
+		}
+		// An array may span lines; it ends on the first line that completes it
+		last := i
+		for last < end-1 && !complete(lines[i:last+1]) {
+			last++
+		}
+		lines = slices.Replace(lines, i, last+1, line)
+		return strings.Join(lines, "\n"), nil
+	}
+	return strings.Join(slices.Insert(lines, start+1, line), "\n"), nil
+}
+
+// complete reports whether lines hold a whole TOML key/value pair
+func complete(lines []string) bool {
+	var v map[string]interface{}
+	_, err := toml.Decode(strings.Join(lines, "\n"), &v)
+	return err == nil
+}
+
+// writeFile replaces path with text through a temporary file, so a crash
+// never leaves it half written. A symlinked path is resolved first, so the
+// file it points to is replaced rather than the link, and the file keeps its
+// permissions.
+func writeFile(path, text string) error {
+	target, err := filepath.EvalSymlinks(path)
+	switch {
+	case err == nil:
+		path = target
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	default:
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink to a missing file", path)
+		}
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), mode); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// WriteFile only uses the mode when it creates the file, and the umask
+	// may have narrowed it
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetFavorites(t *testing.T) {
+	tests := []struct {
+		text  string
+		names []string
+		want  string
+	}{
+		{"", []string{"Wiki"}, "[favorites]\nresources = [\"Wiki\"]\n"},
+		{"[probe]\nenabled = true\n", []string{"Wiki"}, "[probe]\nenabled = true\n\n[favorites]\nresources = [\"Wiki\"]\n"},
+		{"[favorites] # Pinned\nresources = [\"Old\"]\n[probe]\n", []string{"A", "B"}, "[favorites] # Pinned\nresources = [\"A\", \"B\"]\n[probe]\n"},
+		{"[favorites]\nresources = [\n  \"Old\",\n]\n", nil, "[favorites]\nresources = []\n"},
+		{"[favorites]\n[probe]\n", []string{"Wiki"}, "[favorites]\nresources = [\"Wiki\"]\n[probe]\n"},
+	}
+	for _, tt := range tests {
+		got, err := setFavorites(tt.text, tt.names)
+		if err != nil || got != tt.want {
+			t.Errorf("setFavorites(%q, %q) = %q, %v; want %q", tt.text, tt.names, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetFavoritesKeepsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", FileName)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("# Managed in dotfiles\n[probe]\nenabled = true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A typical umask narrows 0660, so only an explicit chmod keeps it
+	if err := os.Chmod(target, 0o660); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, FileName)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(link)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := store.SetFavorites([]string{"Wiki"}); err != nil {
+		t.Fatalf("SetFavorites: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("%s is no longer a symlink (%v)", link, err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# Managed in dotfiles") || !strings.Contains(string(data), `resources = ["Wiki"]`) {
+		t.Errorf("target = %q, want the comment kept and the favorites added", data)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o660 {
+		t.Errorf("target mode = %v, want 0660", info.Mode().Perm())
+	}
+	if got := store.Get().Favorites.Resources; !slices.Equal(got, []string{"Wiki"}) {
+		t.Errorf("favorites after reload = %q, want [Wiki]", got)
+	}
+}
+
+func TestSetFavoritesDanglingSymlink(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, FileName)
+	if err := os.Symlink(filepath.Join(dir, "missing.toml"), link); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(link)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := store.SetFavorites([]string{"Wiki"}); err == nil {
+		t.Error("SetFavorites replaced a dangling symlink")
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink (%v)", link, err)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store holds the active configuration and reloads it on request or when
+// the file changes on disk
+type Store struct {
+	path   string
+	editMu sync.Mutex // Serializes SetFavorites
+
+	mu          sync.RWMutex
+	cfg         *Config
+	modTime     time.Time
+	subscribers []func(*Config)
+}
+
+// NewStore loads the configuration at path. If the file is invalid the
+// defaults are used and the error is returned alongside the store.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, cfg: Default()}
+	s.modTime = s.fileModTime()
+
+	cfg, err := Load(path)
+	if err != nil {
+		return s, err
+	}
+	s.cfg = cfg
+	return s, nil
+}
+
+// Path returns the file the store loads from
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get returns the active configuration. Callers must not modify it.
+func (s *Store) Get() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// OnChange registers fn to be called with the new configuration after every
+// successful reload
+func (s *Store) OnChange(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload re-reads the file. An invalid file leaves the active configuration
+// untouched.
+func (s *Store) Reload() error {
+	modTime := s.fileModTime()
+	cfg, err := Load(s.path)
+
+	s.mu.Lock()
+	s.modTime = modTime
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.cfg = cfg
+	subscribers := s.subscribers
+	s.mu.Unlock()
+
+	log.Printf("Configuration loaded from %s", s.path)
+	for _, fn := range subscribers {
+		fn(cfg)
+	}
+	return nil
+}
+
+// Watch reloads the configuration whenever the file's modification time
+// changes, checking every interval until ctx is cancelled
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.RLock()
+			last := s.modTime
+			s.mu.RUnlock()
+
+			if modTime := s.fileModTime(); !modTime.Equal(last) {
+				if err := s.Reload(); err != nil {
+					log.Printf("Failed to reload configuration: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// fileModTime returns the file's modification time, or zero if it is missing
+func (s *Store) fileModTime() time.Time {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
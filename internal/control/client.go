@@ -0,0 +1,131 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrNoDaemon is returned by Dial when no tray daemon owns the control name
+var ErrNoDaemon = errors.New("twingate-tray daemon is not running")
+
+// Client talks to a running daemon's control interface
+type Client struct {
+	conn *dbus.Conn
+	obj  dbus.BusObject
+}
+
+// Dial connects to the session bus and returns a Client for the running
+// daemon, or ErrNoDaemon if there is none
+func Dial() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to D-Bus: %w", err)
+	}
+
+	var hasOwner bool
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, BusName).Store(&hasOwner)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to look up %s: %w", BusName, err)
+	}
+	if !hasOwner {
+		conn.Close()
+		return nil, ErrNoDaemon
+	}
+
+	return &Client{conn: conn, obj: conn.Object(BusName, ObjectPath)}, nil
+}
+
+// Close releases the bus connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Connect asks the daemon to connect
+func (c *Client) Connect() error {
+	return c.obj.Call(Interface+".Connect", 0).Err
+}
+
+// Disconnect asks the daemon to disconnect
+func (c *Client) Disconnect() error {
+	return c.obj.Call(Interface+".Disconnect", 0).Err
+}
+
+// Refresh asks the daemon to re-read the Twingate status
+func (c *Client) Refresh() error {
+	return c.obj.Call(Interface+".Refresh", 0).Err
+}
+
+// SwitchExitNode asks the daemon to switch to node
+func (c *Client) SwitchExitNode(node string) error {
+	return c.obj.Call(Interface+".SwitchExitNode", 0, node).Err
+}
+
+// ListResources returns the resources known to the daemon
+func (c *Client) ListResources() ([]Resource, error) {
+	var resources []Resource
+	err := c.obj.Call(Interface+".ListResources", 0).Store(&resources)
+	return resources, err
+}
+
+// Status reads the daemon's properties
+func (c *Client) Status() (Status, error) {
+	var props map[string]dbus.Variant
+	err := c.obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, Interface).Store(&props)
+	if err != nil {
+		return Status{}, err
+	}
+
+	var status Status
+	if v, ok := props["State"]; ok {
+		status.State, _ = v.Value().(string)
+	}
+	if v, ok := props["Network"]; ok {
+		status.Network, _ = v.Value().(string)
+	}
+	if v, ok := props["User"]; ok {
+		status.User, _ = v.Value().(string)
+	}
+	if v, ok := props["Interface"]; ok {
+		status.Interface, _ = v.Value().(string)
+	}
+	if v, ok := props["ExitNode"]; ok {
+		status.ExitNode, _ = v.Value().(string)
+	}
+	if v, ok := props["ConnectedSince"]; ok {
+		status.ConnectedSince, _ = v.Value().(int64)
+	}
+	return status, nil
+}
+
+// WatchState subscribes to StateChanged signals. The returned channel is
+// closed when the client is closed.
+func (c *Client) WatchState() (<-chan StateChange, error) {
+	err := c.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(ObjectPath),
+		dbus.WithMatchInterface(Interface),
+		dbus.WithMatchMember("StateChanged"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to StateChanged: %w", err)
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	c.conn.Signal(signals)
+
+	changes := make(chan StateChange, 16)
+	go func() {
+		defer close(changes)
+		for sig := range signals {
+			if sig.Name != Interface+".StateChanged" || len(sig.Body) < 2 {
+				continue
+			}
+			state, _ := sig.Body[0].(string)
+			reason, _ := sig.Body[1].(string)
+			changes <- StateChange{State: state, Reason: reason}
+		}
+	}()
+	return changes, nil
+}
@@ -0,0 +1,35 @@
+package control
+
+import "github.com/godbus/dbus/v5"
+
+// D-Bus names of the daemon control interface. The running tray exports it on
+// the session bus so the CLI, panel widgets and scripts share one source of truth.
+const (
+	BusName    = "io.github.bisand.TwingateTray1"
+	ObjectPath = dbus.ObjectPath("/io/github/bisand/TwingateTray1")
+	Interface  = "io.github.bisand.TwingateTray1"
+)
+
+// Resource is a Twingate resource as returned by ListResources: a(sssb)
+type Resource struct {
+	Name       string
+	Address    string
+	AuthStatus string
+	NeedsAuth  bool
+}
+
+// StateChange is the payload of the StateChanged signal: (ss)
+type StateChange struct {
+	State  string // app.ConnectionState name, e.g. "connected"
+	Reason string // Why the state changed, or the error for the "error" state
+}
+
+// Status is a snapshot of the daemon's properties
+type Status struct {
+	State          string
+	Network        string
+	User           string
+	Interface      string // Empty while the tunnel is down
+	ExitNode       string // Empty when traffic does not use an exit node
+	ConnectedSince int64  // Unix seconds, 0 when not connected
+}
@@ -0,0 +1,146 @@
+package control
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// privateBus starts a dbus-daemon for the test and makes it the session bus
+func privateBus(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the bus address: %v", err)
+	}
+	address = strings.TrimSpace(address)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+	return address
+}
+
+func startServer(t *testing.T, address string, handlers Handlers) *Server {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect to the private bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	server := NewServer(conn, handlers)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return server
+}
+
+func dial(t *testing.T) *Client {
+	t.Helper()
+	client, err := Dial()
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestDialWithoutDaemon(t *testing.T) {
+	privateBus(t)
+	if client, err := Dial(); !errors.Is(err, ErrNoDaemon) {
+		if client != nil {
+			client.Close()
+		}
+		t.Errorf("Dial = %v, want ErrNoDaemon", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	server := startServer(t, privateBus(t), Handlers{})
+	client := dial(t)
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status != (Status{State: "disconnected"}) {
+		t.Errorf("Status = %+v before any update, want disconnected", status)
+	}
+
+	since := time.Unix(1760000000, 0)
+	server.SetNetwork("acme")
+	server.SetUser("ada@acme.example")
+	server.SetInterface("sdwan0")
+	server.SetExitNode("Oslo")
+	server.SetState("connected", "", since)
+
+	status, err = client.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	want := Status{
+		State:          "connected",
+		Network:        "acme",
+		User:           "ada@acme.example",
+		Interface:      "sdwan0",
+		ExitNode:       "Oslo",
+		ConnectedSince: since.Unix(),
+	}
+	if status != want {
+		t.Errorf("Status = %+v, want %+v", status, want)
+	}
+}
+
+func TestListResources(t *testing.T) {
+	want := []Resource{
+		{Name: "Database", Address: "db.internal:5432", AuthStatus: "locked", NeedsAuth: true},
+		{Name: "Wiki", Address: "wiki.internal", AuthStatus: "authenticated"},
+	}
+	startServer(t, privateBus(t), Handlers{
+		ListResources: func() ([]Resource, error) { return want, nil },
+	})
+
+	got, err := dial(t).ListResources()
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListResources = %+v, want %+v", got, want)
+	}
+}
+
+func TestListResourcesErrors(t *testing.T) {
+	tests := []struct {
+		handler func() ([]Resource, error)
+		want    string
+	}{
+		{nil, "ListResources is not supported by this daemon"},
+		{func() ([]Resource, error) { return nil, errors.New("twingate is not running") }, "twingate is not running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			startServer(t, privateBus(t), Handlers{ListResources: tt.handler})
+			if _, err := dial(t).ListResources(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ListResources error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
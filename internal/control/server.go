@@ -0,0 +1,217 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// Handlers implement the daemon control methods. Connect, Disconnect and
+// SwitchExitNode only start the operation; progress is reported through the
+// State property and the StateChanged signal.
+type Handlers struct {
+	Connect        func()
+	Disconnect     func()
+	Refresh        func() error
+	SwitchExitNode func(node string)
+	ListResources  func() ([]Resource, error)
+}
+
+// Server exports the control interface on the session bus
+type Server struct {
+	conn     *dbus.Conn
+	handlers Handlers
+	props    *prop.Properties
+}
+
+// NewServer creates a control server on an existing bus connection
+func NewServer(conn *dbus.Conn, handlers Handlers) *Server {
+	return &Server{conn: conn, handlers: handlers}
+}
+
+// Start claims the well-known name and exports the interface
+func (s *Server) Start() error {
+	reply, err := s.conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("failed to request bus name %s: %w", BusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("bus name %s is already owned (reply=%d)", BusName, reply)
+	}
+
+	if err := s.conn.Export(&methods{handlers: s.handlers}, ObjectPath, Interface); err != nil {
+		return fmt.Errorf("failed to export %s: %w", Interface, err)
+	}
+
+	s.props, err = prop.Export(s.conn, ObjectPath, prop.Map{
+		Interface: {
+			"State":          {Value: "disconnected", Emit: prop.EmitTrue},
+			"Network":        {Value: "", Emit: prop.EmitTrue},
+			"User":           {Value: "", Emit: prop.EmitTrue},
+			"Interface":      {Value: "", Emit: prop.EmitTrue},
+			"ExitNode":       {Value: "", Emit: prop.EmitTrue},
+			"ConnectedSince": {Value: int64(0), Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export control properties: %w", err)
+	}
+
+	node := introspect.Node{
+		Name: string(ObjectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name: Interface,
+				Methods: []introspect.Method{
+					{Name: "Connect"},
+					{Name: "Disconnect"},
+					{Name: "Refresh"},
+					{Name: "SwitchExitNode", Args: []introspect.Arg{
+						{Name: "node", Type: "s", Direction: "in"},
+					}},
+					{Name: "ListResources", Args: []introspect.Arg{
+						{Name: "resources", Type: "a(sssb)", Direction: "out"},
+					}},
+				},
+				Signals: []introspect.Signal{
+					{Name: "StateChanged", Args: []introspect.Arg{
+						{Name: "state", Type: "s"},
+						{Name: "reason", Type: "s"},
+					}},
+				},
+				Properties: s.props.Introspection(Interface),
+			},
+		},
+	}
+	if err := s.conn.Export(introspect.NewIntrospectable(&node), ObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return fmt.Errorf("failed to export control introspection: %w", err)
+	}
+
+	log.Printf("Control interface available at %s", BusName)
+	return nil
+}
+
+// SetState publishes a new connection state and emits StateChanged
+func (s *Server) SetState(state, reason string, connectedSince time.Time) {
+	if s.props == nil {
+		return
+	}
+
+	since := int64(0)
+	if !connectedSince.IsZero() {
+		since = connectedSince.Unix()
+	}
+	s.setProperty("ConnectedSince", since)
+	s.setProperty("State", state)
+
+	if err := s.conn.Emit(ObjectPath, Interface+".StateChanged", state, reason); err != nil {
+		log.Printf("Failed to emit StateChanged: %v", err)
+	}
+}
+
+// SetNetwork publishes the current network name
+func (s *Server) SetNetwork(network string) {
+	if s.props == nil {
+		return
+	}
+	s.setProperty("Network", network)
+}
+
+// SetUser publishes the signed-in account
+func (s *Server) SetUser(user string) {
+	if s.props == nil {
+		return
+	}
+	s.setProperty("User", user)
+}
+
+// SetInterface publishes the tunnel interface, or "" while it is down
+func (s *Server) SetInterface(name string) {
+	if s.props == nil {
+		return
+	}
+	s.setProperty("Interface", name)
+}
+
+// SetExitNode publishes the exit node in use, or "" for none
+func (s *Server) SetExitNode(node string) {
+	if s.props == nil {
+		return
+	}
+	s.setProperty("ExitNode", node)
+}
+
+// setProperty updates a property. prop.SetMust panics when the change signal
+// cannot be sent, e.g. on a bus closed during shutdown, so that is only logged.
+func (s *Server) setProperty(name string, value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Failed to set %s: %v", name, r)
+		}
+	}()
+	s.props.SetMust(Interface, name, value)
+}
+
+// methods holds the exported D-Bus methods so Server's own API stays private
+type methods struct {
+	handlers Handlers
+}
+
+func (m *methods) Connect() *dbus.Error {
+	if m.handlers.Connect == nil {
+		return notSupported("Connect")
+	}
+	go m.handlers.Connect()
+	return nil
+}
+
+func (m *methods) Disconnect() *dbus.Error {
+	if m.handlers.Disconnect == nil {
+		return notSupported("Disconnect")
+	}
+	go m.handlers.Disconnect()
+	return nil
+}
+
+func (m *methods) Refresh() *dbus.Error {
+	if m.handlers.Refresh == nil {
+		return notSupported("Refresh")
+	}
+	if err := m.handlers.Refresh(); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *methods) SwitchExitNode(node string) *dbus.Error {
+	if m.handlers.SwitchExitNode == nil {
+		return notSupported("SwitchExitNode")
+	}
+	go m.handlers.SwitchExitNode(node)
+	return nil
+}
+
+func (m *methods) ListResources() ([]Resource, *dbus.Error) {
+	if m.handlers.ListResources == nil {
+		return nil, notSupported("ListResources")
+	}
+	resources, err := m.handlers.ListResources()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	if resources == nil {
+		resources = []Resource{}
+	}
+	return resources, nil
+}
+
+// notSupported returns the error for a method without a handler
+func notSupported(method string) *dbus.Error {
+	return dbus.MakeFailedError(fmt.Errorf("%s is not supported by this daemon", method))
+}
@@ -0,0 +1,125 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Backend names accepted by Select
+const (
+	Auto    = "auto"
+	Zenity  = "zenity"
+	Yad     = "yad"
+	KDialog = "kdialog"
+	Builtin = "builtin" // No dialog program; see Fallback
+)
+
+// ErrUnavailable is returned by dialogs the backend cannot show
+var ErrUnavailable = errors.New("no dialog program available")
+
+// Size is a window size in pixels. A zero dimension is left to the program.
+type Size struct {
+	Width  int
+	Height int
+}
+
+// Options are shared by all dialogs
+type Options struct {
+	Title string
+	Size  Size
+	Icon  string // Image file shown with the text, if the program supports it
+}
+
+// Backend shows dialogs. Every method blocks until the dialog is closed.
+// Cancelling is not an error: it returns ok false.
+type Backend interface {
+	// Name returns the backend's name, e.g. "zenity"
+	Name() string
+
+	// Info shows a message with an OK button
+	Info(opts Options, text string) error
+
+	// List lets the user pick one of items and returns it
+	List(opts Options, text string, items []string) (selected string, ok bool, err error)
+
+	// TextInfo shows scrollable monospace text. With a non-empty extra a
+	// second button is shown, and extraPressed reports whether it closed
+	// the dialog.
+	TextInfo(opts Options, text, extra string) (extraPressed bool, err error)
+
+	// Question asks a yes/no question and reports whether the answer was yes
+	Question(opts Options, text string) (yes bool, err error)
+
+	// Entry asks for a line of text, starting with initial
+	Entry(opts Options, text, initial string) (value string, ok bool, err error)
+}
+
+// Select returns the backend called name. Auto picks the first installed
+// program, preferring kdialog on KDE and zenity elsewhere. When the program
+// is missing, or name is Builtin, fallback is returned; the error then says
+// why unless Builtin was asked for.
+func Select(name string, fallback Backend) (Backend, error) {
+	switch name {
+	case Builtin:
+		return fallback, nil
+	case Auto, "":
+		for _, candidate := range detectionOrder() {
+			if backend, err := Select(candidate, nil); err == nil {
+				return backend, nil
+			}
+		}
+		return fallback, fmt.Errorf("%w: install zenity, yad or kdialog", ErrUnavailable)
+	}
+
+	var backend Backend
+	switch name {
+	case Zenity:
+		backend = zenity{}
+	case Yad:
+		backend = yad{}
+	case KDialog:
+		backend = kdialog{}
+	default:
+		return fallback, fmt.Errorf("unknown dialog backend %q", name)
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fallback, fmt.Errorf("%w: %s is not installed", ErrUnavailable, name)
+	}
+	return backend, nil
+}
+
+// detectionOrder lists the programs Auto tries, in order
+func detectionOrder() []string {
+	desktop := strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP"))
+	if strings.Contains(desktop, "KDE") {
+		return []string{KDialog, Zenity, Yad}
+	}
+	return []string{Zenity, Yad, KDialog}
+}
+
+// run starts a dialog program with stdin as its input and returns its
+// trimmed output and exit code. err is only set if the program could not run.
+func run(name string, args []string, stdin string) (string, int, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	out, err := cmd.Output()
+	output := strings.TrimRight(string(out), "\n")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+			return output, exitErr.ExitCode(), nil
+		}
+		return output, -1, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return output, 0, nil
+}
+
+// exitError reports an exit code that means neither OK nor cancel
+func exitError(name string, code int) error {
+	return fmt.Errorf("%s exited with status %d", name, code)
+}
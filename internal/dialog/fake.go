@@ -0,0 +1,91 @@
+package dialog
+
+import (
+	"sync"
+)
+
+// Call is a dialog shown through a Fake
+type Call struct {
+	Kind    string // "info", "list", "textinfo", "question" or "entry"
+	Options Options
+	Text    string
+	Items   []string // List only
+	Extra   string   // TextInfo only
+	Initial string   // Entry only
+}
+
+// Answer is how the user closes a dialog shown through a Fake. Value is the
+// selected item or entered text. OK false cancels; for TextInfo it means the
+// extra button was not pressed, and for Question the answer was no.
+type Answer struct {
+	Value string
+	OK    bool
+	Err   error
+}
+
+// Fake records the dialogs it is asked to show and answers them in order,
+// so the flows that use dialogs can run without a display. Dialogs beyond
+// the queued answers are cancelled.
+type Fake struct {
+	mu      sync.Mutex
+	calls   []Call
+	answers []Answer
+}
+
+// NewFake creates a fake that gives answers in order
+func NewFake(answers ...Answer) *Fake {
+	return &Fake{answers: answers}
+}
+
+// Calls returns the dialogs shown so far
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Answer queues more answers
+func (f *Fake) Answer(answers ...Answer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.answers = append(f.answers, answers...)
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) Info(opts Options, text string) error {
+	return f.record(Call{Kind: "info", Options: opts, Text: text}).Err
+}
+
+func (f *Fake) List(opts Options, text string, items []string) (string, bool, error) {
+	a := f.record(Call{Kind: "list", Options: opts, Text: text, Items: append([]string(nil), items...)})
+	return a.Value, a.OK, a.Err
+}
+
+func (f *Fake) TextInfo(opts Options, text, extra string) (bool, error) {
+	a := f.record(Call{Kind: "textinfo", Options: opts, Text: text, Extra: extra})
+	return a.OK, a.Err
+}
+
+func (f *Fake) Question(opts Options, text string) (bool, error) {
+	a := f.record(Call{Kind: "question", Options: opts, Text: text})
+	return a.OK, a.Err
+}
+
+func (f *Fake) Entry(opts Options, text, initial string) (string, bool, error) {
+	a := f.record(Call{Kind: "entry", Options: opts, Text: text, Initial: initial})
+	return a.Value, a.OK, a.Err
+}
+
+// record stores call and pops the next answer
+func (f *Fake) record(call Call) Answer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	if len(f.answers) == 0 {
+		return Answer{}
+	}
+	a := f.answers[0]
+	f.answers = f.answers[1:]
+	return a
+}
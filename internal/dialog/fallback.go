@@ -0,0 +1,55 @@
+package dialog
+
+import (
+	"strings"
+)
+
+// fallbackTextLimit caps the text put in a notification
+const fallbackTextLimit = 1000
+
+// Fallback is the built-in backend used when no dialog program is installed.
+// Messages become desktop notifications; dialogs that need an answer tell
+// the user what to install and return ErrUnavailable.
+type Fallback struct {
+	notify func(title, body string)
+}
+
+// NewFallback creates a fallback that shows messages with notify
+func NewFallback(notify func(title, body string)) *Fallback {
+	return &Fallback{notify: notify}
+}
+
+func (f *Fallback) Name() string { return Builtin }
+
+func (f *Fallback) Info(opts Options, text string) error {
+	f.notify(opts.Title, text)
+	return nil
+}
+
+func (f *Fallback) List(opts Options, text string, items []string) (string, bool, error) {
+	f.unavailable(opts)
+	return "", false, ErrUnavailable
+}
+
+// TextInfo shows the start of text; the extra button cannot be offered
+func (f *Fallback) TextInfo(opts Options, text, extra string) (bool, error) {
+	if len(text) > fallbackTextLimit {
+		text = strings.ToValidUTF8(text[:fallbackTextLimit], "") + "…"
+	}
+	f.notify(opts.Title, strings.TrimSpace(text))
+	return false, nil
+}
+
+func (f *Fallback) Question(opts Options, text string) (bool, error) {
+	f.unavailable(opts)
+	return false, ErrUnavailable
+}
+
+func (f *Fallback) Entry(opts Options, text, initial string) (string, bool, error) {
+	f.unavailable(opts)
+	return "", false, ErrUnavailable
+}
+
+func (f *Fallback) unavailable(opts Options) {
+	f.notify(opts.Title, "Install zenity, yad or kdialog to use this dialog.")
+}
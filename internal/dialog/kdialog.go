@@ -0,0 +1,103 @@
+package dialog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// kdialogCancel is kdialog's exit code for Cancel, No and closing the window
+const kdialogCancel = 1
+
+// kdialog shows dialogs with KDE's kdialog
+type kdialog struct{}
+
+func (kdialog) Name() string { return KDialog }
+
+// args returns the flags shared by every dialog
+func (kdialog) args(opts Options) []string {
+	args := []string{"--title", opts.Title}
+	if opts.Size.Width > 0 && opts.Size.Height > 0 {
+		args = append(args, "--geometry", fmt.Sprintf("%dx%d", opts.Size.Width, opts.Size.Height))
+	}
+	if opts.Icon != "" {
+		args = append(args, "--icon", opts.Icon)
+	}
+	return args
+}
+
+func (k kdialog) Info(opts Options, text string) error {
+	_, code, err := run(KDialog, append(k.args(opts), "--msgbox", text), "")
+	return k.check(code, err)
+}
+
+// List uses a menu whose tags are the item indexes, so items may contain
+// anything
+func (k kdialog) List(opts Options, text string, items []string) (string, bool, error) {
+	args := append(k.args(opts), "--menu", text)
+	for i, item := range items {
+		args = append(args, strconv.Itoa(i), item)
+	}
+	out, code, err := run(KDialog, args, "")
+	if err := k.check(code, err); err != nil || code != 0 {
+		return "", false, err
+	}
+	i, err := strconv.Atoi(out)
+	if err != nil || i < 0 || i >= len(items) {
+		return "", false, fmt.Errorf("kdialog returned unexpected selection %q", out)
+	}
+	return items[i], true, nil
+}
+
+// TextInfo shows the text in a text box. kdialog's text box has no extra
+// buttons, so extra is offered in a question afterwards.
+func (k kdialog) TextInfo(opts Options, text, extra string) (bool, error) {
+	f, err := os.CreateTemp("", "twingate-tray-*.txt")
+	if err != nil {
+		return false, fmt.Errorf("failed to write text for kdialog: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to write text for kdialog: %w", err)
+	}
+
+	args := append(k.args(opts), "--textbox", f.Name())
+	if opts.Size.Width > 0 && opts.Size.Height > 0 {
+		args = append(args, strconv.Itoa(opts.Size.Width), strconv.Itoa(opts.Size.Height))
+	}
+	_, code, err := run(KDialog, args, "")
+	if err := k.check(code, err); err != nil || extra == "" {
+		return false, err
+	}
+
+	_, code, err = run(KDialog, []string{"--title", opts.Title, "--yes-label", extra, "--no-label", "Close", "--yesno", extra + "?"}, "")
+	return code == 0, k.check(code, err)
+}
+
+func (k kdialog) Question(opts Options, text string) (bool, error) {
+	_, code, err := run(KDialog, append(k.args(opts), "--yesno", text), "")
+	return code == 0, k.check(code, err)
+}
+
+func (k kdialog) Entry(opts Options, text, initial string) (string, bool, error) {
+	out, code, err := run(KDialog, append(k.args(opts), "--inputbox", text, initial), "")
+	if err := k.check(code, err); err != nil || code != 0 {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+// check turns an exit code other than OK or cancel into an error
+func (kdialog) check(code int, err error) error {
+	if err != nil {
+		return err
+	}
+	if code == 0 || code == kdialogCancel {
+		return nil
+	}
+	return exitError(KDialog, code)
+}
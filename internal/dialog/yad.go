@@ -0,0 +1,88 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yad exit codes besides 0 (OK)
+const (
+	yadCancel = 1
+	yadExtra  = 2 // Our extra button
+	yadEscape = 252
+)
+
+// yad shows dialogs with yad, a zenity fork that can show large images
+type yad struct{}
+
+func (yad) Name() string { return Yad }
+
+// args returns the flags shared by every dialog
+func (yad) args(opts Options) []string {
+	args := []string{"--title=" + opts.Title, "--center"}
+	if opts.Size.Width > 0 {
+		args = append(args, fmt.Sprintf("--width=%d", opts.Size.Width))
+	}
+	if opts.Size.Height > 0 {
+		args = append(args, fmt.Sprintf("--height=%d", opts.Size.Height))
+	}
+	if opts.Icon != "" {
+		args = append(args, "--image="+opts.Icon, "--image-on-top")
+	}
+	return args
+}
+
+func (y yad) Info(opts Options, text string) error {
+	args := append(y.args(opts), "--text="+text, "--buttons-layout=center", "--button=OK:0")
+	_, code, err := run(Yad, args, "")
+	return y.check(code, err)
+}
+
+func (y yad) List(opts Options, text string, items []string) (string, bool, error) {
+	args := append(y.args(opts), "--list", "--text="+text, "--column=", "--no-headers", "--separator=")
+	out, code, err := run(Yad, append(args, items...), "")
+	if err := y.check(code, err); err != nil || code != 0 || out == "" {
+		return "", false, err
+	}
+	return strings.TrimSuffix(out, "|"), true, nil
+}
+
+func (y yad) TextInfo(opts Options, text, extra string) (bool, error) {
+	args := append(y.args(opts), "--text-info", "--fontname=monospace 10")
+	if extra != "" {
+		args = append(args, "--button="+extra+":2")
+	}
+	args = append(args, "--button=OK:0")
+	_, code, err := run(Yad, args, text)
+	if code == yadExtra {
+		return true, nil
+	}
+	return false, y.check(code, err)
+}
+
+func (y yad) Question(opts Options, text string) (bool, error) {
+	args := append(y.args(opts), "--text="+text, "--button=No:1", "--button=Yes:0")
+	_, code, err := run(Yad, args, "")
+	return code == 0, y.check(code, err)
+}
+
+func (y yad) Entry(opts Options, text, initial string) (string, bool, error) {
+	args := append(y.args(opts), "--entry", "--text="+text, "--entry-text="+initial)
+	out, code, err := run(Yad, args, "")
+	if err := y.check(code, err); err != nil || code != 0 {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+// check turns an exit code other than OK or cancel into an error
+func (yad) check(code int, err error) error {
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 0, yadCancel, yadExtra, yadEscape:
+		return nil
+	}
+	return exitError(Yad, code)
+}
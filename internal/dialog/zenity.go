@@ -0,0 +1,85 @@
+package dialog
+
+import (
+	"fmt"
+)
+
+// zenity exit codes besides 0 (OK)
+const (
+	zenityCancel  = 1
+	zenityTimeout = 5
+)
+
+// zenity shows dialogs with GNOME's zenity
+type zenity struct{}
+
+func (zenity) Name() string { return Zenity }
+
+// args returns the flags shared by every dialog
+func (zenity) args(kind string, opts Options) []string {
+	args := []string{kind, "--title=" + opts.Title}
+	if opts.Size.Width > 0 {
+		args = append(args, fmt.Sprintf("--width=%d", opts.Size.Width))
+	}
+	if opts.Size.Height > 0 {
+		args = append(args, fmt.Sprintf("--height=%d", opts.Size.Height))
+	}
+	if opts.Icon != "" {
+		// zenity only shows the icon in the title bar
+		args = append(args, "--window-icon="+opts.Icon)
+	}
+	return args
+}
+
+func (z zenity) Info(opts Options, text string) error {
+	_, code, err := run(Zenity, append(z.args("--info", opts), "--text="+text), "")
+	return z.check(code, err)
+}
+
+func (z zenity) List(opts Options, text string, items []string) (string, bool, error) {
+	args := append(z.args("--list", opts), "--text="+text, "--column=", "--hide-header")
+	out, code, err := run(Zenity, append(args, items...), "")
+	if err := z.check(code, err); err != nil || code != 0 || out == "" {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+func (z zenity) TextInfo(opts Options, text, extra string) (bool, error) {
+	args := append(z.args("--text-info", opts), "--font=monospace 10", "--ok-label=OK")
+	if extra != "" {
+		args = append(args, "--extra-button="+extra)
+	}
+	out, code, err := run(Zenity, args, text)
+	// The extra button exits like cancel but prints its label
+	if extra != "" && code == zenityCancel && out == extra {
+		return true, nil
+	}
+	return false, z.check(code, err)
+}
+
+func (z zenity) Question(opts Options, text string) (bool, error) {
+	_, code, err := run(Zenity, append(z.args("--question", opts), "--text="+text), "")
+	return code == 0, z.check(code, err)
+}
+
+func (z zenity) Entry(opts Options, text, initial string) (string, bool, error) {
+	args := append(z.args("--entry", opts), "--text="+text, "--entry-text="+initial)
+	out, code, err := run(Zenity, args, "")
+	if err := z.check(code, err); err != nil || code != 0 {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+// check turns an exit code other than OK, cancel or timeout into an error
+func (zenity) check(code int, err error) error {
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 0, zenityCancel, zenityTimeout:
+		return nil
+	}
+	return exitError(Zenity, code)
+}
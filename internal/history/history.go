@@ -0,0 +1,214 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the history journal inside the state directory
+const FileName = "history.jsonl"
+
+// Retention is how long events are kept before Prune drops them
+const Retention = 90 * 24 * time.Hour
+
+// Event kinds recorded in the journal
+const (
+	KindConnected    = "connected"
+	KindDisconnected = "disconnected"
+	KindError        = "error"
+	KindDaemonStop   = "daemon-stop" // The tray exited; ends any open session
+	KindSleep        = "sleep"       // The system suspended; ends any open session
+	KindWake         = "wake"        // The system resumed
+)
+
+// Event is a single line of the history journal
+type Event struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	From     string    `json:"from,omitempty"` // Previous app.ConnectionState name
+	Reason   string    `json:"reason,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Network  string    `json:"network,omitempty"`
+	ExitNode string    `json:"exit_node,omitempty"`
+	Drop     bool      `json:"drop,omitempty"`     // The connection was lost without the user asking
+	RxBytes  uint64    `json:"rx_bytes,omitempty"` // Received during the session; set on the event that ends it
+	TxBytes  uint64    `json:"tx_bytes,omitempty"` // Sent during the session; set on the event that ends it
+}
+
+// Path returns the location of the history journal, honouring XDG_STATE_HOME
+func Path() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "twingate-tray", FileName)
+}
+
+// Store appends events to a JSON lines journal
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a store for the journal at path. The file and its
+// directory are created on the first Append.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the journal location
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append writes e to the end of the journal
+func (s *Store) Append(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode history event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the events recorded at or after since, oldest first.
+// A missing journal yields no events; malformed lines are skipped.
+func (s *Store) Read(since time.Time) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked(since)
+}
+
+func (s *Store) readLocked(since time.Time) ([]Event, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !e.Time.Before(since) {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	// Events can be appended slightly out of order by concurrent writers
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// CloseOpenSession ends a session the journal still has open, which a tray
+// that crashed or was killed leaves behind. It is called when the tray
+// starts, before it records anything, so no session of its own is open yet.
+// It reports whether a session was closed.
+func (s *Store) CloseOpenSession() (bool, error) {
+	s.mu.Lock()
+	events, err := s.readLocked(time.Time{})
+	s.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+	closed := EndOpenSession(events)
+	if len(closed) == len(events) {
+		return false, nil
+	}
+	return true, s.Append(closed[len(closed)-1])
+}
+
+// EndOpenSession returns events with a daemon-stop appended when they end
+// in an open session. Nothing says how long a tray that stopped without
+// recording it stayed up, so the session ends at the last recorded event
+// rather than running on to now. Events must be sorted oldest first.
+func EndOpenSession(events []Event) []Event {
+	open := false
+	for _, e := range events {
+		switch e.Kind {
+		case KindConnected:
+			open = true
+		case KindDisconnected, KindError, KindDaemonStop, KindSleep:
+			open = false
+		}
+	}
+	if !open {
+		return events
+	}
+	stop := Event{
+		Time:   events[len(events)-1].Time,
+		Kind:   KindDaemonStop,
+		Reason: "not recorded; the tray did not exit cleanly",
+	}
+	return append(events[:len(events):len(events)], stop)
+}
+
+// Prune rewrites the journal without the events older than before
+func (s *Store) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.readLocked(before)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(s.path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to prune history: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to prune history: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
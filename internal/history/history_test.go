@@ -0,0 +1,109 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+
+func at(hours float64) time.Time {
+	return start.Add(time.Duration(hours * float64(time.Hour)))
+}
+
+func TestEndOpenSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		closed bool
+	}{
+		{"empty", nil, false},
+		{"open", []Event{{Time: at(0), Kind: KindConnected}}, true},
+		{"open after wake", []Event{{Time: at(0), Kind: KindConnected}, {Time: at(1), Kind: KindWake}}, true},
+		{"disconnected", []Event{{Time: at(0), Kind: KindConnected}, {Time: at(1), Kind: KindDisconnected}}, false},
+		{"slept", []Event{{Time: at(0), Kind: KindConnected}, {Time: at(1), Kind: KindSleep}, {Time: at(2), Kind: KindWake}}, false},
+		{"stopped", []Event{{Time: at(0), Kind: KindConnected}, {Time: at(1), Kind: KindDaemonStop}}, false},
+	}
+	for _, tt := range tests {
+		got := EndOpenSession(tt.events)
+		if !tt.closed {
+			if len(got) != len(tt.events) {
+				t.Errorf("%s: EndOpenSession appended %+v", tt.name, got[len(got)-1])
+			}
+			continue
+		}
+		if len(got) != len(tt.events)+1 {
+			t.Errorf("%s: EndOpenSession left the session open", tt.name)
+			continue
+		}
+		last := tt.events[len(tt.events)-1].Time
+		if stop := got[len(got)-1]; stop.Kind != KindDaemonStop || !stop.Time.Equal(last) {
+			t.Errorf("%s: EndOpenSession appended %s at %s, want %s at the last event", tt.name, stop.Kind, stop.Time, KindDaemonStop)
+		}
+	}
+}
+
+func TestCloseOpenSession(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	for _, e := range []Event{
+		{Time: at(0), Kind: KindConnected},
+		{Time: at(2), Kind: KindWake}, // Last sign of the tray before it crashed
+	} {
+		if err := store.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	closed, err := store.CloseOpenSession()
+	if err != nil || !closed {
+		t.Fatalf("CloseOpenSession = %v, %v; want a closed session", closed, err)
+	}
+	if closed, err := store.CloseOpenSession(); err != nil || closed {
+		t.Errorf("CloseOpenSession again = %v, %v; want nothing to close", closed, err)
+	}
+
+	// The tray came back two days later; the downtime is not connected time
+	events, err := store.Read(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := Summarize(events, start, at(48))
+	if len(days) != 3 {
+		t.Fatalf("Summarize returned %d days, want 3", len(days))
+	}
+	if days[0].Connected != 2*time.Hour || days[0].Sessions != 1 {
+		t.Errorf("first day = %+v, want one session of 2h", days[0])
+	}
+	for _, d := range days[1:] {
+		if d.Connected != 0 {
+			t.Errorf("%s connected %s while the tray was not running", d.Day.Format(time.DateOnly), d.Connected)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	events := []Event{
+		{Time: at(0), Kind: KindConnected},
+		{Time: at(1), Kind: KindDisconnected, Drop: true, RxBytes: 100, TxBytes: 10},
+		{Time: at(14), Kind: KindConnected}, // 23:00, runs past midnight
+		{Time: at(16), Kind: KindSleep, RxBytes: 50},
+		{Time: at(17), Kind: KindWake},
+		{Time: at(18), Kind: KindConnected},
+	}
+	days := Summarize(events, start, at(20))
+	if len(days) != 2 {
+		t.Fatalf("Summarize returned %d days, want 2", len(days))
+	}
+
+	want := []DaySummary{
+		{Day: start.Add(-9 * time.Hour), Connected: 2 * time.Hour, Sessions: 2, Drops: 1, Received: 100, Sent: 10},
+		{Day: start.Add(15 * time.Hour), Connected: 3 * time.Hour, Sessions: 1, Received: 50}, // Open session counted up to now
+	}
+	for i, d := range days {
+		if !d.Day.Equal(want[i].Day) || d.Connected != want[i].Connected || d.Sessions != want[i].Sessions ||
+			d.Drops != want[i].Drops || d.Received != want[i].Received || d.Sent != want[i].Sent {
+			t.Errorf("day %d = %+v, want %+v", i, d, want[i])
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package history
+
+import "time"
+
+// DaySummary is the connection record for one local calendar day
+type DaySummary struct {
+	Day       time.Time     // Local midnight at the start of the day
+	Connected time.Duration // Time spent connected during the day
+	Sessions  int           // Sessions that started during the day
+	Drops     int           // Connections lost without the user asking
+	Received  uint64        // Bytes received in sessions that ended during the day
+	Sent      uint64        // Bytes sent in sessions that ended during the day
+}
+
+// Summarize folds events into one summary per day from the day of from up to
+// and including the day of now. A session still open at the end is counted
+// up to now, so callers end it with EndOpenSession unless the tray is still
+// running it. Events must be sorted oldest first, as Read returns them.
+func Summarize(events []Event, from, now time.Time) []DaySummary {
+	first := startOfDay(from)
+	var days []DaySummary
+	index := make(map[time.Time]int)
+	for d := first; !d.After(now); d = d.AddDate(0, 0, 1) {
+		index[d] = len(days)
+		days = append(days, DaySummary{Day: d})
+	}
+
+	day := func(t time.Time) *DaySummary {
+		if i, ok := index[startOfDay(t)]; ok {
+			return &days[i]
+		}
+		return nil
+	}
+
+	// addConnected spreads [start, end) over the days it covers
+	addConnected := func(start, end time.Time) {
+		if start.Before(first) {
+			start = first
+		}
+		for start.Before(end) {
+			next := startOfDay(start).AddDate(0, 0, 1)
+			if next.After(end) {
+				next = end
+			}
+			if d := day(start); d != nil {
+				d.Connected += next.Sub(start)
+			}
+			start = next
+		}
+	}
+
+	var sessionStart time.Time
+	inSession := false
+	for _, e := range events {
+		switch e.Kind {
+		case KindConnected:
+			if !inSession {
+				inSession = true
+				sessionStart = e.Time
+				if d := day(e.Time); d != nil {
+					d.Sessions++
+				}
+			}
+		case KindDisconnected, KindError, KindDaemonStop, KindSleep:
+			if inSession {
+				addConnected(sessionStart, e.Time)
+				inSession = false
+			}
+			if d := day(e.Time); d != nil {
+				if e.Drop {
+					d.Drops++
+				}
+				d.Received += e.RxBytes
+				d.Sent += e.TxBytes
+			}
+		}
+	}
+	if inSession {
+		addConnected(sessionStart, now)
+	}
+
+	return days
+}
+
+// startOfDay returns local midnight at the start of t's day
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,138 @@
+package launch
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bisand/twingate-tray/internal/probe"
+)
+
+// Launcher opens resources that match its patterns with a command
+type Launcher struct {
+	Name     string
+	Resource string // Glob on the resource name; empty matches any
+	Address  string // Glob on the resource address or its host; empty matches any
+	Command  Template
+	Terminal bool // Run the command in a terminal emulator
+}
+
+// Matches reports whether the launcher applies to a resource. The address
+// pattern may match the whole address or just its host, so "*.internal"
+// also matches "db.internal:5432".
+func (l Launcher) Matches(name, address string) bool {
+	if l.Resource != "" && !Glob(l.Resource, name) {
+		return false
+	}
+	if l.Address == "" || Glob(l.Address, address) {
+		return true
+	}
+	host := ResourceVars(name, address)["host"]
+	return host != "" && Glob(l.Address, host)
+}
+
+// Args expands the command for a resource
+func (l Launcher) Args(name, address string) ([]string, error) {
+	return l.Command.Expand(ResourceVars(name, address))
+}
+
+// Match returns the launchers that apply to a resource, in order
+func Match(launchers []Launcher, name, address string) []Launcher {
+	var matched []Launcher
+	for _, l := range launchers {
+		if l.Matches(name, address) {
+			matched = append(matched, l)
+		}
+	}
+	return matched
+}
+
+// ResourceVars returns the placeholder values for a resource. {host} is the
+// name or IP address without ports, and {port} the first port the address
+// names; both are empty for ranges and wildcards.
+func ResourceVars(name, address string) map[string]string {
+	vars := map[string]string{"name": name, "address": address}
+	target, err := probe.ParseAddress(address)
+	if err != nil {
+		return vars
+	}
+	if host, err := target.SingleHost(); err == nil {
+		vars["host"] = host
+	}
+	if len(target.Ports) > 0 {
+		vars["port"] = strconv.Itoa(target.Ports[0].First)
+	}
+	return vars
+}
+
+// Glob reports whether s matches pattern, ignoring case. * matches any run
+// of characters, including dots and slashes, and ? matches one.
+func Glob(pattern, s string) bool {
+	p, str := []rune(strings.ToLower(pattern)), []rune(strings.ToLower(s))
+	pi, si := 0, 0
+	star, mark := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case star >= 0:
+			// Let the last * take one more character
+			pi = star + 1
+			mark++
+			si = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// Open runs the launcher for a resource in the background, in a new
+// terminal window if it asks for one
+func (l Launcher) Open(name, address string) error {
+	args, err := l.Args(name, address)
+	if err != nil {
+		return err
+	}
+	if l.Terminal {
+		term, err := FindTerminal()
+		if err != nil {
+			return err
+		}
+		args = term.Command(args...)
+	}
+	return Start(args)
+}
+
+// Find returns the launcher called name, ignoring case
+func Find(launchers []Launcher, name string) (Launcher, bool) {
+	for _, l := range launchers {
+		if strings.EqualFold(l.Name, name) {
+			return l, true
+		}
+	}
+	return Launcher{}, false
+}
+
+// Quote formats args as a shell command line, quoting arguments that need it
+func Quote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.IndexFunc(arg, needsQuote) < 0 {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+func needsQuote(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+}
@@ -0,0 +1,160 @@
+package launch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Variables are the placeholders a template may use
+var Variables = []string{"name", "address", "host", "port"}
+
+// Template is a command line with placeholders such as "ssh {host}". It is
+// split into arguments like a shell would, before the placeholders are
+// filled in, so a value with spaces or quotes stays a single argument.
+type Template struct {
+	source string
+	args   [][]part
+}
+
+// part is literal text or a placeholder within one argument
+type part struct {
+	text     string
+	variable bool
+}
+
+// ParseTemplate splits a command template into arguments. Single quotes keep
+// text as is, double quotes allow \" and \\, and a backslash outside quotes
+// escapes the next character. {{ and }} stand for literal braces.
+func ParseTemplate(s string) (Template, error) {
+	t := Template{source: s}
+	var arg []part
+	var text strings.Builder
+	inArg := false
+	flush := func() {
+		if text.Len() > 0 {
+			arg = append(arg, part{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				flush()
+				t.args = append(t.args, arg)
+				arg, inArg = nil, false
+			}
+			continue
+		case c == '\'':
+			end := slices.Index(runes[i+1:], '\'')
+			if end < 0 {
+				return Template{}, errors.New("unterminated single quote")
+			}
+			text.WriteString(string(runes[i+1 : i+1+end]))
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				if runes[i] == '{' || runes[i] == '}' {
+					n, err := brace(runes, i, &arg, &text, flush)
+					if err != nil {
+						return Template{}, err
+					}
+					i = n
+					continue
+				}
+				text.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return Template{}, errors.New("unterminated double quote")
+			}
+		case c == '\\':
+			if i+1 == len(runes) {
+				return Template{}, errors.New("trailing backslash")
+			}
+			i++
+			text.WriteRune(runes[i])
+		case c == '{' || c == '}':
+			n, err := brace(runes, i, &arg, &text, flush)
+			if err != nil {
+				return Template{}, err
+			}
+			i = n
+		default:
+			text.WriteRune(c)
+		}
+		inArg = true
+	}
+	if inArg {
+		flush()
+		t.args = append(t.args, arg)
+	}
+	if len(t.args) == 0 {
+		return Template{}, errors.New("empty command")
+	}
+	return t, nil
+}
+
+// brace handles a brace at runes[i]: an escaped {{ or }}, or a placeholder.
+// It returns the index of the last rune used.
+func brace(runes []rune, i int, arg *[]part, text *strings.Builder, flush func()) (int, error) {
+	c := runes[i]
+	if i+1 < len(runes) && runes[i+1] == c {
+		text.WriteRune(c)
+		return i + 1, nil
+	}
+	if c == '}' {
+		return 0, errors.New("unmatched }")
+	}
+	end := slices.Index(runes[i+1:], '}')
+	if end < 0 {
+		return 0, errors.New("unterminated {")
+	}
+	name := string(runes[i+1 : i+1+end])
+	if !slices.Contains(Variables, name) {
+		return 0, fmt.Errorf("unknown placeholder {%s} (want {%s})", name, strings.Join(Variables, "}, {"))
+	}
+	flush()
+	*arg = append(*arg, part{text: name, variable: true})
+	return i + 1 + end, nil
+}
+
+// String returns the template as written
+func (t Template) String() string {
+	return t.source
+}
+
+// IsZero reports whether the template is unset
+func (t Template) IsZero() bool {
+	return len(t.args) == 0
+}
+
+// Expand fills in the placeholders. Using a placeholder that has no value,
+// such as {port} for an address without a port, is an error.
+func (t Template) Expand(vars map[string]string) ([]string, error) {
+	args := make([]string, 0, len(t.args))
+	for _, arg := range t.args {
+		var b strings.Builder
+		for _, p := range arg {
+			if !p.variable {
+				b.WriteString(p.text)
+				continue
+			}
+			value := vars[p.text]
+			if value == "" {
+				return nil, fmt.Errorf("{%s} has no value for this resource", p.text)
+			}
+			b.WriteString(value)
+		}
+		args = append(args, b.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,93 @@
+package launch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// terminals are the emulators looked for, in order, with the arguments that
+// come before the command to run
+var terminals = []struct {
+	name string
+	exec []string
+}{
+	{"xdg-terminal-exec", nil},
+	{"x-terminal-emulator", []string{"-e"}},
+	{"gnome-terminal", []string{"--"}},
+	{"kgx", []string{"--"}},
+	{"konsole", []string{"-e"}},
+	{"xfce4-terminal", []string{"-x"}},
+	{"mate-terminal", []string{"-x"}},
+	{"kitty", nil},
+	{"alacritty", []string{"-e"}},
+	{"foot", nil},
+	{"wezterm", []string{"start", "--"}},
+	{"xterm", []string{"-e"}},
+}
+
+// Terminal is a terminal emulator that can run a command in a new window
+type Terminal struct {
+	Path string
+	exec []string
+}
+
+// FindTerminal returns the emulator named by $TERMINAL, or else the first
+// known one on PATH
+func FindTerminal() (Terminal, error) {
+	if name := os.Getenv("TERMINAL"); name != "" {
+		if path, err := exec.LookPath(name); err == nil {
+			return Terminal{Path: path, exec: execArgs(filepath.Base(path))}, nil
+		}
+		log.Printf("Warning: $TERMINAL is %q, which was not found", name)
+	}
+	for _, t := range terminals {
+		if path, err := exec.LookPath(t.name); err == nil {
+			return Terminal{Path: path, exec: t.exec}, nil
+		}
+	}
+	return Terminal{}, errors.New("no terminal emulator found; set $TERMINAL")
+}
+
+// Name returns the emulator's program name
+func (t Terminal) Name() string {
+	return filepath.Base(t.Path)
+}
+
+// Command returns the command line that runs args in a new terminal window
+func (t Terminal) Command(args ...string) []string {
+	cmd := append([]string{t.Path}, t.exec...)
+	return append(cmd, args...)
+}
+
+// execArgs returns the arguments a known emulator needs before the command.
+// Unknown emulators get -e, which most accept.
+func execArgs(name string) []string {
+	for _, t := range terminals {
+		if t.name == name {
+			return t.exec
+		}
+	}
+	return []string{"-e"}
+}
+
+// Start runs args in the background. The process is reaped when it exits,
+// and a failure is only logged since nobody waits for it.
+func Start(args []string) error {
+	if len(args) == 0 {
+		return errors.New("empty command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Printf("%s exited: %v", filepath.Base(args[0]), err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,132 @@
+package logind
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// systemd-logind D-Bus names
+const (
+	loginService   = "org.freedesktop.login1"
+	loginPath      = dbus.ObjectPath("/org/freedesktop/login1")
+	loginInterface = "org.freedesktop.login1.Manager"
+)
+
+// SleepWatcher reports suspend and resume through logind's PrepareForSleep
+// signal. It holds a delay inhibitor lock so subscribers get to run before
+// the system goes down.
+type SleepWatcher struct {
+	conn    *dbus.Conn
+	who     string
+	signals chan *dbus.Signal
+
+	mu          sync.Mutex
+	lock        *os.File
+	subscribers []func(sleeping bool)
+}
+
+// NewSleepWatcher creates a watcher on conn, normally the system bus. who
+// names the application in `systemd-inhibit --list`.
+func NewSleepWatcher(conn *dbus.Conn, who string) *SleepWatcher {
+	return &SleepWatcher{conn: conn, who: who, signals: make(chan *dbus.Signal, 4)}
+}
+
+// Subscribe registers fn to be called with true before sleep and false after
+// wake. Callbacks run in order on the watcher's goroutine; the system waits
+// for the sleep callbacks, up to logind's InhibitDelayMaxSec.
+func (w *SleepWatcher) Subscribe(fn func(sleeping bool)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start subscribes to PrepareForSleep and takes the inhibitor lock
+func (w *SleepWatcher) Start(ctx context.Context) error {
+	err := w.conn.AddMatchSignal(
+		dbus.WithMatchSender(loginService),
+		dbus.WithMatchObjectPath(loginPath),
+		dbus.WithMatchInterface(loginInterface),
+		dbus.WithMatchMember("PrepareForSleep"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to PrepareForSleep: %w", err)
+	}
+	w.conn.Signal(w.signals)
+
+	if err := w.inhibit(); err != nil {
+		// Still useful without the lock; wake handling does not need it
+		log.Printf("Warning: %v", err)
+	}
+
+	go w.run(ctx)
+	return nil
+}
+
+func (w *SleepWatcher) run(ctx context.Context) {
+	defer func() {
+		w.conn.RemoveSignal(w.signals)
+		w.release()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-w.signals:
+			if !ok {
+				return
+			}
+			if sig.Path != loginPath || sig.Name != loginInterface+".PrepareForSleep" || len(sig.Body) < 1 {
+				continue
+			}
+			sleeping, _ := sig.Body[0].(bool)
+
+			w.mu.Lock()
+			subscribers := w.subscribers
+			w.mu.Unlock()
+			for _, fn := range subscribers {
+				fn(sleeping)
+			}
+
+			if sleeping {
+				// Let the system suspend now that the state is recorded
+				w.release()
+			} else if err := w.inhibit(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+}
+
+// inhibit takes a delay lock on sleep
+func (w *SleepWatcher) inhibit() error {
+	var fd dbus.UnixFD
+	err := w.conn.Object(loginService, loginPath).Call(loginInterface+".Inhibit", 0,
+		"sleep", w.who, "Record the Twingate connection state before sleep", "delay").Store(&fd)
+	if err != nil {
+		return fmt.Errorf("failed to take sleep inhibitor lock: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lock != nil {
+		w.lock.Close()
+	}
+	w.lock = os.NewFile(uintptr(fd), "logind-inhibitor")
+	return nil
+}
+
+// release drops the inhibitor lock, if held
+func (w *SleepWatcher) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lock != nil {
+		w.lock.Close()
+		w.lock = nil
+	}
+}
@@ -0,0 +1,193 @@
+package netif
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// LinkFlags are the IFF_* flags of a link
+type LinkFlags uint32
+
+// Link flags reported by rtnetlink (linux/if.h)
+const (
+	FlagUp           LinkFlags = syscall.IFF_UP
+	FlagBroadcast    LinkFlags = syscall.IFF_BROADCAST
+	FlagLoopback     LinkFlags = syscall.IFF_LOOPBACK
+	FlagPointToPoint LinkFlags = syscall.IFF_POINTOPOINT
+	FlagRunning      LinkFlags = syscall.IFF_RUNNING
+	FlagNoARP        LinkFlags = syscall.IFF_NOARP
+	FlagMulticast    LinkFlags = syscall.IFF_MULTICAST
+	FlagLowerUp      LinkFlags = 1 << 16
+	FlagDormant      LinkFlags = 1 << 17
+)
+
+var flagNames = []struct {
+	flag LinkFlags
+	name string
+}{
+	{FlagUp, "UP"},
+	{FlagBroadcast, "BROADCAST"},
+	{FlagLoopback, "LOOPBACK"},
+	{FlagPointToPoint, "POINTOPOINT"},
+	{FlagRunning, "RUNNING"},
+	{FlagNoARP, "NOARP"},
+	{FlagMulticast, "MULTICAST"},
+	{FlagLowerUp, "LOWER_UP"},
+	{FlagDormant, "DORMANT"},
+}
+
+// Has reports whether all of flag are set
+func (f LinkFlags) Has(flag LinkFlags) bool {
+	return f&flag == flag
+}
+
+// String returns the flags in `ip link` notation, e.g. "UP,POINTOPOINT,NOARP"
+func (f LinkFlags) String() string {
+	var names []string
+	for _, n := range flagNames {
+		if f.Has(n.flag) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Stats are the traffic counters of a link
+type Stats struct {
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	TxPackets uint64 `json:"tx_packets"`
+	TxErrors  uint64 `json:"tx_errors"`
+	TxDropped uint64 `json:"tx_dropped"`
+}
+
+// Address is an address assigned to a link
+type Address struct {
+	Prefix netip.Prefix // Address with its prefix length, e.g. 100.96.0.5/32
+	Peer   netip.Prefix // Remote end of a point-to-point address; invalid otherwise
+	Scope  string       // "global", "link", "host", ...
+	Label  string       // IPv4 label, usually the link name
+}
+
+// Route is a route through a link
+type Route struct {
+	Destination netip.Prefix // 0.0.0.0/0 or ::/0 for a default route
+	Gateway     netip.Addr   // Invalid for directly connected routes
+	Source      netip.Addr   // Preferred source address; invalid if unset
+	Table       int          // Routing table, e.g. 254 for main
+	Metric      int
+	Protocol    string // "kernel", "boot", "static", ...
+	Scope       string
+	Type        string // "unicast", "local", "broadcast", ...
+}
+
+// TableName returns the routing table's name as used by `ip route`
+func (r Route) TableName() string {
+	switch r.Table {
+	case syscall.RT_TABLE_MAIN:
+		return "main"
+	case syscall.RT_TABLE_LOCAL:
+		return "local"
+	case syscall.RT_TABLE_DEFAULT:
+		return "default"
+	default:
+		return fmt.Sprint(r.Table)
+	}
+}
+
+// String returns the route in a compact `ip route` like notation
+func (r Route) String() string {
+	var b strings.Builder
+	if r.Destination.Bits() == 0 {
+		b.WriteString("default")
+	} else {
+		b.WriteString(r.Destination.String())
+	}
+	if r.Gateway.IsValid() {
+		b.WriteString(" via " + r.Gateway.String())
+	}
+	if r.Table != syscall.RT_TABLE_MAIN {
+		b.WriteString(" table " + r.TableName())
+	}
+	if r.Metric != 0 {
+		fmt.Fprintf(&b, " metric %d", r.Metric)
+	}
+	return b.String()
+}
+
+// Link is everything known about a network interface
+type Link struct {
+	Name      string
+	Index     int
+	Flags     LinkFlags
+	OperState string // "UP", "DOWN", "UNKNOWN", ... as shown by `ip link`
+	MTU       int
+	Addresses []Address
+	Routes    []Route
+	Stats     Stats
+}
+
+// IPv4 returns the link's IPv4 addresses
+func (l *Link) IPv4() []Address {
+	return l.addresses(func(a netip.Addr) bool { return a.Is4() })
+}
+
+// IPv6 returns the link's IPv6 addresses
+func (l *Link) IPv6() []Address {
+	return l.addresses(func(a netip.Addr) bool { return a.Is6() })
+}
+
+func (l *Link) addresses(match func(netip.Addr) bool) []Address {
+	var out []Address
+	for _, a := range l.Addresses {
+		if match(a.Prefix.Addr()) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Inspect reads the link called name, its addresses, routes and counters
+// from rtnetlink
+func Inspect(name string) (*Link, error) {
+	dump, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	links, err := parseLinks(dump)
+	if err != nil {
+		return nil, err
+	}
+	var link *Link
+	for i := range links {
+		if links[i].Name == name {
+			link = &links[i]
+			break
+		}
+	}
+	if link == nil {
+		return nil, fmt.Errorf("interface %s not found", name)
+	}
+
+	dump, err = syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	if link.Addresses, err = parseAddresses(dump, link.Index); err != nil {
+		return nil, err
+	}
+
+	dump, err = syscall.NetlinkRIB(syscall.RTM_GETROUTE, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+	if link.Routes, err = parseRoutes(dump, link.Index); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
@@ -0,0 +1,267 @@
+package netif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// rtnetlink attributes the syscall package lacks (linux/if_link.h)
+const (
+	iflaStats64 = 23
+)
+
+// Operational states (IF_OPER_* in linux/if.h) as printed by `ip link`
+var operStates = []string{"UNKNOWN", "NOTPRESENT", "DOWN", "LOWERLAYERDOWN", "TESTING", "DORMANT", "UP"}
+
+var scopeNames = map[uint8]string{
+	syscall.RT_SCOPE_UNIVERSE: "global",
+	syscall.RT_SCOPE_SITE:     "site",
+	syscall.RT_SCOPE_LINK:     "link",
+	syscall.RT_SCOPE_HOST:     "host",
+	syscall.RT_SCOPE_NOWHERE:  "nowhere",
+}
+
+var protocolNames = map[uint8]string{
+	syscall.RTPROT_UNSPEC:   "unspec",
+	syscall.RTPROT_REDIRECT: "redirect",
+	syscall.RTPROT_KERNEL:   "kernel",
+	syscall.RTPROT_BOOT:     "boot",
+	syscall.RTPROT_STATIC:   "static",
+	syscall.RTPROT_DHCP:     "dhcp",
+}
+
+var routeTypeNames = map[uint8]string{
+	syscall.RTN_UNICAST:     "unicast",
+	syscall.RTN_LOCAL:       "local",
+	syscall.RTN_BROADCAST:   "broadcast",
+	syscall.RTN_ANYCAST:     "anycast",
+	syscall.RTN_MULTICAST:   "multicast",
+	syscall.RTN_BLACKHOLE:   "blackhole",
+	syscall.RTN_UNREACHABLE: "unreachable",
+	syscall.RTN_PROHIBIT:    "prohibit",
+}
+
+// parseLinks decodes an RTM_GETLINK dump
+func parseLinks(dump []byte) ([]Link, error) {
+	msgs, err := syscall.ParseNetlinkMessage(dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse link dump: %w", err)
+	}
+
+	var links []Link
+	for i := range msgs {
+		m := &msgs[i]
+		if m.Header.Type != syscall.RTM_NEWLINK || len(m.Data) < syscall.SizeofIfInfomsg {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse link attributes: %w", err)
+		}
+
+		link := Link{
+			Index:     int(int32(binary.NativeEndian.Uint32(m.Data[4:8]))),
+			Flags:     LinkFlags(binary.NativeEndian.Uint32(m.Data[8:12])),
+			OperState: operStates[0],
+		}
+		haveStats64 := false
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.IFLA_IFNAME:
+				link.Name = cString(a.Value)
+			case syscall.IFLA_MTU:
+				link.MTU = int(uint32Attr(a.Value))
+			case syscall.IFLA_OPERSTATE:
+				if len(a.Value) > 0 && int(a.Value[0]) < len(operStates) {
+					link.OperState = operStates[a.Value[0]]
+				}
+			case iflaStats64:
+				link.Stats = parseStats(a.Value, 8)
+				haveStats64 = true
+			case syscall.IFLA_STATS:
+				if !haveStats64 {
+					link.Stats = parseStats(a.Value, 4)
+				}
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// parseStats decodes rtnl_link_stats (size 4) or rtnl_link_stats64 (size 8).
+// Both start with rx/tx packets, bytes, errors and dropped in that order.
+func parseStats(b []byte, size int) Stats {
+	field := func(n int) uint64 {
+		off := n * size
+		if len(b) < off+size {
+			return 0
+		}
+		if size == 8 {
+			return binary.NativeEndian.Uint64(b[off:])
+		}
+		return uint64(binary.NativeEndian.Uint32(b[off:]))
+	}
+	return Stats{
+		RxPackets: field(0),
+		TxPackets: field(1),
+		RxBytes:   field(2),
+		TxBytes:   field(3),
+		RxErrors:  field(4),
+		TxErrors:  field(5),
+		RxDropped: field(6),
+		TxDropped: field(7),
+	}
+}
+
+// parseAddresses decodes an RTM_GETADDR dump, keeping the addresses of the
+// link with the given index
+func parseAddresses(dump []byte, index int) ([]Address, error) {
+	msgs, err := syscall.ParseNetlinkMessage(dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse address dump: %w", err)
+	}
+
+	var addrs []Address
+	for i := range msgs {
+		m := &msgs[i]
+		if m.Header.Type != syscall.RTM_NEWADDR || len(m.Data) < syscall.SizeofIfAddrmsg {
+			continue
+		}
+		if int(binary.NativeEndian.Uint32(m.Data[4:8])) != index {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse address attributes: %w", err)
+		}
+
+		bits := int(m.Data[1])
+		var address, local netip.Addr
+		addr := Address{Scope: scopeName(m.Data[3])}
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.IFA_ADDRESS:
+				address, _ = netip.AddrFromSlice(a.Value)
+			case syscall.IFA_LOCAL:
+				local, _ = netip.AddrFromSlice(a.Value)
+			case syscall.IFA_LABEL:
+				addr.Label = cString(a.Value)
+			}
+		}
+
+		// On point-to-point links IFA_LOCAL is ours and IFA_ADDRESS the peer
+		switch {
+		case local.IsValid():
+			addr.Prefix = netip.PrefixFrom(local, bits)
+			if address.IsValid() && address != local {
+				addr.Peer = netip.PrefixFrom(address, bits)
+			}
+		case address.IsValid():
+			addr.Prefix = netip.PrefixFrom(address, bits)
+		default:
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// parseRoutes decodes an RTM_GETROUTE dump, keeping the routes whose output
+// interface is the link with the given index
+func parseRoutes(dump []byte, index int) ([]Route, error) {
+	msgs, err := syscall.ParseNetlinkMessage(dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse route dump: %w", err)
+	}
+
+	var routes []Route
+	for i := range msgs {
+		m := &msgs[i]
+		if m.Header.Type != syscall.RTM_NEWROUTE || len(m.Data) < syscall.SizeofRtMsg {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse route attributes: %w", err)
+		}
+
+		family, dstLen := m.Data[0], int(m.Data[1])
+		route := Route{
+			Table:    int(m.Data[4]),
+			Protocol: protocolName(m.Data[5]),
+			Scope:    scopeName(m.Data[6]),
+			Type:     routeTypeName(m.Data[7]),
+		}
+		oif := 0
+		var dst netip.Addr
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.RTA_OIF:
+				oif = int(uint32Attr(a.Value))
+			case syscall.RTA_DST:
+				dst, _ = netip.AddrFromSlice(a.Value)
+			case syscall.RTA_GATEWAY:
+				route.Gateway, _ = netip.AddrFromSlice(a.Value)
+			case syscall.RTA_PREFSRC:
+				route.Source, _ = netip.AddrFromSlice(a.Value)
+			case syscall.RTA_PRIORITY:
+				route.Metric = int(uint32Attr(a.Value))
+			case syscall.RTA_TABLE:
+				// Tables above 255 only fit in the attribute
+				route.Table = int(uint32Attr(a.Value))
+			}
+		}
+		if oif != index {
+			continue
+		}
+
+		// A missing destination is the default route of the family
+		if !dst.IsValid() {
+			if family == syscall.AF_INET6 {
+				dst = netip.IPv6Unspecified()
+			} else {
+				dst = netip.IPv4Unspecified()
+			}
+		}
+		route.Destination = netip.PrefixFrom(dst, dstLen)
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func scopeName(scope uint8) string {
+	if name, ok := scopeNames[scope]; ok {
+		return name
+	}
+	return fmt.Sprint(scope)
+}
+
+func protocolName(proto uint8) string {
+	if name, ok := protocolNames[proto]; ok {
+		return name
+	}
+	return fmt.Sprint(proto)
+}
+
+func routeTypeName(t uint8) string {
+	if name, ok := routeTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprint(t)
+}
+
+func uint32Attr(b []byte) uint32 {
+	if len(b) < 4 {
+		return 0
+	}
+	return binary.NativeEndian.Uint32(b)
+}
+
+// cString returns b up to its NUL terminator
+func cString(b []byte) string {
+	return strings.TrimRight(string(b), "\x00")
+}
@@ -0,0 +1,250 @@
+package netif
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+// The dumps in testdata were captured with syscall.NetlinkRIB on an x86-64
+// host after setting up a tun device the way the Twingate client does:
+//
+//	ip link set sdwan0 mtu 1280 up
+//	ip addr add 100.96.0.5 peer 100.96.0.1/32 dev sdwan0
+//	ip -6 addr add fd7a:115c:a1e0::5/128 dev sdwan0
+//	ip route add 10.20.0.0/16 via 100.96.0.1 dev sdwan0 metric 50
+//	ip route add default dev sdwan0 table 1000
+//	ip route add 172.16.5.0/24 dev sdwan0 table 1000 proto static
+//
+// with a little traffic through it. lo is index 1, eth0 index 4 and sdwan0
+// index 8.
+const (
+	loIndex    = 1
+	ethIndex   = 4
+	sdwanIndex = 8
+)
+
+// dump returns a captured rtnetlink dump. Netlink uses the host's byte
+// order, so the little-endian captures are skipped on big-endian hosts.
+func dump(t *testing.T, name string) []byte {
+	t.Helper()
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("rtnetlink fixtures are little-endian")
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseLinks(t *testing.T) {
+	links, err := parseLinks(dump(t, "links.bin"))
+	if err != nil {
+		t.Fatalf("parseLinks: %v", err)
+	}
+	names := make([]string, len(links))
+	for i, l := range links {
+		names[i] = l.Name
+	}
+	if want := []string{"lo", "ifb0", "ifb1", "eth0", "sdwan0"}; !slices.Equal(names, want) {
+		t.Fatalf("links = %q, want %q", names, want)
+	}
+
+	lo := links[0]
+	if lo.Index != loIndex || !lo.Flags.Has(FlagLoopback|FlagUp) || lo.MTU != 65536 {
+		t.Errorf("lo = %+v", lo)
+	}
+
+	eth := links[3]
+	if eth.Index != ethIndex || eth.OperState != "UP" || eth.MTU != 1400 {
+		t.Errorf("eth0 = %+v", eth)
+	}
+
+	sdwan := links[4]
+	if sdwan.Index != sdwanIndex || sdwan.MTU != 1280 {
+		t.Errorf("sdwan0 index %d, mtu %d", sdwan.Index, sdwan.MTU)
+	}
+	// A tun device has no carrier state of its own
+	if sdwan.OperState != "UNKNOWN" {
+		t.Errorf("sdwan0 state = %q, want UNKNOWN", sdwan.OperState)
+	}
+	if got, want := sdwan.Flags.String(), "UP,POINTOPOINT,RUNNING,NOARP,MULTICAST,LOWER_UP"; got != want {
+		t.Errorf("sdwan0 flags = %s, want %s", got, want)
+	}
+	want := Stats{RxBytes: 84, RxPackets: 3, TxBytes: 488, TxPackets: 6}
+	if sdwan.Stats != want {
+		t.Errorf("sdwan0 stats = %+v, want %+v", sdwan.Stats, want)
+	}
+}
+
+func TestParseLinksStats32(t *testing.T) {
+	// Older kernels only send the 32-bit counters
+	links, err := parseLinks(withoutLinkAttr(t, dump(t, "links.bin"), iflaStats64))
+	if err != nil {
+		t.Fatalf("parseLinks: %v", err)
+	}
+	want := Stats{RxBytes: 84, RxPackets: 3, TxBytes: 488, TxPackets: 6}
+	if got := links[4].Stats; got != want {
+		t.Errorf("sdwan0 stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseAddresses(t *testing.T) {
+	addrs := dump(t, "addrs.bin")
+	tests := []struct {
+		name  string
+		index int
+		want  []Address
+	}{
+		{
+			// IFA_LOCAL is our end and IFA_ADDRESS the peer
+			name:  "point-to-point tun",
+			index: sdwanIndex,
+			want: []Address{
+				{
+					Prefix: netip.MustParsePrefix("100.96.0.5/32"),
+					Peer:   netip.MustParsePrefix("100.96.0.1/32"),
+					Scope:  "global",
+					Label:  "sdwan0",
+				},
+				{Prefix: netip.MustParsePrefix("fd7a:115c:a1e0::5/128"), Scope: "global"},
+				{Prefix: netip.MustParsePrefix("fe80::cd3a:4dfa:eaed:66c4/64"), Scope: "link"},
+			},
+		},
+		{
+			// IFA_LOCAL and IFA_ADDRESS are the same on broadcast links
+			name:  "ethernet",
+			index: ethIndex,
+			want: []Address{
+				{Prefix: netip.MustParsePrefix("192.0.2.2/24"), Scope: "global", Label: "eth0"},
+				{Prefix: netip.MustParsePrefix("fd00::2/64"), Scope: "global"},
+				{Prefix: netip.MustParsePrefix("fe80::fc:ff:fe00:1/64"), Scope: "link"},
+			},
+		},
+		{
+			name:  "loopback",
+			index: loIndex,
+			want: []Address{
+				{Prefix: netip.MustParsePrefix("127.0.0.1/8"), Scope: "host", Label: "lo"},
+				{Prefix: netip.MustParsePrefix("::1/128"), Scope: "host"},
+			},
+		},
+		{
+			name:  "unknown index",
+			index: 99,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAddresses(addrs, tt.index)
+			if err != nil {
+				t.Fatalf("parseAddresses: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseAddresses = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := parseRoutes(dump(t, "routes.bin"), sdwanIndex)
+	if err != nil {
+		t.Fatalf("parseRoutes: %v", err)
+	}
+	src := netip.MustParseAddr("100.96.0.5")
+	want := []Route{
+		// Tables above 255 come from RTA_TABLE, and the default route has no RTA_DST
+		{Destination: netip.MustParsePrefix("0.0.0.0/0"), Table: 1000, Protocol: "boot", Scope: "link", Type: "unicast"},
+		{Destination: netip.MustParsePrefix("172.16.5.0/24"), Table: 1000, Protocol: "static", Scope: "link", Type: "unicast"},
+		{
+			Destination: netip.MustParsePrefix("10.20.0.0/16"),
+			Gateway:     netip.MustParseAddr("100.96.0.1"),
+			Table:       syscall.RT_TABLE_MAIN,
+			Metric:      50,
+			Protocol:    "boot",
+			Scope:       "global",
+			Type:        "unicast",
+		},
+		{Destination: netip.MustParsePrefix("100.96.0.1/32"), Source: src, Table: syscall.RT_TABLE_MAIN, Protocol: "kernel", Scope: "link", Type: "unicast"},
+		{Destination: netip.MustParsePrefix("100.96.0.5/32"), Source: src, Table: syscall.RT_TABLE_LOCAL, Protocol: "kernel", Scope: "host", Type: "local"},
+		{Destination: netip.MustParsePrefix("fd7a:115c:a1e0::5/128"), Table: syscall.RT_TABLE_MAIN, Metric: 256, Protocol: "kernel", Scope: "global", Type: "unicast"},
+		{Destination: netip.MustParsePrefix("fe80::/64"), Table: syscall.RT_TABLE_MAIN, Metric: 256, Protocol: "kernel", Scope: "global", Type: "unicast"},
+		{Destination: netip.MustParsePrefix("fd7a:115c:a1e0::5/128"), Table: syscall.RT_TABLE_LOCAL, Protocol: "kernel", Scope: "global", Type: "local"},
+		{Destination: netip.MustParsePrefix("fe80::cd3a:4dfa:eaed:66c4/128"), Table: syscall.RT_TABLE_LOCAL, Protocol: "kernel", Scope: "global", Type: "local"},
+		{Destination: netip.MustParsePrefix("ff00::/8"), Table: syscall.RT_TABLE_LOCAL, Metric: 256, Protocol: "kernel", Scope: "global", Type: "multicast"},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("parseRoutes returned %d routes, want %d: %v", len(routes), len(want), routes)
+	}
+	for i := range want {
+		if routes[i] != want[i] {
+			t.Errorf("route %d = %+v, want %+v", i, routes[i], want[i])
+		}
+	}
+	if got := routes[0].String(); got != "default table 1000" {
+		t.Errorf("route 0 = %q, want default table 1000", got)
+	}
+}
+
+func TestParseRoutesIPv6Default(t *testing.T) {
+	routes, err := parseRoutes(dump(t, "routes.bin"), ethIndex)
+	if err != nil {
+		t.Fatalf("parseRoutes: %v", err)
+	}
+	i := slices.IndexFunc(routes, func(r Route) bool { return r.Gateway == netip.MustParseAddr("fd00::1") })
+	if i < 0 {
+		t.Fatalf("no route via fd00::1 in %v", routes)
+	}
+	if got, want := routes[i].Destination, netip.MustParsePrefix("::/0"); got != want {
+		t.Errorf("IPv6 default route destination = %s, want %s", got, want)
+	}
+}
+
+func TestParseTruncatedDump(t *testing.T) {
+	links := dump(t, "links.bin")
+	if _, err := parseLinks(links[:len(links)-100]); err == nil {
+		t.Error("parseLinks accepted a truncated dump")
+	}
+}
+
+// withoutLinkAttr returns a link dump with every attribute of type attr removed
+func withoutLinkAttr(t *testing.T, dump []byte, attr uint16) []byte {
+	t.Helper()
+	msgs, err := syscall.ParseNetlinkMessage(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []byte
+	for _, m := range msgs {
+		data := m.Data
+		if m.Header.Type == syscall.RTM_NEWLINK {
+			data = slices.Clone(data[:syscall.SizeofIfInfomsg])
+			for b := m.Data[syscall.SizeofIfInfomsg:]; len(b) >= syscall.SizeofRtAttr; {
+				n := int(binary.NativeEndian.Uint16(b))
+				size := min((n+syscall.RTA_ALIGNTO-1)&^(syscall.RTA_ALIGNTO-1), len(b))
+				if binary.NativeEndian.Uint16(b[2:]) != attr {
+					data = append(data, b[:size]...)
+				}
+				b = b[size:]
+			}
+		}
+		header := make([]byte, syscall.SizeofNlMsghdr)
+		binary.NativeEndian.PutUint32(header, uint32(syscall.SizeofNlMsghdr+len(data)))
+		binary.NativeEndian.PutUint16(header[4:], m.Header.Type)
+		binary.NativeEndian.PutUint16(header[6:], m.Header.Flags)
+		binary.NativeEndian.PutUint32(header[8:], m.Header.Seq)
+		binary.NativeEndian.PutUint32(header[12:], m.Header.Pid)
+		out = append(out, header...)
+		out = append(out, data...)
+		for len(out)%syscall.NLMSG_ALIGNTO != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+package netif
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// rtnetlink multicast groups (linux/rtnetlink.h); the syscall package lacks them
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+)
+
+// receiveTimeout bounds each netlink read so the watcher notices cancellation
+const receiveTimeout = time.Second
+
+// LinkWatcher reports rtnetlink link and address changes for one interface
+type LinkWatcher struct {
+	mu          sync.Mutex
+	name        string
+	index       int // Last known index of name; links are recreated on reconnect
+	subscribers []func(event string)
+}
+
+// NewLinkWatcher creates a watcher for the interface called name
+func NewLinkWatcher(name string) *LinkWatcher {
+	return &LinkWatcher{name: name}
+}
+
+// SetInterface changes the watched interface
+func (w *LinkWatcher) SetInterface(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if name != w.name {
+		w.name = name
+		w.index = 0
+	}
+}
+
+// Subscribe registers fn to be called with a short description of each
+// change. Callbacks run on the watcher's goroutine and must not block.
+func (w *LinkWatcher) Subscribe(fn func(event string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start opens the netlink socket and follows changes until ctx is cancelled
+func (w *LinkWatcher) Start(ctx context.Context) error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpLink | rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("failed to subscribe to netlink: %w", err)
+	}
+	tv := syscall.NsecToTimeval(receiveTimeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("failed to configure netlink socket: %w", err)
+	}
+
+	w.mu.Lock()
+	if iface, err := net.InterfaceByName(w.name); err == nil {
+		w.index = iface.Index
+	}
+	w.mu.Unlock()
+
+	go w.run(ctx, fd)
+	return nil
+}
+
+func (w *LinkWatcher) run(ctx context.Context, fd int) {
+	defer syscall.Close(fd)
+
+	buf := make([]byte, 1<<16)
+	for ctx.Err() == nil {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if err == syscall.EAGAIN || err == syscall.EINTR {
+				continue
+			}
+			// ENOBUFS means events were dropped; the next poll catches up
+			if err != syscall.ENOBUFS {
+				log.Printf("Netlink watcher stopped: %v", err)
+				return
+			}
+			w.notify("netlink overrun")
+			continue
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for i := range msgs {
+			if event, ok := w.match(&msgs[i]); ok {
+				w.notify(event)
+			}
+		}
+	}
+}
+
+// match reports whether msg concerns the watched interface and describes it
+func (w *LinkWatcher) match(msg *syscall.NetlinkMessage) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch msg.Header.Type {
+	case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+		if len(msg.Data) < syscall.SizeofIfInfomsg {
+			return "", false
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(msg)
+		if err != nil {
+			return "", false
+		}
+		for _, a := range attrs {
+			if a.Attr.Type == syscall.IFLA_IFNAME && cString(a.Value) == w.name {
+				w.index = int(binary.NativeEndian.Uint32(msg.Data[4:8]))
+				if msg.Header.Type == syscall.RTM_DELLINK {
+					return w.name + " removed", true
+				}
+				return w.name + " link changed", true
+			}
+		}
+	case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+		if len(msg.Data) < syscall.SizeofIfAddrmsg {
+			return "", false
+		}
+		index := int(binary.NativeEndian.Uint32(msg.Data[4:8]))
+		if index != w.index {
+			// The link may have been recreated since we last saw it
+			iface, err := net.InterfaceByIndex(index)
+			if err != nil || iface.Name != w.name {
+				return "", false
+			}
+			w.index = index
+		}
+		return w.name + " address changed", true
+	}
+	return "", false
+}
+
+func (w *LinkWatcher) notify(event string) {
+	w.mu.Lock()
+	subscribers := w.subscribers
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
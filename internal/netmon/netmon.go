@@ -0,0 +1,225 @@
+package netmon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// NetworkManager D-Bus names
+const (
+	nmService             = "org.freedesktop.NetworkManager"
+	nmPath                = dbus.ObjectPath("/org/freedesktop/NetworkManager")
+	nmInterface           = "org.freedesktop.NetworkManager"
+	activeInterface       = "org.freedesktop.NetworkManager.Connection.Active"
+	settingsConnInterface = "org.freedesktop.NetworkManager.Settings.Connection"
+	propertiesInterface   = "org.freedesktop.DBus.Properties"
+)
+
+// NetworkManager connection types and the short names used in rules
+var typeNames = map[string]string{
+	"802-11-wireless": "wifi",
+	"802-3-ethernet":  "ethernet",
+	"vpn":             "vpn",
+	"wireguard":       "wireguard",
+	"gsm":             "mobile",
+}
+
+// Network is the primary network connection reported by NetworkManager
+type Network struct {
+	ID   string // Connection name, e.g. "Office Wi-Fi"
+	UUID string
+	Type string // "wifi", "ethernet", "vpn", ... or NetworkManager's type name
+	SSID string // Set for Wi-Fi connections
+}
+
+// IsZero reports whether there is no primary connection
+func (n Network) IsZero() bool {
+	return n.UUID == ""
+}
+
+// String returns a short description for logs and notifications
+func (n Network) String() string {
+	switch {
+	case n.IsZero():
+		return "no network"
+	case n.SSID != "":
+		return fmt.Sprintf("%s %q", n.Type, n.SSID)
+	default:
+		return fmt.Sprintf("%s %q", n.Type, n.ID)
+	}
+}
+
+// Watcher follows NetworkManager's primary connection
+type Watcher struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Signal
+
+	mu          sync.Mutex
+	current     Network
+	subscribers []func(Network)
+}
+
+// NewWatcher creates a watcher on conn, normally the system bus. Tests can
+// pass a private bus that runs netmontest.NetworkManager.
+func NewWatcher(conn *dbus.Conn) *Watcher {
+	return &Watcher{conn: conn, signals: make(chan *dbus.Signal, 16)}
+}
+
+// Subscribe registers fn to be called when the primary connection changes.
+// Callbacks run on the watcher's goroutine and must not block.
+func (w *Watcher) Subscribe(fn func(Network)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Current returns the last known primary connection
+func (w *Watcher) Current() Network {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Start reads the current connection, delivers it to subscribers and keeps
+// following changes until ctx is cancelled
+func (w *Watcher) Start(ctx context.Context) error {
+	err := w.conn.AddMatchSignal(
+		dbus.WithMatchSender(nmService),
+		dbus.WithMatchObjectPath(nmPath),
+		dbus.WithMatchInterface(propertiesInterface),
+		dbus.WithMatchMember("PropertiesChanged"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to NetworkManager: %w", err)
+	}
+	w.conn.Signal(w.signals)
+
+	network, err := w.primary()
+	if err != nil {
+		w.conn.RemoveSignal(w.signals)
+		return err
+	}
+	w.update(network)
+
+	go w.run(ctx)
+	return nil
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer w.conn.RemoveSignal(w.signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-w.signals:
+			if !ok {
+				return
+			}
+			if sig.Path != nmPath || sig.Name != propertiesInterface+".PropertiesChanged" || !affectsPrimary(sig) {
+				continue
+			}
+			network, err := w.primary()
+			if err != nil {
+				log.Printf("Failed to read NetworkManager state: %v", err)
+				continue
+			}
+			w.update(network)
+		}
+	}
+}
+
+// affectsPrimary reports whether a PropertiesChanged signal touches the
+// primary or active connections
+func affectsPrimary(sig *dbus.Signal) bool {
+	if len(sig.Body) < 2 {
+		return false
+	}
+	if iface, _ := sig.Body[0].(string); iface != nmInterface {
+		return false
+	}
+	changed, _ := sig.Body[1].(map[string]dbus.Variant)
+	_, primary := changed["PrimaryConnection"]
+	_, active := changed["ActiveConnections"]
+	return primary || active
+}
+
+// update stores network and notifies subscribers if it differs from the last one
+func (w *Watcher) update(network Network) {
+	w.mu.Lock()
+	if network == w.current {
+		w.mu.Unlock()
+		return
+	}
+	w.current = network
+	subscribers := w.subscribers
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(network)
+	}
+}
+
+// primary reads the primary connection from NetworkManager
+func (w *Watcher) primary() (Network, error) {
+	v, err := w.conn.Object(nmService, nmPath).GetProperty(nmInterface + ".PrimaryConnection")
+	if err != nil {
+		return Network{}, fmt.Errorf("failed to read PrimaryConnection: %w", err)
+	}
+	path, _ := v.Value().(dbus.ObjectPath)
+	if path == "" || path == "/" {
+		return Network{}, nil
+	}
+
+	var props map[string]dbus.Variant
+	err = w.conn.Object(nmService, path).Call(propertiesInterface+".GetAll", 0, activeInterface).Store(&props)
+	if err != nil {
+		return Network{}, fmt.Errorf("failed to read active connection %s: %w", path, err)
+	}
+
+	network := Network{
+		ID:   stringProp(props, "Id"),
+		UUID: stringProp(props, "Uuid"),
+		Type: stringProp(props, "Type"),
+	}
+	nmType := network.Type
+	if name, ok := typeNames[nmType]; ok {
+		network.Type = name
+	}
+
+	if nmType == "802-11-wireless" {
+		settingsPath, _ := props["Connection"].Value().(dbus.ObjectPath)
+		if ssid, err := w.ssid(settingsPath); err == nil {
+			network.SSID = ssid
+		} else {
+			log.Printf("Failed to read SSID of %s: %v", network.ID, err)
+		}
+	}
+	return network, nil
+}
+
+// ssid reads the SSID from a connection's settings
+func (w *Watcher) ssid(settingsPath dbus.ObjectPath) (string, error) {
+	if settingsPath == "" || settingsPath == "/" {
+		return "", fmt.Errorf("no settings path")
+	}
+	var settings map[string]map[string]dbus.Variant
+	err := w.conn.Object(nmService, settingsPath).Call(settingsConnInterface+".GetSettings", 0).Store(&settings)
+	if err != nil {
+		return "", err
+	}
+	ssid, _ := settings["802-11-wireless"]["ssid"].Value().([]byte)
+	return string(ssid), nil
+}
+
+func stringProp(props map[string]dbus.Variant, name string) string {
+	v, ok := props[name]
+	if !ok {
+		return ""
+	}
+	s, _ := v.Value().(string)
+	return s
+}
@@ -0,0 +1,148 @@
+package netmon_test
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/netmon"
+	"github.com/bisand/twingate-tray/internal/netmon/netmontest"
+	"github.com/godbus/dbus/v5"
+)
+
+// privateBus starts a dbus-daemon for the test and returns its address
+func privateBus(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the bus address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+func connect(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect to the private bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWatcherAppliesRulesOnPrimaryChange(t *testing.T) {
+	address := privateBus(t)
+	nm, err := netmontest.Export(connect(t, address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []netmon.Rule{
+		{SSID: "Office", Action: netmon.ActionDisconnect},
+		{Type: "ethernet", Action: netmon.ActionNone},
+		{Type: "wifi", Action: netmon.ActionConnect},
+	}
+	type firing struct {
+		network netmon.Network
+		action  netmon.Action
+	}
+	fired := make(chan firing, 8)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := netmon.NewWatcher(connect(t, address))
+	watcher.Subscribe(func(network netmon.Network) {
+		if rule, ok := netmon.Evaluate(rules, network); ok {
+			fired <- firing{network, rule.Action}
+		}
+	})
+	if err := watcher.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !watcher.Current().IsZero() {
+		t.Fatalf("Current = %s, want no network", watcher.Current())
+	}
+
+	steps := []struct {
+		network netmon.Network
+		want    netmon.Action
+	}{
+		{netmon.Network{ID: "Cafe Wi-Fi", UUID: "uuid-1", Type: "wifi", SSID: "Cafe"}, netmon.ActionConnect},
+		{netmon.Network{ID: "Office Wi-Fi", UUID: "uuid-2", Type: "wifi", SSID: "Office"}, netmon.ActionDisconnect},
+		{netmon.Network{ID: "Wired", UUID: "uuid-3", Type: "ethernet"}, netmon.ActionNone},
+	}
+	for _, step := range steps {
+		if err := nm.SetPrimary(step.network); err != nil {
+			t.Fatalf("SetPrimary: %v", err)
+		}
+		select {
+		case got := <-fired:
+			if got.network != step.network || got.action != step.want {
+				t.Errorf("on %s fired %s for %s, want %s", step.network, got.action, got.network, step.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no rule fired after switching to %s", step.network)
+		}
+	}
+
+	// Losing the network matches no rule
+	if err := nm.SetPrimary(netmon.Network{}); err != nil {
+		t.Fatalf("SetPrimary: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !watcher.Current().IsZero() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !watcher.Current().IsZero() {
+		t.Fatalf("Current = %s after the network went away", watcher.Current())
+	}
+	select {
+	case got := <-fired:
+		t.Errorf("rule %s fired without a network", got.action)
+	default:
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []netmon.Rule{
+		{SSID: "Home", Action: netmon.ActionNone},
+		{Connection: "Office LAN", Action: netmon.ActionConnect},
+		{Type: "wifi", Action: netmon.ActionConnect},
+		{Type: "any", Action: netmon.ActionDisconnect},
+	}
+	tests := []struct {
+		network netmon.Network
+		want    netmon.Action
+		ok      bool
+	}{
+		{netmon.Network{UUID: "1", Type: "wifi", SSID: "Home"}, netmon.ActionNone, true},
+		{netmon.Network{UUID: "2", Type: "ethernet", ID: "Office LAN"}, netmon.ActionConnect, true},
+		{netmon.Network{UUID: "3", Type: "wifi", SSID: "Cafe"}, netmon.ActionConnect, true},
+		{netmon.Network{UUID: "4", Type: "vpn", ID: "Work VPN"}, netmon.ActionDisconnect, true},
+		{netmon.Network{}, "", false},
+	}
+	for _, tt := range tests {
+		rule, ok := netmon.Evaluate(rules, tt.network)
+		if ok != tt.ok || rule.Action != tt.want {
+			t.Errorf("Evaluate(%s) = %s, %v; want %s, %v", tt.network, rule.Action, ok, tt.want, tt.ok)
+		}
+	}
+}
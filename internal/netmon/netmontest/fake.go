@@ -0,0 +1,125 @@
+// Package netmontest provides a fake NetworkManager for exercising
+// netmon.Watcher and the network rules on a private bus
+package netmontest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bisand/twingate-tray/internal/netmon"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// NetworkManager D-Bus names the Watcher reads
+const (
+	nmService             = "org.freedesktop.NetworkManager"
+	nmPath                = dbus.ObjectPath("/org/freedesktop/NetworkManager")
+	nmInterface           = "org.freedesktop.NetworkManager"
+	activeInterface       = "org.freedesktop.NetworkManager.Connection.Active"
+	settingsConnInterface = "org.freedesktop.NetworkManager.Settings.Connection"
+)
+
+// NetworkManager connection types for the short names used in rules
+var nmTypes = map[string]string{
+	"wifi":      "802-11-wireless",
+	"ethernet":  "802-3-ethernet",
+	"vpn":       "vpn",
+	"wireguard": "wireguard",
+	"mobile":    "gsm",
+}
+
+// NetworkManager exports the parts of NetworkManager the Watcher reads
+type NetworkManager struct {
+	conn  *dbus.Conn
+	props *prop.Properties
+
+	mu   sync.Mutex
+	next int
+}
+
+// Export claims NetworkManager's name on conn and exports a manager with no
+// primary connection
+func Export(conn *dbus.Conn) (*NetworkManager, error) {
+	reply, err := conn.RequestName(nmService, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", nmService, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("%s is already owned (reply=%d)", nmService, reply)
+	}
+
+	props, err := prop.Export(conn, nmPath, prop.Map{
+		nmInterface: {
+			"PrimaryConnection": {Value: dbus.ObjectPath("/"), Emit: prop.EmitTrue},
+			"ActiveConnections": {Value: []dbus.ObjectPath{}, Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export fake NetworkManager: %w", err)
+	}
+	return &NetworkManager{conn: conn, props: props}, nil
+}
+
+// SetPrimary makes network the primary connection, or clears it when network
+// is zero. Type takes the short rule names ("wifi", "ethernet", ...).
+func (f *NetworkManager) SetPrimary(network netmon.Network) error {
+	if network.IsZero() {
+		f.props.SetMust(nmInterface, "ActiveConnections", []dbus.ObjectPath{})
+		f.props.SetMust(nmInterface, "PrimaryConnection", dbus.ObjectPath("/"))
+		return nil
+	}
+
+	f.mu.Lock()
+	f.next++
+	n := f.next
+	f.mu.Unlock()
+
+	nmType := network.Type
+	if full, ok := nmTypes[network.Type]; ok {
+		nmType = full
+	}
+
+	settingsPath := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/NetworkManager/Settings/%d", n))
+	settings := map[string]map[string]dbus.Variant{
+		"connection": {
+			"id":   dbus.MakeVariant(network.ID),
+			"uuid": dbus.MakeVariant(network.UUID),
+			"type": dbus.MakeVariant(nmType),
+		},
+	}
+	if network.SSID != "" {
+		settings["802-11-wireless"] = map[string]dbus.Variant{
+			"ssid": dbus.MakeVariant([]byte(network.SSID)),
+		}
+	}
+	if err := f.conn.Export(fakeSettings{settings}, settingsPath, settingsConnInterface); err != nil {
+		return fmt.Errorf("failed to export fake settings: %w", err)
+	}
+
+	activePath := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/NetworkManager/ActiveConnection/%d", n))
+	_, err := prop.Export(f.conn, activePath, prop.Map{
+		activeInterface: {
+			"Id":         {Value: network.ID},
+			"Uuid":       {Value: network.UUID},
+			"Type":       {Value: nmType},
+			"Connection": {Value: settingsPath},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export fake active connection: %w", err)
+	}
+
+	f.props.SetMust(nmInterface, "ActiveConnections", []dbus.ObjectPath{activePath})
+	f.props.SetMust(nmInterface, "PrimaryConnection", activePath)
+	return nil
+}
+
+// fakeSettings implements Settings.Connection.GetSettings
+type fakeSettings struct {
+	settings map[string]map[string]dbus.Variant
+}
+
+func (s fakeSettings) GetSettings() (map[string]map[string]dbus.Variant, *dbus.Error) {
+	return s.settings, nil
+}
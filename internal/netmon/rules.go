@@ -0,0 +1,77 @@
+package netmon
+
+import "fmt"
+
+// Action is what a rule does with the Twingate connection
+type Action string
+
+// Rule actions
+const (
+	ActionNone       Action = "none" // Leave the connection alone; stops rule evaluation
+	ActionConnect    Action = "connect"
+	ActionDisconnect Action = "disconnect"
+)
+
+// ParseAction parses a rule action name
+func ParseAction(s string) (Action, error) {
+	switch a := Action(s); a {
+	case ActionNone, ActionConnect, ActionDisconnect:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown action %q (want connect, disconnect or none)", s)
+}
+
+// Rule matches a network and says what to do when it becomes primary.
+// Empty fields match anything; Type "any" does too.
+type Rule struct {
+	SSID       string
+	Connection string // NetworkManager connection name
+	Type       string // "wifi", "ethernet", "vpn", ...
+	Action     Action
+}
+
+// Matches reports whether the rule applies to network. No rule matches the
+// absence of a network.
+func (r Rule) Matches(network Network) bool {
+	if network.IsZero() {
+		return false
+	}
+	if r.SSID != "" && r.SSID != network.SSID {
+		return false
+	}
+	if r.Connection != "" && r.Connection != network.ID {
+		return false
+	}
+	if r.Type != "" && r.Type != "any" && r.Type != network.Type {
+		return false
+	}
+	return true
+}
+
+// String describes the rule for logs
+func (r Rule) String() string {
+	match := ""
+	if r.SSID != "" {
+		match += fmt.Sprintf(" ssid=%q", r.SSID)
+	}
+	if r.Connection != "" {
+		match += fmt.Sprintf(" connection=%q", r.Connection)
+	}
+	if r.Type != "" {
+		match += fmt.Sprintf(" type=%q", r.Type)
+	}
+	if match == "" {
+		match = " any network"
+	}
+	return string(r.Action) + " on" + match
+}
+
+// Evaluate returns the first rule that matches network
+func Evaluate(rules []Rule, network Network) (Rule, bool) {
+	for _, r := range rules {
+		if r.Matches(network) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
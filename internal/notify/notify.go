@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// D-Bus names for the freedesktop notification service
+const (
+	notificationsService   = "org.freedesktop.Notifications"
+	notificationsPath      = "/org/freedesktop/Notifications"
+	notificationsInterface = "org.freedesktop.Notifications"
+)
+
+// Urgency is the notification urgency level defined by the spec
+type Urgency byte
+
+// Urgency levels
+const (
+	UrgencyLow      Urgency = 0
+	UrgencyNormal   Urgency = 1
+	UrgencyCritical Urgency = 2
+)
+
+// Action is a button shown on a notification. Run is called when the user
+// clicks it.
+type Action struct {
+	Key   string
+	Label string
+	Run   func()
+}
+
+// Notification describes a single desktop notification
+type Notification struct {
+	Title   string
+	Body    string
+	Urgency Urgency
+	// Tag groups notifications that should update in place: sending a
+	// notification with the same tag replaces the previous one.
+	Tag     string
+	Actions []Action
+	// Timeout overrides the notifier's default display duration
+	Timeout time.Duration
+}
+
+// Notifier sends notifications through org.freedesktop.Notifications and
+// routes action clicks back to the callbacks that registered them
+type Notifier struct {
+	conn    *dbus.Conn
+	obj     dbus.BusObject
+	appName string
+	icon    string
+	signals chan *dbus.Signal
+
+	mu      sync.Mutex
+	timeout time.Duration
+	tagIDs  map[string]uint32
+	actions map[uint32]map[string]func()
+}
+
+// New creates a Notifier on an existing session bus connection and starts
+// listening for action clicks
+func New(conn *dbus.Conn, appName, icon string, timeout time.Duration) (*Notifier, error) {
+	n := &Notifier{
+		conn:    conn,
+		obj:     conn.Object(notificationsService, notificationsPath),
+		appName: appName,
+		icon:    icon,
+		timeout: timeout,
+		signals: make(chan *dbus.Signal, 16),
+		tagIDs:  make(map[string]uint32),
+		actions: make(map[uint32]map[string]func()),
+	}
+
+	for _, member := range []string{"ActionInvoked", "NotificationClosed"} {
+		err := conn.AddMatchSignal(
+			dbus.WithMatchObjectPath(notificationsPath),
+			dbus.WithMatchInterface(notificationsInterface),
+			dbus.WithMatchMember(member),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", member, err)
+		}
+	}
+	conn.Signal(n.signals)
+	go n.handleSignals()
+
+	return n, nil
+}
+
+// SetTimeout changes the default display duration of new notifications
+func (n *Notifier) SetTimeout(timeout time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.timeout = timeout
+}
+
+// Send shows a notification and returns the id assigned by the server
+func (n *Notifier) Send(notif Notification) (uint32, error) {
+	n.mu.Lock()
+	replacesID := n.tagIDs[notif.Tag]
+	timeout := n.timeout
+	n.mu.Unlock()
+
+	actions := make([]string, 0, len(notif.Actions)*2)
+	for _, a := range notif.Actions {
+		actions = append(actions, a.Key, a.Label)
+	}
+
+	hints := map[string]dbus.Variant{
+		"urgency": dbus.MakeVariant(byte(notif.Urgency)),
+	}
+	if notif.Tag != "" {
+		// Lets servers that keep history collapse updates of the same tag
+		hints["x-canonical-private-synchronous"] = dbus.MakeVariant(notif.Tag)
+	}
+
+	if notif.Timeout != 0 {
+		timeout = notif.Timeout
+	}
+	if notif.Urgency == UrgencyCritical {
+		timeout = 0 // Critical notifications stay until dismissed
+	}
+
+	var id uint32
+	call := n.obj.Call(notificationsInterface+".Notify", 0,
+		n.appName, replacesID, n.icon, notif.Title, notif.Body,
+		actions, hints, int32(timeout/time.Millisecond))
+	if call.Err != nil {
+		return 0, fmt.Errorf("failed to send notification: %w", call.Err)
+	}
+	if err := call.Store(&id); err != nil {
+		return 0, fmt.Errorf("failed to read notification id: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if replacesID != 0 && replacesID != id {
+		delete(n.actions, replacesID)
+	}
+	if notif.Tag != "" {
+		n.tagIDs[notif.Tag] = id
+	}
+	if len(notif.Actions) > 0 {
+		callbacks := make(map[string]func(), len(notif.Actions))
+		for _, a := range notif.Actions {
+			callbacks[a.Key] = a.Run
+		}
+		n.actions[id] = callbacks
+	} else {
+		delete(n.actions, id)
+	}
+
+	return id, nil
+}
+
+// Close dismisses the notification currently shown for tag, if any
+func (n *Notifier) Close(tag string) {
+	n.mu.Lock()
+	id, ok := n.tagIDs[tag]
+	n.mu.Unlock()
+	if !ok {
+		return
+	}
+	if call := n.obj.Call(notificationsInterface+".CloseNotification", 0, id); call.Err != nil {
+		log.Printf("Failed to close notification %d: %v", id, call.Err)
+	}
+}
+
+// handleSignals dispatches ActionInvoked and NotificationClosed signals
+func (n *Notifier) handleSignals() {
+	for sig := range n.signals {
+		switch sig.Name {
+		case notificationsInterface + ".ActionInvoked":
+			if len(sig.Body) < 2 {
+				continue
+			}
+			id, _ := sig.Body[0].(uint32)
+			key, _ := sig.Body[1].(string)
+
+			n.mu.Lock()
+			run := n.actions[id][key]
+			n.mu.Unlock()
+
+			if run != nil {
+				log.Printf("Notification action invoked: %s", key)
+				go run()
+			}
+
+		case notificationsInterface + ".NotificationClosed":
+			if len(sig.Body) < 1 {
+				continue
+			}
+			id, _ := sig.Body[0].(uint32)
+
+			n.mu.Lock()
+			delete(n.actions, id)
+			for tag, tagID := range n.tagIDs {
+				if tagID == id {
+					delete(n.tagIDs, tag)
+				}
+			}
+			n.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/launch"
+	"github.com/bisand/twingate-tray/internal/probe"
+	"github.com/bisand/twingate-tray/internal/twingate"
+)
+
+// Status is the result of `twingate-tray status`
+type Status struct {
+	Header
+	State          string     `json:"state"`
+	Network        string     `json:"network"`
+	User           string     `json:"user"`
+	ConnectedSince *time.Time `json:"connected_since"` // null when not connected
+	Interface      string     `json:"interface"`
+	ExitNode       string     `json:"exit_node"` // Empty when no exit node is active
+}
+
+// NewStatus builds a Status from the state name (an app.ConnectionState name)
+// and the gathered connection info. exit may be nil if it was not available.
+func NewStatus(state string, info twingate.ConnectionInfo, exit *twingate.ExitNodeStatus) Status {
+	s := Status{
+		Header:    header("status"),
+		State:     state,
+		Network:   known(info.Network),
+		User:      known(info.UserEmail),
+		Interface: known(info.Interface),
+	}
+	if !info.ConnectedAt.IsZero() {
+		since := info.ConnectedAt.UTC()
+		s.ConnectedSince = &since
+	}
+	if exit != nil && exit.Enabled {
+		s.ExitNode = exit.CurrentNode
+	}
+	return s
+}
+
+// SetConnectedSince overrides the connection time, e.g. with the daemon's
+// own record. A zero time clears it.
+func (s *Status) SetConnectedSince(t time.Time) {
+	if t.IsZero() {
+		s.ConnectedSince = nil
+		return
+	}
+	t = t.UTC()
+	s.ConnectedSince = &t
+}
+
+// writeText prints the bare state name, which scripts have always parsed
+func (s Status) writeText(w io.Writer) error {
+	_, err := fmt.Fprintln(w, s.State)
+	return err
+}
+
+func (s Status) rows() [][]string {
+	since := ""
+	if s.ConnectedSince != nil {
+		since = s.ConnectedSince.Format(time.RFC3339)
+	}
+	return [][]string{
+		{"state", "network", "user", "connected_since", "interface", "exit_node"},
+		{s.State, s.Network, s.User, since, s.Interface, s.ExitNode},
+	}
+}
+
+// Resource is a single entry of Resources
+type Resource struct {
+	Name       string         `json:"name"`
+	Address    string         `json:"address"`
+	AuthStatus string         `json:"auth_status"`
+	NeedsAuth  bool           `json:"needs_auth"`
+	Probe      *ResourceProbe `json:"probe,omitempty"` // Only with --probe
+}
+
+// ResourceProbe is the reachability of a Resource
+type ResourceProbe struct {
+	Status    string   `json:"status"` // A probe.Status
+	Port      int      `json:"port,omitempty"`
+	LatencyMS float64  `json:"latency_ms,omitempty"`
+	DNSMS     float64  `json:"dns_ms,omitempty"`
+	Resolved  []string `json:"resolved,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	summary   string
+}
+
+// Resources is the result of `twingate-tray resources`
+type Resources struct {
+	Header
+	Resources []Resource `json:"resources"`
+	probed    bool
+}
+
+// NewResources builds Resources from the Twingate resource list
+func NewResources(resources []twingate.Resource) Resources {
+	r := Resources{Header: header("resources"), Resources: []Resource{}}
+	for _, res := range resources {
+		r.Resources = append(r.Resources, Resource{
+			Name:       res.Name,
+			Address:    res.Address,
+			AuthStatus: known(res.AuthStatus),
+			NeedsAuth:  res.NeedsAuth,
+		})
+	}
+	return r
+}
+
+// SetProbes adds reachability results, keyed by address, to every resource
+func (r *Resources) SetProbes(results map[string]probe.Result) {
+	r.probed = true
+	for i, res := range r.Resources {
+		result, ok := results[res.Address]
+		if !ok {
+			continue
+		}
+		p := &ResourceProbe{
+			Status:   string(result.Status),
+			Port:     result.Port,
+			Resolved: result.Resolved,
+			Error:    result.Error,
+			summary:  result.Summary(),
+		}
+		if result.Status == probe.StatusReachable {
+			p.LatencyMS = milliseconds(result.Latency)
+			p.summary += fmt.Sprintf(" (port %d)", result.Port)
+		}
+		if result.DNSTime > 0 {
+			p.DNSMS = milliseconds(result.DNSTime)
+		}
+		r.Resources[i].Probe = p
+	}
+}
+
+func (r Resources) writeText(w io.Writer) error {
+	if len(r.Resources) == 0 {
+		_, err := fmt.Fprintln(w, "No resources available")
+		return err
+	}
+	header := []string{"NAME", "ADDRESS", "AUTH"}
+	if r.probed {
+		header = append(header, "REACHABILITY")
+	}
+	rows := [][]string{header}
+	for _, res := range r.Resources {
+		auth := orDash(res.AuthStatus)
+		if res.NeedsAuth {
+			auth = "locked"
+		}
+		row := []string{res.Name, res.Address, auth}
+		if r.probed {
+			row = append(row, res.Probe.text())
+		}
+		rows = append(rows, row)
+	}
+	return writeTable(w, rows, true)
+}
+
+func (r Resources) rows() [][]string {
+	header := []string{"name", "address", "auth_status", "needs_auth"}
+	if r.probed {
+		header = append(header, "probe_status", "probe_port", "latency_ms", "dns_ms", "probe_error")
+	}
+	rows := [][]string{header}
+	for _, res := range r.Resources {
+		row := []string{res.Name, res.Address, res.AuthStatus, strconv.FormatBool(res.NeedsAuth)}
+		if r.probed {
+			p := res.Probe
+			if p == nil {
+				p = &ResourceProbe{}
+			}
+			row = append(row, p.Status, formatOptionalInt(p.Port), formatOptionalFloat(p.LatencyMS),
+				formatOptionalFloat(p.DNSMS), p.Error)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// text describes the probe for the text table, with the reason when it failed
+func (p *ResourceProbe) text() string {
+	switch {
+	case p == nil:
+		return "-"
+	case p.Error != "":
+		return p.summary + ": " + p.Error
+	default:
+		return p.summary
+	}
+}
+
+// milliseconds converts d to fractional milliseconds, to the microsecond
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Round(time.Microsecond).Microseconds()) / 1000
+}
+
+func formatOptionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func formatOptionalFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Launcher is a single entry of Launchers
+type Launcher struct {
+	Name     string   `json:"name"`
+	Command  []string `json:"command"` // Expanded for the resource; empty when it cannot be
+	Terminal bool     `json:"terminal"`
+	Error    string   `json:"error,omitempty"` // Why the command cannot be expanded
+}
+
+// Launchers is the result of `twingate-tray open --list`
+type Launchers struct {
+	Header
+	Resource  string     `json:"resource"`
+	Launchers []Launcher `json:"launchers"`
+}
+
+// NewLaunchers lists the launchers that open a resource, with their commands
+func NewLaunchers(res twingate.Resource, launchers []launch.Launcher) Launchers {
+	l := Launchers{Header: header("launchers"), Resource: res.Name, Launchers: []Launcher{}}
+	for _, launcher := range launchers {
+		entry := Launcher{Name: launcher.Name, Command: []string{}, Terminal: launcher.Terminal}
+		if args, err := launcher.Args(res.Name, res.Address); err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.Command = args
+		}
+		l.Launchers = append(l.Launchers, entry)
+	}
+	return l
+}
+
+func (l Launchers) writeText(w io.Writer) error {
+	if len(l.Launchers) == 0 {
+		_, err := fmt.Fprintf(w, "No launchers for %s\n", l.Resource)
+		return err
+	}
+	rows := [][]string{{"LAUNCHER", "COMMAND"}}
+	for _, launcher := range l.Launchers {
+		command := launch.Quote(launcher.Command)
+		if launcher.Error != "" {
+			command = "error: " + launcher.Error
+		} else if launcher.Terminal {
+			command += " (in a terminal)"
+		}
+		rows = append(rows, []string{launcher.Name, command})
+	}
+	return writeTable(w, rows, true)
+}
+
+func (l Launchers) rows() [][]string {
+	rows := [][]string{{"name", "command", "terminal", "error"}}
+	for _, launcher := range l.Launchers {
+		rows = append(rows, []string{launcher.Name, launch.Quote(launcher.Command),
+			strconv.FormatBool(launcher.Terminal), launcher.Error})
+	}
+	return rows
+}
+
+// ExitNode is a single entry of ExitNodes
+type ExitNode struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// ExitNodes is the result of `twingate-tray exit-nodes`
+type ExitNodes struct {
+	Header
+	Enabled bool       `json:"enabled"`
+	Current string     `json:"current"` // Empty when no exit node is active
+	Nodes   []ExitNode `json:"nodes"`
+}
+
+// NewExitNodes builds ExitNodes from the Twingate exit node status
+func NewExitNodes(status twingate.ExitNodeStatus) ExitNodes {
+	e := ExitNodes{Header: header("exit_nodes"), Enabled: status.Enabled, Nodes: []ExitNode{}}
+	if status.Enabled {
+		e.Current = status.CurrentNode
+	}
+	for _, node := range status.AvailableNodes {
+		e.Nodes = append(e.Nodes, ExitNode{Name: node, Active: status.Enabled && node == status.CurrentNode})
+	}
+	return e
+}
+
+func (e ExitNodes) writeText(w io.Writer) error {
+	if len(e.Nodes) == 0 {
+		_, err := fmt.Fprintln(w, "No exit nodes available")
+		return err
+	}
+	for _, node := range e.Nodes {
+		marker := " "
+		if node.Active {
+			marker = "*"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", marker, node.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e ExitNodes) rows() [][]string {
+	rows := [][]string{{"name", "active"}}
+	for _, node := range e.Nodes {
+		rows = append(rows, []string{node.Name, strconv.FormatBool(node.Active)})
+	}
+	return rows
+}
+
+// Version is the result of `twingate-tray version`
+type Version struct {
+	Header
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	text      string
+}
+
+// NewVersion builds a Version document. text is the human-readable form.
+func NewVersion(version, commit, buildDate, text string) Version {
+	return Version{Header: header("version"), Version: version, GitCommit: commit, BuildDate: buildDate, text: text}
+}
+
+func (v Version) writeText(w io.Writer) error {
+	_, err := fmt.Fprintln(w, v.text)
+	return err
+}
+
+func (v Version) rows() [][]string {
+	return [][]string{
+		{"version", "git_commit", "build_date"},
+		{v.Version, v.GitCommit, v.BuildDate},
+	}
+}
+
+// Error reports a failed command in the requested format
+type Error struct {
+	Header
+	Error string `json:"error"`
+}
+
+// NewError builds an Error document
+func NewError(err error) Error {
+	return Error{Header: header("error"), Error: err.Error()}
+}
+
+func (e Error) writeText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Error: %s\n", e.Error)
+	return err
+}
+
+func (e Error) rows() [][]string {
+	return [][]string{{"error"}, {e.Error}}
+}
+
+// known strips the "-" placeholder ConnectionInfo uses for missing values
+func known(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/history"
+	"github.com/bisand/twingate-tray/internal/netif"
+)
+
+// HistoryDay is a single entry of History
+type HistoryDay struct {
+	Date             string `json:"date,omitempty"` // Local date, YYYY-MM-DD; empty in Total
+	ConnectedSeconds int64  `json:"connected_seconds"`
+	Sessions         int    `json:"sessions"`
+	Drops            int    `json:"drops"`
+	RxBytes          uint64 `json:"rx_bytes"`
+	TxBytes          uint64 `json:"tx_bytes"`
+}
+
+// History is the result of `twingate-tray history`
+type History struct {
+	Header
+	Days  []HistoryDay `json:"days"`
+	Total HistoryDay   `json:"total"` // Date is empty
+}
+
+// NewHistory builds History from the per-day summaries
+func NewHistory(days []history.DaySummary) History {
+	h := History{Header: header("history"), Days: []HistoryDay{}}
+	for _, d := range days {
+		day := HistoryDay{
+			Date:             d.Day.Format("2006-01-02"),
+			ConnectedSeconds: int64(d.Connected / time.Second),
+			Sessions:         d.Sessions,
+			Drops:            d.Drops,
+			RxBytes:          d.Received,
+			TxBytes:          d.Sent,
+		}
+		h.Days = append(h.Days, day)
+		h.Total.ConnectedSeconds += day.ConnectedSeconds
+		h.Total.Sessions += day.Sessions
+		h.Total.Drops += day.Drops
+		h.Total.RxBytes += day.RxBytes
+		h.Total.TxBytes += day.TxBytes
+	}
+	return h
+}
+
+func (h History) writeText(w io.Writer) error {
+	rows := [][]string{{"DATE", "CONNECTED", "SESSIONS", "DROPS", "RECEIVED", "SENT"}}
+	for _, d := range append(h.Days, h.Total) {
+		date := d.Date
+		if date == "" {
+			date = "Total"
+		}
+		rows = append(rows, []string{
+			date,
+			formatHours(time.Duration(d.ConnectedSeconds) * time.Second),
+			strconv.Itoa(d.Sessions),
+			strconv.Itoa(d.Drops),
+			netif.FormatBytes(d.RxBytes),
+			netif.FormatBytes(d.TxBytes),
+		})
+	}
+	return writeTable(w, rows, true)
+}
+
+func (h History) rows() [][]string {
+	rows := [][]string{{"date", "connected_seconds", "sessions", "drops", "rx_bytes", "tx_bytes"}}
+	for _, d := range h.Days {
+		rows = append(rows, []string{
+			d.Date,
+			strconv.FormatInt(d.ConnectedSeconds, 10),
+			strconv.Itoa(d.Sessions),
+			strconv.Itoa(d.Drops),
+			strconv.FormatUint(d.RxBytes, 10),
+			strconv.FormatUint(d.TxBytes, 10),
+		})
+	}
+	return rows
+}
+
+// formatHours formats d as hours and minutes, e.g. "7h 05m"
+func formatHours(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
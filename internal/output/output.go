@@ -0,0 +1,100 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// SchemaVersion is the version of the JSON documents. It is bumped whenever a
+// field is removed, renamed or changes meaning; new fields may be added
+// without a bump.
+const SchemaVersion = 1
+
+// Format selects how CLI results are written
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatTSV  Format = "tsv"
+)
+
+// ParseFormat parses the value of the --output flag
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatJSON, FormatTSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (want json, text or tsv)", s)
+}
+
+// Document is a result that can be written in every output format
+type Document interface {
+	// writeText writes the human-readable form
+	writeText(w io.Writer) error
+	// rows returns the TSV header followed by the data rows
+	rows() [][]string
+}
+
+// Header is embedded in every JSON document so consumers can check the
+// schema before reading the rest
+type Header struct {
+	SchemaVersion int    `json:"schema_version"`
+	Kind          string `json:"kind"`
+}
+
+func header(kind string) Header {
+	return Header{SchemaVersion: SchemaVersion, Kind: kind}
+}
+
+// Write writes doc to w in the given format
+func Write(w io.Writer, format Format, doc Document) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatTSV:
+		for _, row := range doc.rows() {
+			if _, err := fmt.Fprintln(w, strings.Join(sanitizeTSV(row), "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return doc.writeText(w)
+	}
+}
+
+// sanitizeTSV replaces tabs and newlines so every value stays in its column
+func sanitizeTSV(row []string) []string {
+	clean := make([]string, len(row))
+	for i, v := range row {
+		clean[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(v)
+	}
+	return clean
+}
+
+// writeTable writes rows as aligned columns, skipping the header row when
+// withHeader is false
+func writeTable(w io.Writer, rows [][]string, withHeader bool) error {
+	if !withHeader && len(rows) > 0 {
+		rows = rows[1:]
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(sanitizeTSV(row), "\t"))
+	}
+	return tw.Flush()
+}
+
+// orDash returns "-" for empty values in text output
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,168 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// XDG desktop portal D-Bus names
+const (
+	portalService     = "org.freedesktop.portal.Desktop"
+	portalPath        = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+	settingsInterface = "org.freedesktop.portal.Settings"
+
+	appearanceNamespace = "org.freedesktop.appearance"
+	colorSchemeKey      = "color-scheme"
+)
+
+// ColorScheme is the desktop's preferred colour scheme
+type ColorScheme uint32
+
+// Values of org.freedesktop.appearance color-scheme
+const (
+	NoPreference ColorScheme = iota
+	PreferDark
+	PreferLight
+)
+
+// String returns the scheme as named by the portal specification
+func (c ColorScheme) String() string {
+	switch c {
+	case PreferDark:
+		return "prefer-dark"
+	case PreferLight:
+		return "prefer-light"
+	default:
+		return "no-preference"
+	}
+}
+
+// ColorSchemeWatcher follows the color-scheme setting of the desktop portal
+type ColorSchemeWatcher struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Signal
+
+	mu          sync.Mutex
+	scheme      ColorScheme
+	subscribers []func(ColorScheme)
+}
+
+// NewColorSchemeWatcher creates a watcher on conn, the session bus
+func NewColorSchemeWatcher(conn *dbus.Conn) *ColorSchemeWatcher {
+	return &ColorSchemeWatcher{conn: conn, signals: make(chan *dbus.Signal, 16)}
+}
+
+// Subscribe registers fn to be called when the scheme changes. Callbacks run
+// on the watcher's goroutine and must not block.
+func (w *ColorSchemeWatcher) Subscribe(fn func(ColorScheme)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Scheme returns the last known colour scheme
+func (w *ColorSchemeWatcher) Scheme() ColorScheme {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.scheme
+}
+
+// Start reads the current scheme and follows changes until ctx is cancelled
+func (w *ColorSchemeWatcher) Start(ctx context.Context) error {
+	scheme, err := w.read()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.scheme = scheme
+	w.mu.Unlock()
+
+	err = w.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(portalPath),
+		dbus.WithMatchInterface(settingsInterface),
+		dbus.WithMatchMember("SettingChanged"),
+		dbus.WithMatchArg(0, appearanceNamespace),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to watch the colour scheme: %w", err)
+	}
+	w.conn.Signal(w.signals)
+
+	go w.run(ctx)
+	return nil
+}
+
+// read asks the portal for the scheme. ReadOne is tried first; portals
+// before version 2 only have Read, which wraps the value in a second variant.
+func (w *ColorSchemeWatcher) read() (ColorScheme, error) {
+	obj := w.conn.Object(portalService, portalPath)
+
+	var value dbus.Variant
+	err := obj.Call(settingsInterface+".ReadOne", 0, appearanceNamespace, colorSchemeKey).Store(&value)
+	if err != nil {
+		if err = obj.Call(settingsInterface+".Read", 0, appearanceNamespace, colorSchemeKey).Store(&value); err != nil {
+			return NoPreference, fmt.Errorf("failed to read the colour scheme from the desktop portal: %w", err)
+		}
+	}
+	return schemeFromVariant(value), nil
+}
+
+func (w *ColorSchemeWatcher) run(ctx context.Context) {
+	defer w.conn.RemoveSignal(w.signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-w.signals:
+			if !ok {
+				return
+			}
+			if sig.Path != portalPath || sig.Name != settingsInterface+".SettingChanged" || len(sig.Body) < 3 {
+				continue
+			}
+			namespace, _ := sig.Body[0].(string)
+			key, _ := sig.Body[1].(string)
+			if namespace != appearanceNamespace || key != colorSchemeKey {
+				continue
+			}
+			value, _ := sig.Body[2].(dbus.Variant)
+			w.update(schemeFromVariant(value))
+		}
+	}
+}
+
+// update stores scheme and notifies subscribers if it differs from the last one
+func (w *ColorSchemeWatcher) update(scheme ColorScheme) {
+	w.mu.Lock()
+	if scheme == w.scheme {
+		w.mu.Unlock()
+		return
+	}
+	w.scheme = scheme
+	subscribers := w.subscribers
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(scheme)
+	}
+}
+
+// schemeFromVariant unwraps the uint32 setting; unknown values count as no
+// preference
+func schemeFromVariant(v dbus.Variant) ColorScheme {
+	value := v.Value()
+	for {
+		inner, ok := value.(dbus.Variant)
+		if !ok {
+			break
+		}
+		value = inner.Value()
+	}
+	if n, ok := value.(uint32); ok && n <= uint32(PreferLight) {
+		return ColorScheme(n)
+	}
+	return NoPreference
+}
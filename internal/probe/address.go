@@ -0,0 +1,202 @@
+package probe
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Kind is the form of a resource address
+type Kind int
+
+const (
+	KindHost     Kind = iota // A DNS name such as db.internal
+	KindIP                   // A single IPv4 or IPv6 address
+	KindCIDR                 // A network range such as 10.0.0.0/24
+	KindWildcard             // A DNS pattern such as *.corp.example
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindIP:
+		return "ip"
+	case KindCIDR:
+		return "cidr"
+	case KindWildcard:
+		return "wildcard"
+	default:
+		return "host"
+	}
+}
+
+// PortRange is an inclusive range of TCP ports
+type PortRange struct {
+	First, Last int
+}
+
+// Target is a parsed resource address
+type Target struct {
+	Address string // As given
+	Kind    Kind
+	Host    string       // The DNS name or wildcard pattern
+	IP      netip.Addr   // KindIP
+	Prefix  netip.Prefix // KindCIDR
+	Ports   []PortRange  // Ports in the address; empty means the configured ones
+}
+
+// ParseAddress parses a resource address: a host name, IP address, CIDR
+// range or wildcard name, optionally followed by ports such as ":22",
+// ":8000-8100" or ":80,443". IPv6 addresses need brackets to carry ports.
+func ParseAddress(address string) (Target, error) {
+	t := Target{Address: address}
+	host, ports, err := splitPorts(strings.TrimSpace(address))
+	if err != nil {
+		return t, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	if ports != "" {
+		if t.Ports, err = parsePorts(ports); err != nil {
+			return t, fmt.Errorf("invalid address %q: %w", address, err)
+		}
+	}
+
+	switch {
+	case host == "":
+		return t, fmt.Errorf("invalid address %q: no host", address)
+	case strings.Contains(host, "/"):
+		prefix, err := netip.ParsePrefix(host)
+		if err != nil {
+			return t, fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		t.Kind, t.Prefix = KindCIDR, prefix.Masked()
+	case strings.Contains(host, "*"):
+		if !strings.HasPrefix(host, "*.") || !validHostname(host[2:]) {
+			return t, fmt.Errorf("invalid address %q: wildcards must look like *.example.com", address)
+		}
+		t.Kind, t.Host = KindWildcard, host
+	default:
+		if ip, err := netip.ParseAddr(host); err == nil {
+			t.Kind, t.IP = KindIP, ip
+			break
+		}
+		if !validHostname(host) {
+			return t, fmt.Errorf("invalid address %q: not a host name or IP address", address)
+		}
+		t.Kind, t.Host = KindHost, strings.TrimSuffix(host, ".")
+	}
+	return t, nil
+}
+
+// splitPorts splits "host:ports" and "[v6]:ports". A bare IPv6 address has
+// no ports.
+func splitPorts(s string) (host, ports string, err error) {
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return "", "", fmt.Errorf("missing ]")
+		}
+		host, rest := s[1:end], s[end+1:]
+		if rest == "" {
+			return host, "", nil
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("unexpected %q after ]", rest)
+		}
+		if rest == ":" {
+			return "", "", fmt.Errorf("no ports after :")
+		}
+		return host, rest[1:], nil
+	}
+	if strings.Count(s, ":") != 1 {
+		return s, "", nil
+	}
+	host, ports, _ = strings.Cut(s, ":")
+	if ports == "" {
+		return "", "", fmt.Errorf("no ports after :")
+	}
+	return host, ports, nil
+}
+
+// parsePorts parses a comma-separated list of ports and ranges
+func parsePorts(s string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, part := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		r := PortRange{}
+		var err error
+		if r.First, err = parsePort(first); err != nil {
+			return nil, err
+		}
+		r.Last = r.First
+		if isRange {
+			if r.Last, err = parsePort(last); err != nil {
+				return nil, err
+			}
+			if r.Last < r.First {
+				return nil, fmt.Errorf("port range %s is backwards", part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// validHostname reports whether name is made of DNS labels
+func validHostname(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SingleHost returns the name or IP address to resolve or dial. Ranges and
+// wildcards name no single host.
+func (t Target) SingleHost() (string, error) {
+	switch t.Kind {
+	case KindHost:
+		return t.Host, nil
+	case KindIP:
+		return t.IP.String(), nil
+	case KindCIDR:
+		if t.Prefix.IsSingleIP() {
+			return t.Prefix.Addr().String(), nil
+		}
+		return "", fmt.Errorf("%s is a network range", t.Prefix)
+	default:
+		return "", fmt.Errorf("%s is a wildcard name", t.Host)
+	}
+}
+
+// portList returns up to limit ports to try: the address's own, or
+// defaults when it names none
+func (t Target) portList(defaults []int, limit int) []int {
+	if len(t.Ports) == 0 {
+		return defaults[:min(len(defaults), limit)]
+	}
+	var ports []int
+	for _, r := range t.Ports {
+		for p := r.First; p <= r.Last && len(ports) < limit; p++ {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
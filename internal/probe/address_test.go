@@ -0,0 +1,140 @@
+package probe
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		kind    Kind
+		host    string
+		ip      string
+		prefix  string
+		ports   []PortRange
+	}{
+		{address: "db.internal", kind: KindHost, host: "db.internal"},
+		{address: "db.internal.", kind: KindHost, host: "db.internal"},
+		{address: " db.internal ", kind: KindHost, host: "db.internal"},
+		{address: "my_host", kind: KindHost, host: "my_host"},
+		{address: "db.internal:5432", kind: KindHost, host: "db.internal", ports: []PortRange{{5432, 5432}}},
+		{address: "web:80,443", kind: KindHost, host: "web", ports: []PortRange{{80, 80}, {443, 443}}},
+		{address: "web:8000-8100, 9000", kind: KindHost, host: "web", ports: []PortRange{{8000, 8100}, {9000, 9000}}},
+		{address: "10.0.0.5", kind: KindIP, ip: "10.0.0.5"},
+		{address: "10.0.0.5:22", kind: KindIP, ip: "10.0.0.5", ports: []PortRange{{22, 22}}},
+		{address: "fd00::1", kind: KindIP, ip: "fd00::1"},       // Bare IPv6 has no ports
+		{address: "fd00::1:22", kind: KindIP, ip: "fd00::1:22"}, // ... even when it ends like one
+		{address: "[fd00::1]", kind: KindIP, ip: "fd00::1"},
+		{address: "[fd00::1]:22", kind: KindIP, ip: "fd00::1", ports: []PortRange{{22, 22}}},
+		{address: "10.0.0.0/24", kind: KindCIDR, prefix: "10.0.0.0/24"},
+		{address: "10.0.0.7/24", kind: KindCIDR, prefix: "10.0.0.0/24"}, // Masked
+		{address: "10.0.0.0/24:443", kind: KindCIDR, prefix: "10.0.0.0/24", ports: []PortRange{{443, 443}}},
+		{address: "fd00::/64", kind: KindCIDR, prefix: "fd00::/64"},
+		{address: "[fd00::/64]:22", kind: KindCIDR, prefix: "fd00::/64", ports: []PortRange{{22, 22}}},
+		{address: "*.corp.example", kind: KindWildcard, host: "*.corp.example"},
+		{address: "*.corp.example:443", kind: KindWildcard, host: "*.corp.example", ports: []PortRange{{443, 443}}},
+	}
+	for _, tt := range tests {
+		target, err := ParseAddress(tt.address)
+		if err != nil {
+			t.Errorf("ParseAddress(%q): %v", tt.address, err)
+			continue
+		}
+		if target.Kind != tt.kind || target.Host != tt.host || !slices.Equal(target.Ports, tt.ports) {
+			t.Errorf("ParseAddress(%q) = %s %q ports %v, want %s %q ports %v",
+				tt.address, target.Kind, target.Host, target.Ports, tt.kind, tt.host, tt.ports)
+		}
+		if tt.ip != "" && target.IP != netip.MustParseAddr(tt.ip) {
+			t.Errorf("ParseAddress(%q) IP = %s, want %s", tt.address, target.IP, tt.ip)
+		}
+		if tt.prefix != "" && target.Prefix != netip.MustParsePrefix(tt.prefix) {
+			t.Errorf("ParseAddress(%q) prefix = %s, want %s", tt.address, target.Prefix, tt.prefix)
+		}
+		if target.Address != tt.address {
+			t.Errorf("ParseAddress(%q) kept %q as the address", tt.address, target.Address)
+		}
+	}
+}
+
+func TestParseAddressErrors(t *testing.T) {
+	for _, address := range []string{
+		"",
+		":22",
+		"db:",
+		"[fd00::1]:",
+		"db:0",
+		"db:65536",
+		"db:ssh",
+		"db:100-90", // Backwards range
+		"db:80-",
+		"db:80,,443",
+		"[fd00::1",
+		"[fd00::1]22",
+		"[]:22",
+		"10.0.0.0/33",
+		"*",
+		"*corp.example",
+		"*.",
+		"a.*.example",
+		"*.*.example",
+		"-db.internal",
+		"db-.internal",
+		"db..internal",
+		"db internal",
+		"db!internal",
+	} {
+		if target, err := ParseAddress(address); err == nil {
+			t.Errorf("ParseAddress(%q) = %+v, want an error", address, target)
+		}
+	}
+}
+
+func TestSingleHost(t *testing.T) {
+	tests := []struct {
+		address string
+		host    string
+		err     bool
+	}{
+		{"db.internal:5432", "db.internal", false},
+		{"[fd00::1]:22", "fd00::1", false},
+		{"10.0.0.5/32", "10.0.0.5", false},
+		{"10.0.0.0/24", "", true},
+		{"*.corp.example", "", true},
+	}
+	for _, tt := range tests {
+		target, err := ParseAddress(tt.address)
+		if err != nil {
+			t.Fatalf("ParseAddress(%q): %v", tt.address, err)
+		}
+		host, err := target.SingleHost()
+		if host != tt.host || (err != nil) != tt.err {
+			t.Errorf("SingleHost(%q) = %q, %v; want %q, error: %v", tt.address, host, err, tt.host, tt.err)
+		}
+	}
+}
+
+func TestPortList(t *testing.T) {
+	defaults := []int{443, 80, 22}
+	tests := []struct {
+		address string
+		limit   int
+		want    []int
+	}{
+		{"db", 8, []int{443, 80, 22}},
+		{"db", 2, []int{443, 80}},
+		{"db:5432", 8, []int{5432}},
+		{"db:80,8000-8002", 8, []int{80, 8000, 8001, 8002}},
+		{"db:1-65535", 3, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		target, err := ParseAddress(tt.address)
+		if err != nil {
+			t.Fatalf("ParseAddress(%q): %v", tt.address, err)
+		}
+		if got := target.portList(defaults, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("portList(%q, %d) = %v, want %v", tt.address, tt.limit, got, tt.want)
+		}
+	}
+}
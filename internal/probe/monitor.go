@@ -0,0 +1,158 @@
+package probe
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Monitor probes a set of resources periodically and on demand, keeping
+// the latest result for each
+type Monitor struct {
+	prober  *Prober
+	trigger chan struct{}
+	wake    chan struct{}
+
+	mu          sync.Mutex
+	targets     []string
+	interval    time.Duration
+	results     map[string]Result
+	subscribers []func(map[string]Result)
+}
+
+// NewMonitor creates a monitor that probes with prober. It only probes on
+// Trigger until an interval is set.
+func NewMonitor(prober *Prober) *Monitor {
+	return &Monitor{
+		prober:  prober,
+		trigger: make(chan struct{}, 1),
+		wake:    make(chan struct{}, 1),
+		results: make(map[string]Result),
+	}
+}
+
+// Prober returns the prober used for each round
+func (m *Monitor) Prober() *Prober {
+	return m.prober
+}
+
+// Subscribe registers fn to receive all results after every round
+func (m *Monitor) Subscribe(fn func(map[string]Result)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// SetTargets sets the resource addresses to probe. Results for addresses no
+// longer listed are dropped; new ones are probed in the next round.
+func (m *Monitor) SetTargets(addresses []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.targets = slices.Clone(addresses)
+	maps.DeleteFunc(m.results, func(address string, _ Result) bool {
+		return !slices.Contains(addresses, address)
+	})
+}
+
+// SetInterval sets the time between rounds. Zero probes only on Trigger.
+func (m *Monitor) SetInterval(d time.Duration) {
+	m.mu.Lock()
+	m.interval = d
+	m.mu.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Result returns the latest result for address
+func (m *Monitor) Result(address string) (Result, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.results[address]
+	return r, ok
+}
+
+// Trigger starts a round now. A round already running absorbs it.
+func (m *Monitor) Trigger() {
+	select {
+	case m.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run probes until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	for {
+		m.mu.Lock()
+		interval := m.interval
+		m.mu.Unlock()
+
+		var tick <-chan time.Time
+		var timer *time.Timer
+		if interval > 0 {
+			timer = time.NewTimer(interval)
+			tick = timer.C
+		}
+
+		probe := false
+		select {
+		case <-ctx.Done():
+		case <-m.wake: // Restart the wait with the new interval
+		case <-m.trigger:
+			probe = true
+		case <-tick:
+			probe = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if probe {
+			m.round(ctx)
+		}
+	}
+}
+
+// round probes every target and notifies subscribers
+func (m *Monitor) round(ctx context.Context) {
+	m.mu.Lock()
+	targets := m.targets
+	m.mu.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+
+	start := time.Now()
+	results := m.prober.ProbeAll(ctx, targets)
+	if ctx.Err() != nil {
+		return
+	}
+	reachable := 0
+	for _, r := range results {
+		if r.Status == StatusReachable {
+			reachable++
+		}
+	}
+	log.Printf("Probed %d resources in %s: %d reachable", len(results), time.Since(start).Round(time.Millisecond), reachable)
+
+	m.mu.Lock()
+	for address, r := range results {
+		// Targets may have changed while probing
+		if slices.Contains(m.targets, address) {
+			m.results[address] = r
+		}
+	}
+	snapshot := maps.Clone(m.results)
+	subscribers := slices.Clone(m.subscribers)
+	m.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(snapshot)
+	}
+}
@@ -0,0 +1,254 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds the DNS lookup and the TCP connects of one probe
+const DefaultTimeout = 3 * time.Second
+
+// DefaultPorts are tried when a resource address names no ports
+var DefaultPorts = []int{443, 80, 22}
+
+const (
+	maxPorts    = 8 // Ports tried per resource, even for long ranges
+	concurrency = 8 // Resources probed at once
+)
+
+// Status is the outcome of a probe
+type Status string
+
+const (
+	StatusReachable   Status = "reachable"   // A TCP connect succeeded
+	StatusUnreachable Status = "unreachable" // Every TCP connect failed
+	StatusDNSFailed   Status = "dns_failed"  // The name did not resolve
+	StatusSkipped     Status = "skipped"     // The address cannot be probed
+)
+
+// Result is the health of one resource
+type Result struct {
+	Address   string
+	Status    Status
+	Resolved  []string      // What the name resolved to; empty for IPs
+	DNSTime   time.Duration // Zero for IPs
+	Port      int           // The port that accepted the connection
+	Latency   time.Duration // TCP connect time to Port
+	Error     string        // Why the resource was unreachable or skipped
+	CheckedAt time.Time
+}
+
+// Summary is a short description for menus and tables
+func (r Result) Summary() string {
+	switch r.Status {
+	case StatusReachable:
+		return FormatLatency(r.Latency)
+	case StatusUnreachable:
+		return "unreachable"
+	case StatusDNSFailed:
+		return "DNS failed"
+	default:
+		return "not probed"
+	}
+}
+
+// FormatLatency formats d in whole milliseconds, or below one as "<1 ms"
+func FormatLatency(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1 ms"
+	}
+	return fmt.Sprintf("%d ms", d.Round(time.Millisecond).Milliseconds())
+}
+
+// Prober checks whether resources answer through the tunnel
+type Prober struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	ports   []int
+
+	lookup func(ctx context.Context, host string) ([]string, error)
+	dial   func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewProber creates a prober with the default timeout and ports, using the
+// system resolver
+func NewProber() *Prober {
+	var dialer net.Dialer
+	return &Prober{
+		timeout: DefaultTimeout,
+		ports:   slices.Clone(DefaultPorts),
+		lookup:  net.DefaultResolver.LookupHost,
+		dial:    dialer.DialContext,
+	}
+}
+
+// SetTimeout sets the time limit of each probe
+func (p *Prober) SetTimeout(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = d
+}
+
+// SetPorts sets the ports tried for addresses that name none
+func (p *Prober) SetPorts(ports []int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ports = append([]int(nil), ports...)
+}
+
+func (p *Prober) settings() (time.Duration, []int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.timeout, p.ports
+}
+
+// Probe resolves address and connects to its ports. All ports are tried at
+// once; the first to accept decides the latency.
+func (p *Prober) Probe(ctx context.Context, address string) Result {
+	result := Result{Address: address, Status: StatusSkipped, CheckedAt: time.Now()}
+	timeout, defaults := p.settings()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	target, err := ParseAddress(address)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	host, err := target.SingleHost()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	ports := target.portList(defaults, maxPorts)
+	if len(ports) == 0 {
+		result.Error = "no ports to probe"
+		return result
+	}
+
+	ip := host
+	if target.Kind == KindHost {
+		start := time.Now()
+		addrs, err := p.lookup(ctx, host)
+		result.DNSTime = time.Since(start)
+		if err != nil || len(addrs) == 0 {
+			result.Status = StatusDNSFailed
+			result.Error = dnsError(err)
+			return result
+		}
+		result.Resolved = addrs
+		ip = addrs[0]
+	}
+
+	result.Status = StatusUnreachable
+	result.Port, result.Latency, err = p.connect(ctx, ip, ports)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = StatusReachable
+	return result
+}
+
+// connect dials ip on every port and returns the first that accepts
+func (p *Prober) connect(ctx context.Context, ip string, ports []int) (int, time.Duration, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		port    int
+		latency time.Duration
+		err     error
+	}
+	attempts := make(chan attempt, len(ports))
+	for _, port := range ports {
+		go func() {
+			start := time.Now()
+			conn, err := p.dial(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+			if err == nil {
+				conn.Close()
+			}
+			attempts <- attempt{port, time.Since(start), err}
+		}()
+	}
+
+	var failures []string
+	for range ports {
+		a := <-attempts
+		if a.err == nil {
+			return a.port, a.latency, nil
+		}
+		failures = append(failures, fmt.Sprintf("port %d: %s", a.port, dialError(a.err)))
+	}
+	return 0, 0, errors.New(strings.Join(failures, "; "))
+}
+
+// ProbeAll probes addresses, a few at a time, and returns the results by
+// address
+func (p *Prober) ProbeAll(ctx context.Context, addresses []string) map[string]Result {
+	results := make(map[string]Result, len(addresses))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, concurrency)
+	for _, address := range addresses {
+		mu.Lock()
+		_, dup := results[address]
+		results[address] = Result{}
+		mu.Unlock()
+		if dup {
+			continue
+		}
+
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			r := p.Probe(ctx, address)
+			<-limit
+			mu.Lock()
+			results[address] = r
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// dnsError shortens resolver errors, which repeat the name
+func dnsError(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil:
+		return "no addresses"
+	case errors.As(err, &dnsErr) && dnsErr.IsTimeout:
+		return "DNS timeout"
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "no such host"
+	default:
+		return err.Error()
+	}
+}
+
+// dialError shortens dial errors, which repeat the address
+func dialError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var sysErr *os.SyscallError
+	if errors.As(err, &sysErr) {
+		return sysErr.Err.Error()
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Err != nil {
+		return opErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,188 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeListener accepts TCP connections on a loopback port, standing in for
+// a resource behind the tunnel. Close it to make the resource unreachable.
+type fakeListener struct {
+	ln   net.Listener
+	wg   sync.WaitGroup
+	port int
+}
+
+func newFakeListener(t *testing.T) *fakeListener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake listener: %v", err)
+	}
+	f := &fakeListener{ln: ln, port: ln.Addr().(*net.TCPAddr).Port}
+	f.wg.Add(1)
+	go f.serve()
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// Address returns the listener as a resource address, e.g. "127.0.0.1:40123"
+func (f *fakeListener) Address() string {
+	return fmt.Sprintf("127.0.0.1:%d", f.port)
+}
+
+// Close stops accepting connections
+func (f *fakeListener) Close() {
+	f.ln.Close()
+	f.wg.Wait()
+}
+
+func (f *fakeListener) serve() {
+	defer f.wg.Done()
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}
+}
+
+// closedPort returns a loopback port nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	f := newFakeListener(t)
+	f.Close()
+	return f.port
+}
+
+// newTestProber returns a prober whose resolver knows only the given names
+func newTestProber(hosts map[string][]string) *Prober {
+	p := NewProber()
+	p.SetTimeout(2 * time.Second)
+	p.lookup = func(ctx context.Context, host string) ([]string, error) {
+		if addrs, ok := hosts[host]; ok {
+			return addrs, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return p
+}
+
+func TestProbeReachable(t *testing.T) {
+	resource := newFakeListener(t)
+	p := newTestProber(map[string][]string{"db.internal": {"127.0.0.1"}})
+
+	for _, address := range []string{resource.Address(), fmt.Sprintf("db.internal:%d", resource.port)} {
+		r := p.Probe(context.Background(), address)
+		if r.Status != StatusReachable || r.Port != resource.port || r.Error != "" {
+			t.Errorf("Probe(%q) = %+v, want reachable on %d", address, r, resource.port)
+		}
+	}
+
+	r := p.Probe(context.Background(), fmt.Sprintf("db.internal:%d", resource.port))
+	if !slices.Equal(r.Resolved, []string{"127.0.0.1"}) {
+		t.Errorf("Resolved = %q, want 127.0.0.1", r.Resolved)
+	}
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	resource := newFakeListener(t)
+	address := resource.Address()
+	resource.Close()
+
+	r := newTestProber(nil).Probe(context.Background(), address)
+	if r.Status != StatusUnreachable || r.Error == "" {
+		t.Errorf("Probe(%q) = %+v, want unreachable with a reason", address, r)
+	}
+	if r.Summary() != "unreachable" {
+		t.Errorf("Summary = %q, want unreachable", r.Summary())
+	}
+}
+
+func TestProbeDefaultPorts(t *testing.T) {
+	resource := newFakeListener(t)
+	p := newTestProber(nil)
+	p.SetPorts([]int{closedPort(t), resource.port})
+
+	// The address names no port, so the configured ones are tried
+	r := p.Probe(context.Background(), "127.0.0.1")
+	if r.Status != StatusReachable || r.Port != resource.port {
+		t.Errorf("Probe = %+v, want reachable on %d", r, resource.port)
+	}
+}
+
+func TestProbeSkipsAndDNSFailures(t *testing.T) {
+	tests := []struct {
+		address string
+		status  Status
+		err     string
+	}{
+		{"unknown.internal:22", StatusDNSFailed, "no such host"},
+		{"10.0.0.0/24", StatusSkipped, "10.0.0.0/24 is a network range"},
+		{"*.corp.example", StatusSkipped, "*.corp.example is a wildcard name"},
+		{"db:0", StatusSkipped, `invalid address "db:0": invalid port "0"`},
+	}
+	p := newTestProber(nil)
+	for _, tt := range tests {
+		r := p.Probe(context.Background(), tt.address)
+		if r.Status != tt.status || r.Error != tt.err {
+			t.Errorf("Probe(%q) = %s %q, want %s %q", tt.address, r.Status, r.Error, tt.status, tt.err)
+		}
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	p := newTestProber(nil)
+	p.SetTimeout(20 * time.Millisecond)
+	p.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	r := p.Probe(context.Background(), "10.0.0.5:22")
+	if r.Status != StatusUnreachable || r.Error != "port 22: timeout" {
+		t.Errorf("Probe = %s %q, want unreachable after a timeout", r.Status, r.Error)
+	}
+}
+
+func TestProbeAll(t *testing.T) {
+	up := newFakeListener(t)
+	down := newFakeListener(t)
+	down.Close()
+
+	addresses := []string{up.Address(), down.Address(), up.Address()}
+	results := newTestProber(nil).ProbeAll(context.Background(), addresses)
+	if len(results) != 2 {
+		t.Fatalf("ProbeAll returned %d results, want one per address", len(results))
+	}
+	if results[up.Address()].Status != StatusReachable {
+		t.Errorf("%s = %+v, want reachable", up.Address(), results[up.Address()])
+	}
+	if results[down.Address()].Status != StatusUnreachable {
+		t.Errorf("%s = %+v, want unreachable", down.Address(), results[down.Address()])
+	}
+}
+
+func TestDNSError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "no addresses"},
+		{&net.DNSError{Err: "i/o timeout", IsTimeout: true}, "DNS timeout"},
+		{&net.DNSError{Err: "no such host", IsNotFound: true}, "no such host"},
+		{errors.New("resolver broke"), "resolver broke"},
+	}
+	for _, tt := range tests {
+		if got := dnsError(tt.err); got != tt.want {
+			t.Errorf("dnsError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// systemd D-Bus names
+const (
+	systemdService      = "org.freedesktop.systemd1"
+	systemdPath         = dbus.ObjectPath("/org/freedesktop/systemd1")
+	managerInterface    = "org.freedesktop.systemd1.Manager"
+	unitInterface       = "org.freedesktop.systemd1.Unit"
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+)
+
+// UnitWatcher follows the ActiveState of a systemd unit
+type UnitWatcher struct {
+	conn    *dbus.Conn
+	unit    string
+	path    dbus.ObjectPath
+	signals chan *dbus.Signal
+
+	mu          sync.Mutex
+	state       string
+	subscribers []func(activeState string)
+}
+
+// NewUnitWatcher creates a watcher for unit, e.g. "twingate.service", on
+// conn, normally the system bus
+func NewUnitWatcher(conn *dbus.Conn, unit string) *UnitWatcher {
+	return &UnitWatcher{conn: conn, unit: unit, signals: make(chan *dbus.Signal, 16)}
+}
+
+// Subscribe registers fn to be called when the unit's ActiveState changes.
+// Callbacks run on the watcher's goroutine and must not block.
+func (w *UnitWatcher) Subscribe(fn func(activeState string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// ActiveState returns the last known ActiveState, e.g. "active" or "inactive"
+func (w *UnitWatcher) ActiveState() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state
+}
+
+// Start looks up the unit and follows its ActiveState until ctx is cancelled
+func (w *UnitWatcher) Start(ctx context.Context) error {
+	manager := w.conn.Object(systemdService, systemdPath)
+	if err := manager.Call(managerInterface+".LoadUnit", 0, w.unit).Store(&w.path); err != nil {
+		return fmt.Errorf("failed to look up %s: %w", w.unit, err)
+	}
+
+	// systemd only emits unit signals once a client has subscribed
+	if err := manager.Call(managerInterface+".Subscribe", 0).Err; err != nil {
+		return fmt.Errorf("failed to subscribe to systemd: %w", err)
+	}
+
+	err := w.conn.AddMatchSignal(
+		dbus.WithMatchSender(systemdService),
+		dbus.WithMatchObjectPath(w.path),
+		dbus.WithMatchInterface(propertiesInterface),
+		dbus.WithMatchMember("PropertiesChanged"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", w.unit, err)
+	}
+	w.conn.Signal(w.signals)
+
+	v, err := w.conn.Object(systemdService, w.path).GetProperty(unitInterface + ".ActiveState")
+	if err != nil {
+		w.conn.RemoveSignal(w.signals)
+		return fmt.Errorf("failed to read ActiveState of %s: %w", w.unit, err)
+	}
+	state, _ := v.Value().(string)
+	w.mu.Lock()
+	w.state = state
+	w.mu.Unlock()
+
+	go w.run(ctx)
+	return nil
+}
+
+func (w *UnitWatcher) run(ctx context.Context) {
+	defer w.conn.RemoveSignal(w.signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-w.signals:
+			if !ok {
+				return
+			}
+			if sig.Path != w.path || sig.Name != propertiesInterface+".PropertiesChanged" || len(sig.Body) < 2 {
+				continue
+			}
+			if iface, _ := sig.Body[0].(string); iface != unitInterface {
+				continue
+			}
+			changed, _ := sig.Body[1].(map[string]dbus.Variant)
+			v, ok := changed["ActiveState"]
+			if !ok {
+				continue
+			}
+			state, _ := v.Value().(string)
+			w.update(state)
+		}
+	}
+}
+
+// update stores state and notifies subscribers if it differs from the last one
+func (w *UnitWatcher) update(state string) {
+	w.mu.Lock()
+	if state == w.state {
+		w.mu.Unlock()
+		return
+	}
+	w.state = state
+	subscribers := w.subscribers
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(state)
+	}
+}
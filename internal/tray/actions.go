@@ -0,0 +1,186 @@
+package tray
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ClickAction is what clicking or scrolling on the icon does
+type ClickAction string
+
+// Click actions
+const (
+	ActionNone           ClickAction = "none"
+	ActionMenu           ClickAction = "menu" // Let the panel show the menu; left click only
+	ActionToggle         ClickAction = "toggle"
+	ActionConnectionInfo ClickAction = "connection_info"
+	ActionResources      ClickAction = "resources"
+	ActionCycleExitNodes ClickAction = "cycle_exit_nodes"
+	ActionCommand        ClickAction = "command"
+)
+
+// ParseClickAction parses a click action name
+func ParseClickAction(s string) (ClickAction, error) {
+	switch a := ClickAction(s); a {
+	case ActionNone, ActionMenu, ActionToggle, ActionConnectionInfo, ActionResources, ActionCycleExitNodes, ActionCommand:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown action %q (want menu, toggle, connection_info, resources, cycle_exit_nodes, command or none)", s)
+}
+
+// Binding is the action for one kind of click or scroll
+type Binding struct {
+	Action  ClickAction
+	Command []string // Program and arguments for ActionCommand
+}
+
+// Bindings says what the icon does on left click, middle click and scroll
+type Bindings struct {
+	LeftClick   Binding
+	MiddleClick Binding
+	Scroll      Binding
+}
+
+// DefaultBindings leave clicks to the panel's menu handling
+var DefaultBindings = Bindings{
+	LeftClick:   Binding{Action: ActionMenu},
+	MiddleClick: Binding{Action: ActionNone},
+	Scroll:      Binding{Action: ActionNone},
+}
+
+// SetBindings changes what clicks and scrolling on the icon do. With a left
+// click other than ActionMenu the item stops being menu-only, so panels send
+// Activate and show the menu on right click instead.
+func (st *SystemTray) SetBindings(b Bindings) {
+	st.mu.Lock()
+	wasMenu := st.bindings.LeftClick.Action == ActionMenu
+	st.bindings = b
+	isMenu := b.LeftClick.Action == ActionMenu
+	st.mu.Unlock()
+
+	if wasMenu != isMenu {
+		// Announce the change the standard way for hosts that watch
+		// PropertiesChanged. The StatusNotifierItem spec only has New*
+		// signals for the icons, title, status and tooltip, though, and hosts
+		// such as Plasma only re-read the item's properties, ItemIsMenu
+		// among them, when one of those arrives. NewTitle is the cheapest to
+		// send since the title never changes.
+		st.conn.Emit(st.objectPath, "org.freedesktop.DBus.Properties.PropertiesChanged",
+			"org.kde.StatusNotifierItem", map[string]dbus.Variant{"ItemIsMenu": dbus.MakeVariant(isMenu)}, []string{})
+		st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewTitle")
+	}
+}
+
+// trigger runs binding in the background. step is the direction for
+// cycling exit nodes, and env is added to a command's environment.
+func (st *SystemTray) trigger(name string, binding Binding, step int, env []string) {
+	switch binding.Action {
+	case ActionNone, ActionMenu, "":
+		return
+	}
+	log.Printf("Tray icon %s: %s", name, binding.Action)
+
+	switch binding.Action {
+	case ActionToggle:
+		st.mu.RLock()
+		state := st.state
+		st.mu.RUnlock()
+		if wantsDisconnect(state) {
+			go st.onDisconnect()
+		} else {
+			go st.onConnect()
+		}
+	case ActionConnectionInfo:
+		go st.onConnectionInfo()
+	case ActionResources:
+		if st.onResourcesShow != nil {
+			go st.onResourcesShow()
+		}
+	case ActionCycleExitNodes:
+		st.cycleExitNode(step)
+	case ActionCommand:
+		st.runCommand(binding.Command, env)
+	}
+}
+
+// cycleExitNode switches to the exit node step places after the current
+// one. Events that arrive while a switch is running are dropped, so a burst
+// of scroll events moves one node at a time.
+func (st *SystemTray) cycleExitNode(step int) {
+	if st.onExitNodeSelect == nil || !st.cycling.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer st.cycling.Store(false)
+
+		st.mu.RLock()
+		loaded := st.exitNodesLoaded
+		st.mu.RUnlock()
+		if !loaded && st.onExitNodesOpen != nil {
+			st.onExitNodesOpen()
+		}
+
+		st.mu.RLock()
+		nodes := st.exitNodes
+		current := -1
+		if st.exitNodeEnabled {
+			for i, node := range nodes {
+				if node == st.currentExitNode {
+					current = i
+				}
+			}
+		}
+		st.mu.RUnlock()
+
+		if len(nodes) == 0 {
+			log.Println("No exit nodes to cycle through")
+			return
+		}
+		next := current + step
+		if current < 0 && step < 0 {
+			next = len(nodes) - 1
+		}
+		next = (next%len(nodes) + len(nodes)) % len(nodes)
+		if next == current {
+			return
+		}
+		st.onExitNodeSelect(nodes[next])
+	}()
+}
+
+// runCommand starts a user command with the connection state in its
+// environment
+func (st *SystemTray) runCommand(args []string, env []string) {
+	if len(args) == 0 {
+		return
+	}
+	st.mu.RLock()
+	state := st.state
+	st.mu.RUnlock()
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "TWINGATE_TRAY_STATE="+state.String())
+	cmd.Env = append(cmd.Env, env...)
+	if err := cmd.Start(); err != nil {
+		log.Printf("Failed to run tray command %s: %v", args[0], err)
+		return
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Printf("Tray command %s failed: %v", args[0], err)
+		}
+	}()
+}
+
+// scrollEnv describes a scroll event to a command
+func scrollEnv(delta int32, orientation string) []string {
+	return []string{
+		"TWINGATE_TRAY_SCROLL_DELTA=" + strconv.Itoa(int(delta)),
+		"TWINGATE_TRAY_SCROLL_ORIENTATION=" + orientation,
+	}
+}
@@ -0,0 +1,93 @@
+package tray
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// privateBus starts a dbus-daemon for the test and returns its address
+func privateBus(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the bus address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+func connect(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect to the private bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSetBindingsAnnouncesItemIsMenu(t *testing.T) {
+	address := privateBus(t)
+	st := &SystemTray{conn: connect(t, address), objectPath: "/StatusNotifierItem", bindings: DefaultBindings}
+
+	host := connect(t, address)
+	if err := host.AddMatchSignal(dbus.WithMatchObjectPath(st.objectPath)); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 8)
+	host.Signal(signals)
+	next := func() *dbus.Signal {
+		t.Helper()
+		for {
+			select {
+			case sig := <-signals:
+				if sig.Path == st.objectPath {
+					return sig
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no signal after changing ItemIsMenu")
+				return nil
+			}
+		}
+	}
+
+	st.SetBindings(Bindings{LeftClick: Binding{Action: ActionToggle}})
+
+	sig := next()
+	if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) != 3 {
+		t.Fatalf("first signal = %s %v, want PropertiesChanged", sig.Name, sig.Body)
+	}
+	changed, _ := sig.Body[1].(map[string]dbus.Variant)
+	if sig.Body[0] != "org.kde.StatusNotifierItem" || changed["ItemIsMenu"].Value() != false {
+		t.Errorf("PropertiesChanged %v, want ItemIsMenu false", sig.Body)
+	}
+	if sig := next(); sig.Name != "org.kde.StatusNotifierItem.NewTitle" {
+		t.Errorf("second signal = %s, want NewTitle for hosts that ignore PropertiesChanged", sig.Name)
+	}
+
+	// Rebinding middle click leaves ItemIsMenu alone
+	st.SetBindings(Bindings{LeftClick: Binding{Action: ActionToggle}, MiddleClick: Binding{Action: ActionResources}})
+	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewIcon") // Marker
+	if sig := next(); sig.Name != "org.kde.StatusNotifierItem.NewIcon" {
+		t.Errorf("got %s without a change to ItemIsMenu", sig.Name)
+	}
+}
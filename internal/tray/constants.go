@@ -22,21 +22,59 @@ const (
 	MenuItemAbout          = 18
 	MenuItemSeparator6     = 19
 	MenuItemQuit           = 20
+	MenuItemTraffic        = 21
+	MenuItemSeparator7     = 22
 
 	// Exit node submenu items (100-199)
-	MenuItemExitNodeStart  = 101
-	MenuItemExitNodeStop   = 102
-	MenuItemExitNodeList   = 103
-	MenuItemExitNodeSwitch = 104
+	MenuItemExitNodeStart     = 101
+	MenuItemExitNodeStop      = 102
+	MenuItemExitNodeList      = 103
+	MenuItemExitNodeSwitch    = 104
+	MenuItemExitNodeSeparator = 105
+	MenuItemExitNodeEmpty     = 106
+
+	// Exit node radio items, one per available node at the node's slot (see
+	// idSlots)
+	MenuItemExitNodeFirst = 110
+	MenuItemExitNodeLast  = 199
 
 	// Resources submenu base (200-299 for dynamic resources)
-	MenuItemResourcesBase = 200
+	MenuItemResourcesBase      = 200
+	MenuItemResourcesShowAll   = 201
+	MenuItemResourcesSeparator = 202
+	MenuItemResourcesEmpty     = 203
+	MenuItemResourcesProbe     = 204
+	MenuItemResourcesPin       = 205
+
+	// Resource items, one per resource at the resource's slot
+	MenuItemResourceFirst = 210
+	MenuItemResourceLast  = 299
+
+	// Pin to Menu checkmarks, one per resource at the resource's slot
+	MenuItemResourcePinFirst = 300
+	MenuItemResourcePinLast  = 389
+
+	// Pinned resources in the top-level menu (1000-1999). Each takes the block
+	// of ids at its slot: its submenu, then one per FavoriteAction at that
+	// offset, then its launchers.
+	MenuItemFavoriteFirst = 1000
+	MenuItemFavoriteLast  = 1999
+	MenuItemFavoriteBlock = 20
+
+	// Launcher submenus of resource items (2000-2899), a block at each
+	// resource's slot: its launchers, then Authenticate in the last place
+	MenuItemResourceLaunchFirst = 2000
+	MenuItemResourceLaunchLast  = 2899
+	MenuItemResourceLaunchBlock = 10
 )
 
 // Icon specifications
 const (
-	IconSize      = 256
 	IconPadding   = 0.08 // 8% padding
-	Supersample   = 2    // 2x supersampling for antialiasing
+	Supersample   = 2    // Minimum supersampling for antialiasing
 	ViewBoxAspect = 448.0 / 512.0
 )
+
+// IconSizes are the pixmap sizes sent to the host, which picks the closest
+// one for its panel
+var IconSizes = []int{16, 22, 24, 32, 48, 64, 256}
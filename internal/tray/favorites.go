@@ -0,0 +1,168 @@
+package tray
+
+import (
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// FavoriteAction is an entry in a pinned resource's submenu
+type FavoriteAction int32
+
+// Favorite actions; the value is the item's offset in the favorite's id block
+const (
+	FavoriteCopyAddress  FavoriteAction = 1
+	FavoriteOpenBrowser  FavoriteAction = 2
+	FavoriteOpenSSH      FavoriteAction = 3
+	FavoriteAuthenticate FavoriteAction = 4
+	FavoriteUnpin        FavoriteAction = 5
+
+	favoriteSeparator   FavoriteAction = 8
+	favoriteInfo        FavoriteAction = 9
+	favoriteLaunchFirst FavoriteAction = 10 // The resource's launchers follow
+)
+
+func (a FavoriteAction) String() string {
+	switch a {
+	case FavoriteCopyAddress:
+		return "copy address"
+	case FavoriteOpenBrowser:
+		return "open in browser"
+	case FavoriteOpenSSH:
+		return "open SSH"
+	case FavoriteAuthenticate:
+		return "authenticate"
+	case FavoriteUnpin:
+		return "unpin"
+	default:
+		return "unknown"
+	}
+}
+
+// SetFavorites updates the resource names pinned to the top-level menu
+func (st *SystemTray) SetFavorites(names []string) {
+	names = uniqueBy(names, identity)
+	st.mu.Lock()
+	if slices.Equal(st.favorites, names) {
+		st.mu.Unlock()
+		return
+	}
+	st.favorites = names
+	st.favoriteSlots.assign(names)
+	st.menuRevision++
+	revision := st.menuRevision
+	st.mu.Unlock()
+
+	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(0))
+}
+
+// buildFavoritesMenu returns a submenu per pinned resource. Caller holds st.mu.
+func (st *SystemTray) buildFavoritesMenu() []*menuNode {
+	var items []*menuNode
+	for _, name := range st.favorites {
+		slot, ok := st.favoriteSlots.slot(name)
+		if !ok {
+			continue
+		}
+		base := int32(MenuItemFavoriteFirst + slot*MenuItemFavoriteBlock)
+		id := func(a FavoriteAction) int32 { return base + int32(a) }
+
+		label := name
+		icon := "starred"
+		var children []*menuNode
+		r := slices.IndexFunc(st.resources, func(r ResourceItem) bool { return r.Name == name })
+		switch {
+		case r >= 0:
+			res := st.resources[r]
+			label = resourceLabel(res)
+			browse := actionItem(id(FavoriteOpenBrowser), "Open in Browser")
+			ssh := actionItem(id(FavoriteOpenSSH), "Open SSH in Terminal")
+			if res.Host == "" {
+				// Ranges and wildcards name no host to open
+				browse.props["enabled"] = dbus.MakeVariant(false)
+				ssh.props["enabled"] = dbus.MakeVariant(false)
+			}
+			children = append(children, actionItem(id(FavoriteCopyAddress), "Copy Address"), browse, ssh)
+			if res.Locked {
+				children = append(children, actionItem(id(FavoriteAuthenticate), "Authenticate"))
+				icon = "changes-prevent"
+			}
+			for j, launcher := range res.Launchers {
+				if favoriteLaunchFirst+FavoriteAction(j) >= MenuItemFavoriteBlock {
+					break
+				}
+				children = append(children, actionItem(id(favoriteLaunchFirst+FavoriteAction(j)), launcher))
+			}
+		case st.resourcesLoaded:
+			children = append(children, infoItem(id(favoriteInfo), "Not in the resource list"))
+		default:
+			children = append(children, infoItem(id(favoriteInfo), "Loading…"))
+		}
+		children = append(children,
+			separatorItem(id(favoriteSeparator)),
+			actionItem(id(FavoriteUnpin), "Unpin from Menu"),
+		)
+
+		item := submenuItem(base, label, children)
+		item.props["icon-name"] = dbus.MakeVariant(icon)
+		items = append(items, item)
+	}
+	return items
+}
+
+// buildPinMenu returns the Pin to Menu checkmarks. Caller holds st.mu.
+func (st *SystemTray) buildPinMenu() []*menuNode {
+	var items []*menuNode
+	for _, res := range st.resources {
+		slot, ok := st.resourceSlots.slot(res.Name)
+		if !ok {
+			continue
+		}
+		id := int32(MenuItemResourcePinFirst + slot)
+		item := actionItem(id, res.Name)
+		item.props["toggle-type"] = dbus.MakeVariant("checkmark")
+		toggle := int32(0)
+		if slices.Contains(st.favorites, res.Name) {
+			toggle = 1
+		}
+		item.props["toggle-state"] = dbus.MakeVariant(toggle)
+		items = append(items, item)
+	}
+	return items
+}
+
+// favoriteForID returns the pinned resource and action of menu id, if the
+// resource is still pinned
+func (st *SystemTray) favoriteForID(id int32) (string, FavoriteAction, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	offset := int(id - MenuItemFavoriteFirst)
+	if offset < 0 {
+		return "", 0, false
+	}
+	name, ok := st.favoriteSlots.name(offset / MenuItemFavoriteBlock)
+	if !ok || !slices.Contains(st.favorites, name) {
+		return "", 0, false
+	}
+	return name, FavoriteAction(offset % MenuItemFavoriteBlock), true
+}
+
+// favoriteLauncher returns the launcher shown for action in the submenu of
+// the pinned resource name
+func (st *SystemTray) favoriteLauncher(name string, action FavoriteAction) (string, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	r := slices.IndexFunc(st.resources, func(r ResourceItem) bool { return r.Name == name })
+	j := int(action - favoriteLaunchFirst)
+	if r < 0 || j < 0 || j >= len(st.resources[r].Launchers) {
+		return "", false
+	}
+	return st.resources[r].Launchers[j], true
+}
+
+// isFavorite reports whether the resource is pinned
+func (st *SystemTray) isFavorite(name string) bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return slices.Contains(st.favorites, name)
+}
@@ -1,7 +1,11 @@
 package tray
 
 import (
+	"image/color"
 	"math"
+	"sync"
+
+	"github.com/bisand/twingate-tray/internal/app"
 )
 
 // Font Awesome lock icon polygon data (normalized [0,1] coordinates).
@@ -339,27 +343,160 @@ var faUnlockPolygons = [][][2]float64{
 	},
 }
 
-// generateIconARGBAntialiased creates a Font Awesome lock/unlock icon with
-// 2x supersampling for smoother edges. Returns ARGB data at 256x256.
-// Connected: white closed lock (fa:lock)
-// Disconnected: gray open lock (fa:unlock)
-func generateIconARGBAntialiased(connected bool) ([]byte, int32, int32) {
-	const hiSize = IconSize * Supersample
-	data := make([]byte, IconSize*IconSize*4)
+// iconVariant is the base drawing of the tray icon
+type iconVariant int
+
+const (
+	iconDisconnected iconVariant = iota // Open lock
+	iconConnected                       // Closed lock
+	iconBusy                            // Amber closed lock while a change is in progress
+	iconError                           // Red open lock
+	iconVariants
+)
+
+// iconVariantFor returns the base drawing for a connection state
+func iconVariantFor(state app.ConnectionState) iconVariant {
+	switch state {
+	case app.StateConnected:
+		return iconConnected
+	case app.StateConnecting, app.StateAuthenticating, app.StateDisconnecting:
+		return iconBusy
+	case app.StateError:
+		return iconError
+	default:
+		return iconDisconnected
+	}
+}
+
+// iconBadges is a set of badges drawn on top of the base icon
+type iconBadges uint8
+
+const (
+	badgeExitNode  iconBadges = 1 << iota // Exit node full-tunnel mode, bottom right
+	badgeNeedsAuth                        // Resources need authentication, top right
+)
+
+// iconKey identifies a composited icon
+type iconKey struct {
+	variant iconVariant
+	badges  iconBadges
+}
+
+// status returns the SNI Status for the icon. Errors and resources waiting
+// for authentication ask the host for attention.
+func (k iconKey) status() string {
+	if k.variant == iconError || k.badges&badgeNeedsAuth != 0 {
+		return "NeedsAttention"
+	}
+	return "Active"
+}
+
+var (
+	colorBadgeGlyph = color.RGBA{255, 255, 255, 255}
+	colorExitNode   = color.RGBA{52, 120, 246, 255}
+	colorNeedsAuth  = color.RGBA{253, 126, 20, 255}
+)
+
+// Badge geometry as a fraction of the icon size
+const (
+	badgeRadius = 0.2
+	badgeBorder = 0.04
+	badgeMargin = 0.01
+)
+
+// iconCache renders each base icon, overlay and composite once, at every
+// size in IconSizes. Pixmaps are ARGB in network byte order and must not be
+// modified.
+type iconCache struct {
+	palette iconPalette
+
+	mu        sync.Mutex
+	base      map[iconVariant][]iconPixmap
+	overlay   map[iconBadges][]iconPixmap
+	composite map[iconKey][]iconPixmap
+}
+
+func newIconCache(palette iconPalette) *iconCache {
+	return &iconCache{
+		palette:   palette,
+		base:      make(map[iconVariant][]iconPixmap),
+		overlay:   make(map[iconBadges][]iconPixmap),
+		composite: make(map[iconKey][]iconPixmap),
+	}
+}
+
+// icon returns the base lock for variant
+func (c *iconCache) icon(variant iconVariant) []iconPixmap {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if icon, ok := c.base[variant]; ok {
+		return icon
+	}
+	icon := render(func(dst []byte, size int) {
+		c.drawLock(dst, size, variant)
+	})
+	c.base[variant] = icon
+	return icon
+}
 
-	var polygons [][][2]float64
-	var r, g, b uint8
+// overlayIcon returns transparent pixmaps holding only badges, or an empty
+// list when there are none
+func (c *iconCache) overlayIcon(badges iconBadges) []iconPixmap {
+	if badges == 0 {
+		return []iconPixmap{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if icon, ok := c.overlay[badges]; ok {
+		return icon
+	}
+	icon := render(func(dst []byte, size int) {
+		c.drawBadges(dst, size, badges)
+	})
+	c.overlay[badges] = icon
+	return icon
+}
 
-	if connected {
+// compositeIcon returns the base lock with badges drawn on top, for hosts
+// that show the attention icon in place of icon and overlay
+func (c *iconCache) compositeIcon(key iconKey) []iconPixmap {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if icon, ok := c.composite[key]; ok {
+		return icon
+	}
+	icon := render(func(dst []byte, size int) {
+		c.drawLock(dst, size, key.variant)
+		c.drawBadges(dst, size, key.badges)
+	})
+	c.composite[key] = icon
+	return icon
+}
+
+// render draws an icon at every size in IconSizes
+func render(draw func(dst []byte, size int)) []iconPixmap {
+	icon := make([]iconPixmap, 0, len(IconSizes))
+	for _, size := range IconSizes {
+		data := make([]byte, size*size*4)
+		draw(data, size)
+		icon = append(icon, iconPixmap{Width: int32(size), Height: int32(size), Data: data})
+	}
+	return icon
+}
+
+func (c *iconCache) drawLock(dst []byte, size int, variant iconVariant) {
+	polygons := faUnlockPolygons
+	if variant == iconConnected || variant == iconBusy {
 		polygons = faLockPolygons
-		r, g, b = 255, 255, 255 // White
-	} else {
-		polygons = faUnlockPolygons
-		r, g, b = 140, 140, 140 // Gray
 	}
+	fill(dst, size, c.palette.lock[variant], lockShape(polygons, size))
+}
 
-	padding := float64(hiSize) * IconPadding
-	available := float64(hiSize) - 2*padding
+// lockShape returns an even-odd hit test for the Font Awesome polygons
+// fitted into a size x size icon
+func lockShape(polygons [][][2]float64, size int) func(x, y float64) bool {
+	padding := float64(size) * IconPadding
+	available := float64(size) - 2*padding
 
 	var scaleW, scaleH, offsetX, offsetY float64
 	if ViewBoxAspect < 1.0 {
@@ -376,46 +513,118 @@ func generateIconARGBAntialiased(connected bool) ([]byte, int32, int32) {
 		offsetY = padding + (available-scaleH)/2
 	}
 
-	// Render at high resolution
-	hires := make([]bool, hiSize*hiSize)
-	for y := 0; y < hiSize; y++ {
-		fy := float64(y) + 0.5
-		for x := 0; x < hiSize; x++ {
-			fx := float64(x) + 0.5
-			crossings := 0
-			for _, poly := range polygons {
-				crossings += countCrossings(poly, fx, fy, scaleW, scaleH, offsetX, offsetY)
-			}
-			if crossings%2 == 1 {
-				hires[y*hiSize+x] = true
-			}
+	return func(x, y float64) bool {
+		crossings := 0
+		for _, poly := range polygons {
+			crossings += countCrossings(poly, x, y, scaleW, scaleH, offsetX, offsetY)
 		}
+		return crossings%2 == 1
 	}
+}
+
+// drawBadges paints the badges in badges onto dst
+func (c *iconCache) drawBadges(dst []byte, size int, badges iconBadges) {
+	s := float64(size)
+	r := s * badgeRadius
+	right := s - r - s*(badgeBorder+badgeMargin)
+	top := r + s*(badgeBorder+badgeMargin)
+
+	if badges&badgeExitNode != 0 {
+		c.drawBadge(dst, size, right, s-top, colorExitNode, globeGlyph(r))
+	}
+	if badges&badgeNeedsAuth != 0 {
+		c.drawBadge(dst, size, right, top, colorNeedsAuth, exclamationGlyph(r))
+	}
+}
+
+// drawBadge paints a bordered disc centred on (cx, cy) with a white glyph.
+// glyph takes coordinates relative to the centre.
+func (c *iconCache) drawBadge(dst []byte, size int, cx, cy float64, col color.RGBA, glyph func(x, y float64) bool) {
+	r := float64(size) * badgeRadius
+	border := r + float64(size)*badgeBorder
+	fill(dst, size, c.palette.badgeBorder, disc(cx, cy, border))
+	fill(dst, size, col, disc(cx, cy, r))
+	fill(dst, size, colorBadgeGlyph, func(x, y float64) bool {
+		return glyph(x-cx, y-cy)
+	})
+}
 
-	// Downsample to final size
-	ss2 := float64(Supersample * Supersample)
-	for y := 0; y < IconSize; y++ {
-		for x := 0; x < IconSize; x++ {
+func disc(cx, cy, r float64) func(x, y float64) bool {
+	return func(x, y float64) bool {
+		dx, dy := x-cx, y-cy
+		return dx*dx+dy*dy <= r*r
+	}
+}
+
+// globeGlyph draws a globe outline: a ring with an equator and a meridian
+func globeGlyph(r float64) func(x, y float64) bool {
+	outer, inner := 0.68*r, 0.54*r
+	stroke := 0.07 * r
+	return func(x, y float64) bool {
+		d := math.Hypot(x, y)
+		if d > outer {
+			return false
+		}
+		if d >= inner || math.Abs(y) <= stroke {
+			return true
+		}
+		// Meridian: an ellipse ring half as wide as it is tall
+		e := math.Hypot(x/(0.5*inner), y/inner)
+		return math.Abs(e-1) <= stroke/(0.5*inner)
+	}
+}
+
+// exclamationGlyph draws an exclamation mark
+func exclamationGlyph(r float64) func(x, y float64) bool {
+	half := 0.11 * r
+	return func(x, y float64) bool {
+		if math.Abs(x) <= half && y >= -0.58*r && y <= 0.14*r {
+			return true
+		}
+		dy := y - 0.42*r
+		return x*x+dy*dy <= (1.2*half)*(1.2*half)
+	}
+}
+
+// fill paints col over dst wherever inside reports true, antialiased by
+// supersampling. dst is size x size ARGB; inside takes pixel coordinates.
+func fill(dst []byte, size int, col color.RGBA, inside func(x, y float64) bool) {
+	// Small icons get more samples so thin edges stay smooth
+	ss := max(Supersample, 64/size)
+	samples := float64(ss * ss)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
 			count := 0
-			for dy := 0; dy < Supersample; dy++ {
-				for dx := 0; dx < Supersample; dx++ {
-					if hires[(y*Supersample+dy)*hiSize+(x*Supersample+dx)] {
+			for dy := 0; dy < ss; dy++ {
+				fy := float64(y) + (float64(dy)+0.5)/float64(ss)
+				for dx := 0; dx < ss; dx++ {
+					fx := float64(x) + (float64(dx)+0.5)/float64(ss)
+					if inside(fx, fy) {
 						count++
 					}
 				}
 			}
 			if count > 0 {
-				alpha := uint8(math.Round(255 * float64(count) / ss2))
-				idx := (y*IconSize + x) * 4
-				data[idx] = alpha
-				data[idx+1] = r
-				data[idx+2] = g
-				data[idx+3] = b
+				blend(dst[(y*size+x)*4:], col, float64(count)/samples)
 			}
 		}
 	}
+}
 
-	return data, int32(IconSize), int32(IconSize)
+// blend composites col with coverage alpha over the ARGB pixel px
+func blend(px []byte, col color.RGBA, alpha float64) {
+	dstA := float64(px[0]) / 255
+	outA := alpha + dstA*(1-alpha)
+	if outA == 0 {
+		return
+	}
+	mix := func(src, dst uint8) uint8 {
+		return uint8(math.Round((float64(src)*alpha + float64(dst)*dstA*(1-alpha)) / outA))
+	}
+	px[1] = mix(col.R, px[1])
+	px[2] = mix(col.G, px[2])
+	px[3] = mix(col.B, px[3])
+	px[0] = uint8(math.Round(255 * outA))
 }
 
 // countCrossings counts how many times a ray from (px, py) going right
@@ -0,0 +1,392 @@
+package tray
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/godbus/dbus/v5"
+)
+
+// ResourceItem is a Twingate resource shown in the Resources submenu
+type ResourceItem struct {
+	Name    string
+	Address string
+	Locked  bool
+	Health  string // Latest reachability check, e.g. "12 ms"; empty if never probed
+	Host    string // Single host to open; empty for ranges and wildcards
+
+	Launchers []string // Names of the launchers that open the resource
+}
+
+// menuNode is a DBusMenu item together with its children
+type menuNode struct {
+	id       int32
+	props    map[string]dbus.Variant
+	children []*menuNode
+}
+
+// layout converts the node to the DBusMenu wire format, descending at most
+// depth levels (-1 means unlimited)
+func (n *menuNode) layout(depth int32) menuLayoutItem {
+	item := menuLayoutItem{
+		ID:         n.id,
+		Properties: n.props,
+		Children:   []dbus.Variant{},
+	}
+	if depth == 0 {
+		return item
+	}
+	for _, child := range n.children {
+		item.Children = append(item.Children, dbus.MakeVariant(child.layout(depth-1)))
+	}
+	return item
+}
+
+// find returns the node with the given id, or nil
+func (n *menuNode) find(id int32) *menuNode {
+	if n.id == id {
+		return n
+	}
+	for _, child := range n.children {
+		if found := child.find(id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// flatten collects the properties of the node and all its descendants
+func (n *menuNode) flatten(items map[int32]map[string]dbus.Variant) {
+	items[n.id] = n.props
+	for _, child := range n.children {
+		child.flatten(items)
+	}
+}
+
+// actionItem returns a clickable menu item
+func actionItem(id int32, label string) *menuNode {
+	return &menuNode{id: id, props: map[string]dbus.Variant{
+		"label":   dbus.MakeVariant(label),
+		"enabled": dbus.MakeVariant(true),
+		"visible": dbus.MakeVariant(true),
+	}}
+}
+
+// infoItem returns a disabled, informational menu item
+func infoItem(id int32, label string) *menuNode {
+	return &menuNode{id: id, props: map[string]dbus.Variant{
+		"label":   dbus.MakeVariant(label),
+		"enabled": dbus.MakeVariant(false),
+		"visible": dbus.MakeVariant(true),
+	}}
+}
+
+// separatorItem returns a menu separator
+func separatorItem(id int32) *menuNode {
+	return &menuNode{id: id, props: map[string]dbus.Variant{
+		"type":    dbus.MakeVariant("separator"),
+		"visible": dbus.MakeVariant(true),
+	}}
+}
+
+// submenuItem returns an item that opens a nested menu
+func submenuItem(id int32, label string, children []*menuNode) *menuNode {
+	node := actionItem(id, label)
+	node.props["children-display"] = dbus.MakeVariant("submenu")
+	node.children = children
+	return node
+}
+
+// trafficLabel returns the menu label for the throughput row
+func trafficLabel(traffic string) string {
+	return "Traffic: " + traffic
+}
+
+// wantsDisconnect reports whether the Connect item acts as Disconnect in state
+func wantsDisconnect(state app.ConnectionState) bool {
+	return state == app.StateConnected || state == app.StateConnecting || state == app.StateAuthenticating
+}
+
+// connectItem returns the Connect/Disconnect item for state
+func connectItem(state app.ConnectionState) *menuNode {
+	if wantsDisconnect(state) {
+		return actionItem(MenuItemConnect, "Disconnect")
+	}
+	if state == app.StateDisconnecting {
+		node := actionItem(MenuItemConnect, "Disconnecting…")
+		node.props["enabled"] = dbus.MakeVariant(false)
+		return node
+	}
+	return actionItem(MenuItemConnect, "Connect")
+}
+
+// buildMenu returns the full menu tree for the current tray state
+func (st *SystemTray) buildMenu() *menuNode {
+	st.mu.RLock()
+	state := st.state
+	connected := state == app.StateConnected
+	networkName := st.networkName
+	connectionTime := st.connectionTime
+	traffic := st.traffic
+	autoConnect := st.autoConnect
+	exitNodes := st.buildExitNodeMenu()
+	resources := st.buildResourcesMenu()
+	favorites := st.buildFavoritesMenu()
+	st.mu.RUnlock()
+
+	var children []*menuNode
+
+	// Network info (disabled, informational)
+	if networkName != "" && networkName != "-" {
+		children = append(children, infoItem(MenuItemNetworkInfo, fmt.Sprintf("Network: %s", networkName)))
+	}
+
+	// Connection time (disabled, informational)
+	if connected && connectionTime != "" && connectionTime != "-" {
+		children = append(children, infoItem(MenuItemConnectionTime, fmt.Sprintf("Connected: %s", connectionTime)))
+	}
+
+	// Throughput (disabled, informational)
+	if connected && traffic != "" {
+		children = append(children, infoItem(MenuItemTraffic, trafficLabel(traffic)))
+	}
+
+	// Status (disabled, informational)
+	children = append(children,
+		infoItem(MenuItemStatus, "Status: "+state.Label()),
+		separatorItem(MenuItemSeparator1),
+		connectItem(state),
+	)
+
+	// Pinned resources
+	if len(favorites) > 0 {
+		children = append(children, separatorItem(MenuItemSeparator7))
+		children = append(children, favorites...)
+	}
+
+	children = append(children,
+		separatorItem(MenuItemSeparator2),
+		actionItem(MenuItemRefreshStatus, "Refresh Status"),
+		actionItem(MenuItemConnectionInfo, "Connection Info..."),
+		separatorItem(MenuItemSeparator3),
+		submenuItem(MenuItemExitNode, "Exit Node", exitNodes),
+		submenuItem(MenuItemResources, "Resources", resources),
+		separatorItem(MenuItemSeparator4),
+		actionItem(MenuItemOpenWebAdmin, "Open Web Admin"),
+		actionItem(MenuItemDiagReport, "Diagnostic Report..."),
+	)
+
+	// Auto-connect
+	autoConnectLabel := "Enable Auto-connect on Startup"
+	if autoConnect {
+		autoConnectLabel = "Disable Auto-connect on Startup"
+	}
+	children = append(children,
+		actionItem(MenuItemAutoConnect, autoConnectLabel),
+		separatorItem(MenuItemSeparator5),
+		actionItem(MenuItemAbout, "About"),
+		separatorItem(MenuItemSeparator6),
+		actionItem(MenuItemQuit, "Quit"),
+	)
+
+	// Root menu item
+	return &menuNode{
+		id: 0,
+		props: map[string]dbus.Variant{
+			"children-display": dbus.MakeVariant("submenu"),
+		},
+		children: children,
+	}
+}
+
+// buildExitNodeMenu returns the Exit Node submenu entries. Caller holds st.mu.
+func (st *SystemTray) buildExitNodeMenu() []*menuNode {
+	if !st.exitNodesLoaded {
+		return []*menuNode{infoItem(MenuItemExitNodeEmpty, "Loading…")}
+	}
+	if len(st.exitNodes) == 0 {
+		return []*menuNode{infoItem(MenuItemExitNodeEmpty, "No exit nodes available")}
+	}
+
+	var items []*menuNode
+	if st.exitNodeEnabled {
+		items = append(items, actionItem(MenuItemExitNodeStop, "Stop Exit Node"))
+	} else {
+		items = append(items, actionItem(MenuItemExitNodeStart, "Start Exit Node"))
+	}
+	items = append(items, separatorItem(MenuItemExitNodeSeparator))
+
+	for _, node := range st.exitNodes {
+		slot, ok := st.exitNodeSlots.slot(node)
+		if !ok {
+			continue
+		}
+		id := int32(MenuItemExitNodeFirst + slot)
+		item := actionItem(id, node)
+		item.props["toggle-type"] = dbus.MakeVariant("radio")
+		toggle := int32(0)
+		if st.exitNodeEnabled && node == st.currentExitNode {
+			toggle = 1
+		}
+		item.props["toggle-state"] = dbus.MakeVariant(toggle)
+		items = append(items, item)
+	}
+	return items
+}
+
+// buildResourcesMenu returns the Resources submenu entries. Caller holds st.mu.
+func (st *SystemTray) buildResourcesMenu() []*menuNode {
+	var items []*menuNode
+	if !st.resourcesLoaded {
+		items = append(items, infoItem(MenuItemResourcesEmpty, "Loading…"))
+	} else if len(st.resources) == 0 {
+		items = append(items, infoItem(MenuItemResourcesEmpty, "No resources available"))
+	}
+
+	for _, res := range st.resources {
+		slot, ok := st.resourceSlots.slot(res.Name)
+		if !ok {
+			continue
+		}
+		id := int32(MenuItemResourceFirst + slot)
+		item := actionItem(id, resourceLabel(res))
+		if len(res.Launchers) > 0 {
+			item = submenuItem(id, resourceLabel(res), resourceLaunchMenu(slot, res))
+		}
+		if res.Locked {
+			// Lock indicator; clicking a locked resource authenticates it
+			item.props["icon-name"] = dbus.MakeVariant("changes-prevent")
+		}
+		items = append(items, item)
+	}
+
+	items = append(items,
+		separatorItem(MenuItemResourcesSeparator),
+		actionItem(MenuItemResourcesProbe, "Check Reachability"),
+	)
+	if len(st.resources) > 0 {
+		items = append(items, submenuItem(MenuItemResourcesPin, "Pin to Menu", st.buildPinMenu()))
+	}
+	return append(items, actionItem(MenuItemResourcesShowAll, "Show All Resources..."))
+}
+
+// resourceLaunchMenu returns the launchers of the resource at slot, with
+// Authenticate first while it is locked
+func resourceLaunchMenu(slot int, res ResourceItem) []*menuNode {
+	base := int32(MenuItemResourceLaunchFirst + slot*MenuItemResourceLaunchBlock)
+	var items []*menuNode
+	if res.Locked {
+		items = append(items, actionItem(base+MenuItemResourceLaunchBlock-1, "Authenticate"))
+	}
+	for j, name := range res.Launchers {
+		if j >= MenuItemResourceLaunchBlock-1 {
+			break
+		}
+		items = append(items, actionItem(base+int32(j), name))
+	}
+	return items
+}
+
+// resourceLaunchForID returns the resource and launcher of a launcher
+// submenu item. The launcher is empty for Authenticate.
+func (st *SystemTray) resourceLaunchForID(id int32) (ResourceItem, string, bool) {
+	offset := id - MenuItemResourceLaunchFirst
+	res, ok := st.resourceForID(MenuItemResourceFirst + offset/MenuItemResourceLaunchBlock)
+	j := int(offset % MenuItemResourceLaunchBlock)
+	switch {
+	case !ok:
+		return ResourceItem{}, "", false
+	case j == MenuItemResourceLaunchBlock-1:
+		return res, "", true
+	case j < len(res.Launchers):
+		return res, res.Launchers[j], true
+	}
+	return ResourceItem{}, "", false
+}
+
+// resourceLabel returns the menu label of a resource with its health and lock
+func resourceLabel(res ResourceItem) string {
+	label := fmt.Sprintf("%s (%s)", res.Name, res.Address)
+	if res.Health != "" {
+		label += " - " + res.Health
+	}
+	if res.Locked {
+		label += " - Locked"
+	}
+	return label
+}
+
+// getMenuItems returns the current menu item definitions keyed by id
+func (st *SystemTray) getMenuItems() map[int32]map[string]dbus.Variant {
+	items := make(map[int32]map[string]dbus.Variant)
+	st.buildMenu().flatten(items)
+	return items
+}
+
+// SetExitNodes updates the cached exit node list shown in the Exit Node submenu
+func (st *SystemTray) SetExitNodes(enabled bool, current string, nodes []string) {
+	st.mu.Lock()
+	st.exitNodesLoaded = true
+	st.exitNodeEnabled = enabled
+	st.currentExitNode = current
+	st.exitNodes = uniqueBy(nodes, identity)
+	st.exitNodeSlots.assign(st.exitNodes)
+	st.menuRevision++
+	revision := st.menuRevision
+	st.mu.Unlock()
+
+	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(MenuItemExitNode))
+	st.refreshIcon()
+	st.refreshToolTip()
+}
+
+// SetResources updates the cached resource list shown in the Resources submenu
+func (st *SystemTray) SetResources(resources []ResourceItem) {
+	st.mu.Lock()
+	st.resourcesLoaded = true
+	st.resources = uniqueBy(resources, func(r ResourceItem) string { return r.Name })
+	names := make([]string, len(st.resources))
+	for i, res := range st.resources {
+		names[i] = res.Name
+	}
+	st.resourceSlots.assign(names)
+	st.menuRevision++
+	revision := st.menuRevision
+	// Pinned resources in the top-level menu show the same data
+	parent := int32(MenuItemResources)
+	if len(st.favorites) > 0 {
+		parent = 0
+	}
+	st.mu.Unlock()
+
+	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, parent)
+	st.refreshIcon()
+	st.refreshToolTip()
+}
+
+// exitNodeForID returns the exit node shown at menu id, if it is still listed
+func (st *SystemTray) exitNodeForID(id int32) (string, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	node, ok := st.exitNodeSlots.name(int(id - MenuItemExitNodeFirst))
+	if !ok || !slices.Contains(st.exitNodes, node) {
+		return "", false
+	}
+	return node, true
+}
+
+// resourceForID returns the resource shown at menu id, if it is still listed
+func (st *SystemTray) resourceForID(id int32) (ResourceItem, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	name, ok := st.resourceSlots.name(int(id - MenuItemResourceFirst))
+	if !ok {
+		return ResourceItem{}, false
+	}
+	i := slices.IndexFunc(st.resources, func(r ResourceItem) bool { return r.Name == name })
+	if i < 0 {
+		return ResourceItem{}, false
+	}
+	return st.resources[i], true
+}
@@ -0,0 +1,92 @@
+package tray
+
+// idSlots gives menu items stable ids. Each name keeps its slot, an offset
+// into the item's id range, across refreshes, so a click the host sent
+// before a refresh reaches the item it was meant for rather than whichever
+// item now sits at the same position.
+type idSlots struct {
+	size  int // Slots in the id range
+	slots map[string]int
+}
+
+func newIDSlots(size int) *idSlots {
+	return &idSlots{size: size, slots: make(map[string]int)}
+}
+
+// assign gives every name in names a slot, keeping the slots names already
+// have. Names that left the list keep theirs until the range runs out, so a
+// late click on a removed item is ignored instead of landing on a newcomer.
+// Names that do not fit get no slot.
+func (s *idSlots) assign(names []string) {
+	taken := make(map[int]bool, len(s.slots))
+	for _, slot := range s.slots {
+		taken[slot] = true
+	}
+	free := func() int {
+		for slot := 0; slot < s.size; slot++ {
+			if !taken[slot] {
+				return slot
+			}
+		}
+		return -1
+	}
+
+	reclaimed := false
+	for _, name := range names {
+		if _, ok := s.slots[name]; ok {
+			continue
+		}
+		slot := free()
+		if slot < 0 && !reclaimed {
+			reclaimed = true
+			current := make(map[string]bool, len(names))
+			for _, n := range names {
+				current[n] = true
+			}
+			for n, old := range s.slots {
+				if !current[n] {
+					delete(s.slots, n)
+					delete(taken, old)
+				}
+			}
+			slot = free()
+		}
+		if slot < 0 {
+			continue
+		}
+		s.slots[name] = slot
+		taken[slot] = true
+	}
+}
+
+// slot returns the slot of name
+func (s *idSlots) slot(name string) (int, bool) {
+	slot, ok := s.slots[name]
+	return slot, ok
+}
+
+// name returns the name holding slot
+func (s *idSlots) name(slot int) (string, bool) {
+	for name, held := range s.slots {
+		if held == slot {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// uniqueBy returns a copy of items without those whose key repeats an
+// earlier one, since each name gets a single id
+func uniqueBy[T any](items []T, key func(T) string) []T {
+	seen := make(map[string]bool, len(items))
+	unique := make([]T, 0, len(items))
+	for _, item := range items {
+		if k := key(item); !seen[k] {
+			seen[k] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
+
+func identity(s string) string { return s }
@@ -0,0 +1,145 @@
+package tray
+
+import "testing"
+
+func TestIDSlotsKeepSlots(t *testing.T) {
+	s := newIDSlots(4)
+	s.assign([]string{"a", "b", "c"})
+	before := map[string]int{}
+	for _, name := range []string{"a", "b", "c"} {
+		before[name], _ = s.slot(name)
+	}
+
+	// Reordered, with b gone and d new
+	s.assign([]string{"c", "d", "a"})
+	for _, name := range []string{"a", "c"} {
+		if slot, _ := s.slot(name); slot != before[name] {
+			t.Errorf("%s moved from slot %d to %d", name, before[name], slot)
+		}
+	}
+	if slot, ok := s.slot("d"); !ok || slot == before["b"] {
+		t.Errorf("d got slot %d, %v; want a slot b never held", slot, ok)
+	}
+
+	// The range is full, so b's slot is reclaimed
+	s.assign([]string{"c", "d", "a", "e"})
+	if _, ok := s.slot("b"); ok {
+		t.Error("b kept its slot when the range ran out")
+	}
+	if slot, ok := s.slot("e"); !ok || slot != before["b"] {
+		t.Errorf("e got slot %d, %v; want b's slot %d", slot, ok, before["b"])
+	}
+
+	// No room left
+	s.assign([]string{"a", "c", "d", "e", "f"})
+	if _, ok := s.slot("f"); ok {
+		t.Error("f got a slot beyond the range")
+	}
+	if name, ok := s.name(before["a"]); !ok || name != "a" {
+		t.Errorf("name(%d) = %q, %v; want a", before["a"], name, ok)
+	}
+}
+
+func TestUniqueBy(t *testing.T) {
+	got := uniqueBy([]string{"a", "b", "a", "c", "b"}, identity)
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("uniqueBy = %q, want [a b c]", got)
+	}
+}
+
+// newMenuTestTray returns a tray that can build menus without a bus
+func newMenuTestTray() *SystemTray {
+	return &SystemTray{
+		exitNodeSlots: newIDSlots(MenuItemExitNodeLast - MenuItemExitNodeFirst + 1),
+		resourceSlots: newIDSlots(MenuItemResourceLast - MenuItemResourceFirst + 1),
+		favoriteSlots: newIDSlots((MenuItemFavoriteLast - MenuItemFavoriteFirst + 1) / MenuItemFavoriteBlock),
+	}
+}
+
+// setResources does what SetResources does to the menu data
+func (st *SystemTray) setResources(names ...string) {
+	st.resourcesLoaded = true
+	st.resources = nil
+	for _, name := range names {
+		st.resources = append(st.resources, ResourceItem{Name: name, Address: name + ".internal", Launchers: []string{"ssh"}})
+	}
+	st.resourceSlots.assign(names)
+}
+
+// idOf returns the menu id of the item labelled label, or 0
+func idOf(items []*menuNode, label string) int32 {
+	for _, item := range items {
+		if text, _ := item.props["label"].Value().(string); text == label {
+			return item.id
+		}
+	}
+	return 0
+}
+
+func TestResourceIDsSurviveRefresh(t *testing.T) {
+	st := newMenuTestTray()
+	st.setResources("Database", "Wiki")
+	wiki := idOf(st.buildResourcesMenu(), "Wiki (Wiki.internal)")
+	if wiki == 0 {
+		t.Fatal("Wiki is not in the menu")
+	}
+
+	// A refresh drops Database; a click on Wiki sent before it still opens Wiki
+	st.setResources("Wiki", "Grafana")
+	if id := idOf(st.buildResourcesMenu(), "Wiki (Wiki.internal)"); id != wiki {
+		t.Errorf("Wiki moved from id %d to %d", wiki, id)
+	}
+	if res, ok := st.resourceForID(wiki); !ok || res.Name != "Wiki" {
+		t.Errorf("resourceForID(%d) = %q, %v; want Wiki", wiki, res.Name, ok)
+	}
+	if res, _, ok := st.resourceLaunchForID(MenuItemResourceLaunchFirst + (wiki-MenuItemResourceFirst)*MenuItemResourceLaunchBlock); !ok || res.Name != "Wiki" {
+		t.Errorf("launcher of Wiki resolved to %q, %v", res.Name, ok)
+	}
+
+	// A late click on the removed resource does nothing
+	st.setResources("Database", "Wiki", "Grafana")
+	st.setResources("Wiki", "Grafana")
+	database, _ := st.resourceSlots.slot("Database")
+	if res, ok := st.resourceForID(int32(MenuItemResourceFirst + database)); ok {
+		t.Errorf("click on removed Database opened %q", res.Name)
+	}
+}
+
+func TestFavoriteIDsSurviveUnpin(t *testing.T) {
+	st := newMenuTestTray()
+	st.setResources("Database", "Wiki")
+	st.favorites = []string{"Database", "Wiki"}
+	st.favoriteSlots.assign(st.favorites)
+	wiki := idOf(st.buildFavoritesMenu(), "Wiki (Wiki.internal)")
+
+	st.favorites = []string{"Wiki"}
+	st.favoriteSlots.assign(st.favorites)
+	if id := idOf(st.buildFavoritesMenu(), "Wiki (Wiki.internal)"); id != wiki {
+		t.Errorf("Wiki moved from id %d to %d after unpinning Database", wiki, id)
+	}
+	name, action, ok := st.favoriteForID(wiki + int32(FavoriteCopyAddress))
+	if !ok || name != "Wiki" || action != FavoriteCopyAddress {
+		t.Errorf("favoriteForID = %q, %s, %v; want Wiki, copy address", name, action, ok)
+	}
+	database, _ := st.favoriteSlots.slot("Database")
+	if name, _, ok := st.favoriteForID(int32(MenuItemFavoriteFirst + database*MenuItemFavoriteBlock + int(FavoriteCopyAddress))); ok {
+		t.Errorf("click on unpinned Database reached %q", name)
+	}
+}
+
+func TestExitNodeIDsSurviveRefresh(t *testing.T) {
+	st := newMenuTestTray()
+	st.exitNodesLoaded = true
+	st.exitNodes = []string{"Oslo", "Frankfurt"}
+	st.exitNodeSlots.assign(st.exitNodes)
+	frankfurt := idOf(st.buildExitNodeMenu(), "Frankfurt")
+
+	st.exitNodes = []string{"Frankfurt"}
+	st.exitNodeSlots.assign(st.exitNodes)
+	if node, ok := st.exitNodeForID(frankfurt); !ok || node != "Frankfurt" {
+		t.Errorf("exitNodeForID(%d) = %q, %v; want Frankfurt", frankfurt, node, ok)
+	}
+	if node, ok := st.exitNodeForID(MenuItemExitNodeFirst); ok {
+		t.Errorf("click on removed Oslo switched to %q", node)
+	}
+}
@@ -0,0 +1,104 @@
+package tray
+
+import (
+	"image/color"
+)
+
+// IconStyle controls how the tray icon is drawn. The zero value draws the
+// default icon for a dark panel.
+type IconStyle struct {
+	LightPanel bool // Draw dark locks that stay visible on a light panel
+
+	// Colour overrides per state; a zero colour keeps the panel's default
+	Connected    color.RGBA
+	Disconnected color.RGBA
+	Connecting   color.RGBA // Also used while authenticating and disconnecting
+	Error        color.RGBA
+
+	// ThemeIcons sends the Names as IconName so hosts can use a symbolic
+	// icon from the theme. The pixmaps remain as a fallback.
+	ThemeIcons bool
+	ThemePath  string // Extra directory searched for the icons; empty for none
+	Names      IconNames
+}
+
+// IconNames are the theme icon names per state; empty names use the defaults
+type IconNames struct {
+	Connected    string
+	Disconnected string
+	Connecting   string
+	Error        string
+}
+
+// DefaultIconNames are the icon names sent when none are configured
+var DefaultIconNames = IconNames{
+	Connected:    "twingate-tray-connected-symbolic",
+	Disconnected: "twingate-tray-disconnected-symbolic",
+	Connecting:   "twingate-tray-connecting-symbolic",
+	Error:        "twingate-tray-error-symbolic",
+}
+
+// iconPalette holds the colours the renderer draws with
+type iconPalette struct {
+	lock        [iconVariants]color.RGBA
+	badgeBorder color.RGBA
+}
+
+var (
+	darkPanelPalette = iconPalette{
+		lock: [iconVariants]color.RGBA{
+			iconDisconnected: {140, 140, 140, 255},
+			iconConnected:    {255, 255, 255, 255},
+			iconBusy:         {245, 166, 35, 255},
+			iconError:        {220, 53, 69, 255},
+		},
+		badgeBorder: color.RGBA{32, 32, 32, 255},
+	}
+	lightPanelPalette = iconPalette{
+		lock: [iconVariants]color.RGBA{
+			iconDisconnected: {110, 110, 110, 255},
+			iconConnected:    {33, 33, 33, 255},
+			iconBusy:         {214, 137, 16, 255},
+			iconError:        {200, 35, 51, 255},
+		},
+		badgeBorder: color.RGBA{250, 250, 250, 255},
+	}
+)
+
+// palette returns the colours for the panel with the overrides applied
+func (s IconStyle) palette() iconPalette {
+	p := darkPanelPalette
+	if s.LightPanel {
+		p = lightPanelPalette
+	}
+	overrides := [iconVariants]color.RGBA{
+		iconDisconnected: s.Disconnected,
+		iconConnected:    s.Connected,
+		iconBusy:         s.Connecting,
+		iconError:        s.Error,
+	}
+	for v, c := range overrides {
+		if c != (color.RGBA{}) {
+			p.lock[v] = c
+		}
+	}
+	return p
+}
+
+// iconName returns the theme icon name for variant, or "" when theme icons
+// are off
+func (s IconStyle) iconName(variant iconVariant) string {
+	if !s.ThemeIcons {
+		return ""
+	}
+	names := [iconVariants][2]string{
+		iconDisconnected: {s.Names.Disconnected, DefaultIconNames.Disconnected},
+		iconConnected:    {s.Names.Connected, DefaultIconNames.Connected},
+		iconBusy:         {s.Names.Connecting, DefaultIconNames.Connecting},
+		iconError:        {s.Names.Error, DefaultIconNames.Error},
+	}
+	if name := names[variant][0]; name != "" {
+		return name
+	}
+	return names[variant][1]
+}
@@ -0,0 +1,77 @@
+package tray
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/bisand/twingate-tray/internal/app"
+)
+
+// toolTipLocked returns the tooltip title and its markup body. Caller holds st.mu.
+func (st *SystemTray) toolTipLocked() (title, body string) {
+	title = "Twingate - " + st.state.Label()
+
+	var lines []string
+	add := func(label, value string) {
+		lines = append(lines, "<b>"+label+":</b> "+html.EscapeString(value))
+	}
+
+	if known(st.networkName) {
+		add("Network", st.networkName)
+	}
+	if known(st.user) {
+		add("User", st.user)
+	}
+	if st.state == app.StateConnected {
+		if known(st.connectionTime) {
+			add("Connected for", st.connectionTime)
+		}
+		if st.exitNodeEnabled && st.currentExitNode != "" {
+			add("Exit node", st.currentExitNode)
+		}
+		if st.traffic != "" {
+			add("Traffic", st.traffic)
+		}
+	}
+	if locked := st.lockedResources(); locked > 0 {
+		add("Locked resources", strconv.Itoa(locked))
+	}
+	if st.statusDetail != "" {
+		lines = append(lines, html.EscapeString(st.statusDetail))
+	}
+	if st.lastError != "" {
+		add("Last error", st.lastError)
+	}
+	return title, strings.Join(lines, "<br/>")
+}
+
+// refreshToolTip emits NewToolTip if any field shown in the tooltip changed
+func (st *SystemTray) refreshToolTip() {
+	st.mu.Lock()
+	title, body := st.toolTipLocked()
+	changed := title != st.toolTipTitle || body != st.toolTipBody
+	st.toolTipTitle, st.toolTipBody = title, body
+	st.mu.Unlock()
+
+	if changed {
+		st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewToolTip")
+	}
+}
+
+// known reports whether a display value is set; "-" marks an unknown value
+func known(value string) bool {
+	return value != "" && value != "-"
+}
+
+// lockedResources counts the resources that need authentication. Caller
+// holds st.mu.
+func (st *SystemTray) lockedResources() int {
+	locked := 0
+	for _, res := range st.resources {
+		if res.Locked {
+			locked++
+		}
+	}
+	return locked
+}
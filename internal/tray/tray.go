@@ -5,7 +5,9 @@ import (
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 
+	"github.com/bisand/twingate-tray/internal/app"
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/introspect"
 )
@@ -13,7 +15,7 @@ import (
 // SystemTray manages the system tray icon using D-Bus StatusNotifierItem
 type SystemTray struct {
 	conn             *dbus.Conn
-	connected        bool
+	state            app.ConnectionState
 	onConnect        func()
 	onDisconnect     func()
 	onConnectionInfo func()
@@ -23,6 +25,14 @@ type SystemTray struct {
 	onExitNodeList   func()
 	onExitNodeSwitch func()
 	onResourcesShow  func()
+	onResourcesProbe func()
+	onExitNodeSelect func(string)
+	onResourceSelect func(string)
+	onResourcePin    func(string, bool)
+	onFavorite       func(string, FavoriteAction)
+	onResourceLaunch func(string, string)
+	onExitNodesOpen  func()
+	onResourcesOpen  func()
 	onOpenWebAdmin   func()
 	onDiagReport     func()
 	onAutoConnToggle func(bool)
@@ -36,16 +46,42 @@ type SystemTray struct {
 	menuRevision     uint32
 	registeredString string
 
-	// Cached icon pixmap data (ARGB, network byte order)
-	iconData   []byte
-	iconWidth  int32
-	iconHeight int32
+	// What clicks and scrolling on the icon do
+	bindings Bindings
+	cycling  atomic.Bool // An exit node switch from scrolling is running
+
+	// Rendered icons and the one currently shown
+	iconStyle IconStyle
+	icons     *iconCache
+	iconKey   iconKey
 
 	// Menu state
 	networkName    string
 	networkURL     string
 	connectionTime string
 	autoConnect    bool
+	statusDetail   string // Extra tooltip line, e.g. reconnect progress
+	traffic        string // Throughput and session totals while connected
+	user           string
+	lastError      string
+
+	// Last tooltip emitted, to skip NewToolTip when nothing changed
+	toolTipTitle string
+	toolTipBody  string
+
+	// Submenu data, refreshed when the submenu is about to be shown
+	exitNodesLoaded bool
+	exitNodeEnabled bool
+	currentExitNode string
+	exitNodes       []string
+	resourcesLoaded bool
+	resources       []ResourceItem
+	favorites       []string // Names of the resources pinned to the top-level menu
+
+	// Menu ids of the exit nodes, resources and pinned resources, by name
+	exitNodeSlots *idSlots
+	resourceSlots *idSlots
+	favoriteSlots *idSlots
 }
 
 // CallbackHandlers groups all callback functions for menu actions
@@ -59,6 +95,14 @@ type CallbackHandlers struct {
 	OnExitNodeList     func()
 	OnExitNodeSwitch   func()
 	OnResourcesShow    func()
+	OnResourcesProbe   func()
+	OnExitNodeSelect   func(node string)     // Exit node radio item clicked
+	OnResourceSelect   func(resource string) // Resource item clicked
+	OnExitNodesOpening func()                // Exit Node submenu about to be shown
+	OnResourcesOpening func()                // Resources submenu about to be shown
+	OnResourcePin      func(resource string, pinned bool)
+	OnFavorite         func(resource string, action FavoriteAction)
+	OnResourceLaunch   func(resource, launcher string)
 	OnOpenWebAdmin     func()
 	OnDiagReport       func()
 	OnAutoConnToggle   func(bool)
@@ -77,7 +121,7 @@ func NewSystemTray(handlers CallbackHandlers) (*SystemTray, error) {
 
 	st := &SystemTray{
 		conn:             conn,
-		connected:        false,
+		state:            app.StateDisconnected,
 		onConnect:        handlers.OnConnect,
 		onDisconnect:     handlers.OnDisconnect,
 		onConnectionInfo: handlers.OnConnectionInfo,
@@ -87,6 +131,14 @@ func NewSystemTray(handlers CallbackHandlers) (*SystemTray, error) {
 		onExitNodeList:   handlers.OnExitNodeList,
 		onExitNodeSwitch: handlers.OnExitNodeSwitch,
 		onResourcesShow:  handlers.OnResourcesShow,
+		onResourcesProbe: handlers.OnResourcesProbe,
+		onExitNodeSelect: handlers.OnExitNodeSelect,
+		onResourceSelect: handlers.OnResourceSelect,
+		onResourcePin:    handlers.OnResourcePin,
+		onFavorite:       handlers.OnFavorite,
+		onResourceLaunch: handlers.OnResourceLaunch,
+		onExitNodesOpen:  handlers.OnExitNodesOpening,
+		onResourcesOpen:  handlers.OnResourcesOpening,
 		onOpenWebAdmin:   handlers.OnOpenWebAdmin,
 		onDiagReport:     handlers.OnDiagReport,
 		onAutoConnToggle: handlers.OnAutoConnToggle,
@@ -101,10 +153,13 @@ func NewSystemTray(handlers CallbackHandlers) (*SystemTray, error) {
 		networkURL:       "",
 		connectionTime:   "-",
 		autoConnect:      handlers.InitialAutoConnect,
+		icons:            newIconCache(IconStyle{}.palette()),
+		bindings:         DefaultBindings,
+		exitNodeSlots:    newIDSlots(MenuItemExitNodeLast - MenuItemExitNodeFirst + 1),
+		resourceSlots:    newIDSlots(MenuItemResourceLast - MenuItemResourceFirst + 1),
+		favoriteSlots:    newIDSlots((MenuItemFavoriteLast - MenuItemFavoriteFirst + 1) / MenuItemFavoriteBlock),
 	}
-
-	// Generate initial icon
-	st.iconData, st.iconWidth, st.iconHeight = generateIconARGBAntialiased(false)
+	st.iconKey = iconKey{variant: iconVariantFor(st.state)}
 
 	return st, nil
 }
@@ -135,17 +190,18 @@ func (st *SystemTray) Start() error {
 			"Category":            {Value: "Communications"},
 			"Id":                  {Value: "twingate-indicator"},
 			"Title":               {Value: "Twingate"},
-			"Status":              {Value: "Active"},
-			"IconName":            {Value: ""},
+			"Status":              {Getter: st.getStatusProp},
+			"IconName":            {Getter: st.getIconNameProp},
+			"IconThemePath":       {Getter: st.getIconThemePathProp},
 			"IconPixmap":          {Getter: st.getIconPixmapProp},
 			"OverlayIconName":     {Value: ""},
-			"OverlayIconPixmap":   {Value: []iconPixmap{}},
-			"AttentionIconName":   {Value: ""},
-			"AttentionIconPixmap": {Value: []iconPixmap{}},
+			"OverlayIconPixmap":   {Getter: st.getOverlayIconPixmapProp},
+			"AttentionIconName":   {Getter: st.getAttentionIconNameProp},
+			"AttentionIconPixmap": {Getter: st.getAttentionIconPixmapProp},
 			"AttentionMovieName":  {Value: ""},
 			"ToolTip":             {Getter: st.getToolTipProp},
 			"Menu":                {Value: st.menuPath},
-			"ItemIsMenu":          {Value: true},
+			"ItemIsMenu":          {Getter: st.getItemIsMenuProp},
 			"WindowId":            {Value: int32(0)},
 		},
 	}
@@ -188,6 +244,9 @@ func (st *SystemTray) Start() error {
 					{Name: "NewStatus", Args: []introspect.Arg{
 						{Name: "status", Type: "s"},
 					}},
+					{Name: "NewIconThemePath", Args: []introspect.Arg{
+						{Name: "icon_theme_path", Type: "s"},
+					}},
 				},
 				Properties: []introspect.Property{
 					{Name: "Category", Type: "s", Access: "read"},
@@ -196,6 +255,7 @@ func (st *SystemTray) Start() error {
 					{Name: "Status", Type: "s", Access: "read"},
 					{Name: "WindowId", Type: "i", Access: "read"},
 					{Name: "IconName", Type: "s", Access: "read"},
+					{Name: "IconThemePath", Type: "s", Access: "read"},
 					{Name: "IconPixmap", Type: "a(iiay)", Access: "read"},
 					{Name: "OverlayIconName", Type: "s", Access: "read"},
 					{Name: "OverlayIconPixmap", Type: "a(iiay)", Access: "read"},
@@ -339,15 +399,10 @@ func (st *SystemTray) Start() error {
 	}
 	st.conn.Export(introspect.NewIntrospectable(&menuIntrospect), st.menuPath, "org.freedesktop.DBus.Introspectable")
 
-	// Register with StatusNotifierWatcher
-	// The SNI spec says to pass the bus name for registration
-	st.registeredString = st.serviceName + string(st.objectPath)
-	watcher := st.conn.Object("org.kde.StatusNotifierWatcher", "/StatusNotifierWatcher")
-	call := watcher.Call("org.kde.StatusNotifierWatcher.RegisterStatusNotifierItem", 0, st.serviceName)
-	if call.Err != nil {
-		log.Printf("Warning: Could not register with StatusNotifierWatcher: %v", call.Err)
-	} else {
-		log.Printf("Registered with StatusNotifierWatcher at path: %s", st.registeredString)
+	// Register with StatusNotifierWatcher, now and whenever the panel restarts
+	if err := st.watchHost(); err != nil {
+		log.Printf("Warning: %v - the tray icon will not come back if the panel restarts", err)
+		st.register()
 	}
 
 	log.Println("System tray initialized")
@@ -361,32 +416,109 @@ type iconPixmap struct {
 	Data   []byte
 }
 
-// UpdateStatus updates the tray icon and emits signals when connection state changes
-func (st *SystemTray) UpdateStatus(connected bool) {
+// UpdateState updates the tray icon, tooltip and menu when the connection state changes
+func (st *SystemTray) UpdateState(state app.ConnectionState) {
 	st.mu.Lock()
-	prevConnected := st.connected
-	if prevConnected == connected {
+	prevState := st.state
+	if prevState == state {
 		st.mu.Unlock()
 		return
 	}
-	st.connected = connected
-	st.iconData, st.iconWidth, st.iconHeight = generateIconARGBAntialiased(connected)
+	st.state = state
 	st.menuRevision++
 	revision := st.menuRevision
 	st.mu.Unlock()
 
-	// Emit D-Bus signals for icon change
-	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewIcon")
-	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewToolTip")
+	st.refreshIcon()
+	st.refreshToolTip()
 
 	// Emit menu layout changed
 	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(0))
 
-	tooltip := "Disconnected from Twingate"
-	if connected {
-		tooltip = "Connected to Twingate"
+	log.Printf("Tray status updated: %s", state.Label())
+}
+
+// refreshIcon recomputes the icon from the state, exit node and resources
+// and emits the signals for whatever changed
+func (st *SystemTray) refreshIcon() {
+	st.mu.Lock()
+	key := iconKey{variant: iconVariantFor(st.state)}
+	if st.state == app.StateConnected {
+		if st.exitNodeEnabled {
+			key.badges |= badgeExitNode
+		}
+		if st.lockedResources() > 0 {
+			key.badges |= badgeNeedsAuth
+		}
+	}
+	prev := st.iconKey
+	st.iconKey = key
+	st.mu.Unlock()
+
+	if key == prev {
+		return
+	}
+	if key.variant != prev.variant {
+		st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewIcon")
+	}
+	if key.badges != prev.badges {
+		st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewOverlayIcon")
+	}
+	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewAttentionIcon")
+	if key.status() != prev.status() {
+		st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewStatus", key.status())
 	}
-	log.Printf("Tray status updated: %s", tooltip)
+}
+
+// SetIconStyle changes the icon colours and theme icons, redrawing the icon
+func (st *SystemTray) SetIconStyle(style IconStyle) {
+	st.mu.Lock()
+	if style == st.iconStyle {
+		st.mu.Unlock()
+		return
+	}
+	themePathChanged := style.ThemePath != st.iconStyle.ThemePath
+	st.iconStyle = style
+	st.icons = newIconCache(style.palette())
+	st.mu.Unlock()
+
+	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewIcon")
+	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewOverlayIcon")
+	st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewAttentionIcon")
+	if themePathChanged {
+		st.conn.Emit(st.objectPath, "org.kde.StatusNotifierItem.NewIconThemePath", style.ThemePath)
+	}
+}
+
+// SetStatusDetail sets an extra tooltip line, or clears it when detail is empty
+func (st *SystemTray) SetStatusDetail(detail string) {
+	st.mu.Lock()
+	if st.statusDetail == detail {
+		st.mu.Unlock()
+		return
+	}
+	st.statusDetail = detail
+	st.mu.Unlock()
+
+	st.refreshToolTip()
+}
+
+// SetUser sets the account shown in the tooltip
+func (st *SystemTray) SetUser(user string) {
+	st.mu.Lock()
+	st.user = user
+	st.mu.Unlock()
+
+	st.refreshToolTip()
+}
+
+// SetLastError sets the error shown in the tooltip, or clears it when err is empty
+func (st *SystemTray) SetLastError(err string) {
+	st.mu.Lock()
+	st.lastError = err
+	st.mu.Unlock()
+
+	st.refreshToolTip()
 }
 
 // UpdateNetworkInfo updates the network name and URL displayed in the menu
@@ -400,6 +532,7 @@ func (st *SystemTray) UpdateNetworkInfo(name, url string) {
 
 	// Emit menu layout changed
 	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(0))
+	st.refreshToolTip()
 }
 
 // UpdateConnectionTime updates the connection time displayed in the menu
@@ -412,6 +545,39 @@ func (st *SystemTray) UpdateConnectionTime(timeStr string) {
 
 	// Emit menu layout changed
 	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(0))
+	st.refreshToolTip()
+}
+
+// SetTraffic updates the throughput shown in the menu and tooltip, or hides
+// it when traffic is empty
+func (st *SystemTray) SetTraffic(traffic string) {
+	st.mu.Lock()
+	if st.traffic == traffic {
+		st.mu.Unlock()
+		return
+	}
+	relayout := (st.traffic == "") != (traffic == "")
+	st.traffic = traffic
+	if relayout {
+		st.menuRevision++
+	}
+	revision := st.menuRevision
+	st.mu.Unlock()
+
+	st.refreshToolTip()
+	if relayout {
+		st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(0))
+		return
+	}
+	// Only the label changed; avoid making the host re-read the whole menu
+	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.ItemsPropertiesUpdated",
+		[]groupPropertyItem{{ID: MenuItemTraffic, Properties: map[string]dbus.Variant{
+			"label": dbus.MakeVariant(trafficLabel(traffic)),
+		}}},
+		[]struct {
+			ID    int32
+			Names []string
+		}{})
 }
 
 // SetAutoConnect updates the auto-connect setting
@@ -437,9 +603,15 @@ func (st *SystemTray) RefreshMenu() {
 	st.conn.Emit(st.menuPath, "com.canonical.dbusmenu.LayoutUpdated", revision, int32(0))
 }
 
+// Conn returns the session bus connection used by the tray so other
+// components can share it
+func (st *SystemTray) Conn() *dbus.Conn {
+	return st.conn
+}
+
 // Stop removes the system tray item
 func (st *SystemTray) Stop() {
-	if st.registeredString != "" {
+	if st.Registered() {
 		log.Println("Unregistering from StatusNotifierWatcher")
 	}
 	if st.conn != nil {
@@ -458,16 +630,28 @@ func (st *SystemTray) Id() (string, *dbus.Error) {
 }
 
 func (st *SystemTray) Status() (string, *dbus.Error) {
-	return "Active", nil
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.iconKey.status(), nil
 }
 
 func (st *SystemTray) Category() (string, *dbus.Error) {
 	return "Communications", nil
 }
 
-// IconName returns empty string to force the use of IconPixmap
+// IconName returns the theme icon for the state, or an empty string to
+// make the host use IconPixmap
 func (st *SystemTray) IconName() (string, *dbus.Error) {
-	return "", nil
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.iconStyle.iconName(st.iconKey.variant), nil
+}
+
+// IconThemePath returns an extra directory for the host to look up IconName in
+func (st *SystemTray) IconThemePath() (string, *dbus.Error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.iconStyle.ThemePath, nil
 }
 
 // IconPixmap returns the icon as pixmap data in the SNI format: a(iiay)
@@ -475,23 +659,21 @@ func (st *SystemTray) IconPixmap() ([]iconPixmap, *dbus.Error) {
 	st.mu.RLock()
 	defer st.mu.RUnlock()
 
-	return []iconPixmap{
-		{
-			Width:  st.iconWidth,
-			Height: st.iconHeight,
-			Data:   st.iconData,
-		},
-	}, nil
+	return st.icons.icon(st.iconKey.variant), nil
+}
+
+// OverlayIconPixmap returns the badges drawn over the icon, if any
+func (st *SystemTray) OverlayIconPixmap() ([]iconPixmap, *dbus.Error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.icons.overlayIcon(st.iconKey.badges), nil
 }
 
 func (st *SystemTray) ToolTip() (interface{}, *dbus.Error) {
 	st.mu.RLock()
 	defer st.mu.RUnlock()
 
-	tooltip := "Twingate - Disconnected"
-	if st.connected {
-		tooltip = "Twingate - Connected"
-	}
+	title, body := st.toolTipLocked()
 
 	// ToolTip type: (sa(iiay)ss) = (icon_name, icon_pixmap[], title, description)
 	return struct {
@@ -502,8 +684,8 @@ func (st *SystemTray) ToolTip() (interface{}, *dbus.Error) {
 	}{
 		IconName: "",
 		Pixmaps:  []iconPixmap{},
-		Title:    "Twingate",
-		Desc:     tooltip,
+		Title:    title,
+		Desc:     body,
 	}, nil
 }
 
@@ -511,19 +693,31 @@ func (st *SystemTray) Menu() (dbus.ObjectPath, *dbus.Error) {
 	return st.menuPath, nil
 }
 
+// ItemIsMenu is true while left click is bound to the menu, so the panel
+// shows the DBusMenu instead of calling Activate
 func (st *SystemTray) ItemIsMenu() (bool, *dbus.Error) {
-	return true, nil
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.bindings.LeftClick.Action == ActionMenu, nil
 }
 
-// Activate handles left-click - with ItemIsMenu=true, the panel should show
-// the DBusMenu directly, but some panels still call Activate
+// Activate handles left-click. Some panels call it even with ItemIsMenu=true,
+// so the menu binding does nothing here.
 func (st *SystemTray) Activate(x int32, y int32) *dbus.Error {
 	log.Println("Tray icon activated (left-click)")
+	st.mu.RLock()
+	binding := st.bindings.LeftClick
+	st.mu.RUnlock()
+	st.trigger("left-click", binding, 1, nil)
 	return nil
 }
 
 func (st *SystemTray) SecondaryActivate(x int32, y int32) *dbus.Error {
 	log.Println("Tray icon secondary activated (middle-click)")
+	st.mu.RLock()
+	binding := st.bindings.MiddleClick
+	st.mu.RUnlock()
+	st.trigger("middle-click", binding, 1, nil)
 	return nil
 }
 
@@ -532,7 +726,20 @@ func (st *SystemTray) ContextMenu(x int32, y int32) *dbus.Error {
 	return nil
 }
 
+// Scroll handles the mouse wheel. Scrolling up (positive delta) cycles
+// exit nodes backwards.
 func (st *SystemTray) Scroll(delta int32, orientation string) *dbus.Error {
+	if delta == 0 {
+		return nil
+	}
+	st.mu.RLock()
+	binding := st.bindings.Scroll
+	st.mu.RUnlock()
+	step := 1
+	if delta > 0 {
+		step = -1
+	}
+	st.trigger("scroll", binding, step, scrollEnv(delta, orientation))
 	return nil
 }
 
@@ -549,11 +756,15 @@ func (st *SystemTray) XAyatanaOrderingIndex() (uint32, *dbus.Error) {
 }
 
 func (st *SystemTray) AttentionIconName() (string, *dbus.Error) {
-	return "", nil
+	return st.IconName()
 }
 
+// AttentionIconPixmap returns the icon with its badges composited, shown by
+// hosts in place of the icon while Status is NeedsAttention
 func (st *SystemTray) AttentionIconPixmap() ([]iconPixmap, *dbus.Error) {
-	return []iconPixmap{}, nil
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.icons.compositeIcon(st.iconKey), nil
 }
 
 func (st *SystemTray) AttentionMovieName() (string, *dbus.Error) {
@@ -573,340 +784,20 @@ type menuLayoutItem struct {
 	Children   []dbus.Variant
 }
 
-// makeMenuItem creates a menuLayoutItem wrapped in a dbus.Variant
-func makeMenuItem(id int32, props map[string]dbus.Variant) dbus.Variant {
-	return dbus.MakeVariant(menuLayoutItem{
-		ID:         id,
-		Properties: props,
-		Children:   []dbus.Variant{},
-	})
-}
-
-// getMenuItems returns the current menu item definitions
-func (st *SystemTray) getMenuItems() map[int32]map[string]dbus.Variant {
-	st.mu.RLock()
-	connected := st.connected
-	networkName := st.networkName
-	connectionTime := st.connectionTime
-	autoConnect := st.autoConnect
-	st.mu.RUnlock()
-
-	items := make(map[int32]map[string]dbus.Variant)
-
-	// Root item
-	items[0] = map[string]dbus.Variant{
-		"children-display": dbus.MakeVariant("submenu"),
-	}
-
-	// Connect or Disconnect
-	if connected {
-		items[MenuItemConnect] = map[string]dbus.Variant{
-			"label":   dbus.MakeVariant("Disconnect"),
-			"enabled": dbus.MakeVariant(true),
-			"visible": dbus.MakeVariant(true),
-		}
-	} else {
-		items[MenuItemConnect] = map[string]dbus.Variant{
-			"label":   dbus.MakeVariant("Connect"),
-			"enabled": dbus.MakeVariant(true),
-			"visible": dbus.MakeVariant(true),
-		}
-	}
-
-	// Separator
-	items[MenuItemSeparator1] = map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Network Info
-	if networkName != "" && networkName != "-" {
-		items[MenuItemNetworkInfo] = map[string]dbus.Variant{
-			"label":   dbus.MakeVariant(fmt.Sprintf("Network: %s", networkName)),
-			"enabled": dbus.MakeVariant(false),
-			"visible": dbus.MakeVariant(true),
-		}
-	}
-
-	// Connection Time
-	if connected && connectionTime != "" && connectionTime != "-" {
-		items[MenuItemConnectionTime] = map[string]dbus.Variant{
-			"label":   dbus.MakeVariant(fmt.Sprintf("Connected: %s", connectionTime)),
-			"enabled": dbus.MakeVariant(false),
-			"visible": dbus.MakeVariant(true),
-		}
-	}
-
-	// Status
-	statusText := "Status: Disconnected"
-	if connected {
-		statusText = "Status: Connected"
-	}
-	items[MenuItemStatus] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant(statusText),
-		"enabled": dbus.MakeVariant(false),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Separator
-	items[MenuItemSeparator2] = map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Refresh Status
-	items[MenuItemRefreshStatus] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Refresh Status"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Connection Info
-	items[MenuItemConnectionInfo] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Connection Info..."),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Separator
-	items[MenuItemSeparator3] = map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Exit Node
-	items[MenuItemExitNode] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Exit Node"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Resources
-	items[MenuItemResources] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Resources..."),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Separator
-	items[MenuItemSeparator4] = map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Open Web Admin
-	items[MenuItemOpenWebAdmin] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Open Web Admin"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Diagnostic Report
-	items[MenuItemDiagReport] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Diagnostic Report..."),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Auto-connect
-	autoConnectLabel := "Enable Auto-connect on Startup"
-	if autoConnect {
-		autoConnectLabel = "Disable Auto-connect on Startup"
-	}
-	items[MenuItemAutoConnect] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant(autoConnectLabel),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Separator
-	items[MenuItemSeparator5] = map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	// Quit
-	items[MenuItemQuit] = map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Quit"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}
-
-	return items
-}
-
-// GetLayout returns the menu layout tree
+// GetLayout returns the menu layout tree below parentId
 func (st *SystemTray) GetLayout(parentId int32, recursionDepth int32, propertyNames []string) (uint32, menuLayoutItem, *dbus.Error) {
 	st.mu.RLock()
-	connected := st.connected
 	revision := st.menuRevision
-	networkName := st.networkName
-	connectionTime := st.connectionTime
-	autoConnect := st.autoConnect
 	st.mu.RUnlock()
 
 	log.Printf("GetLayout called: parentId=%d, depth=%d, props=%v", parentId, recursionDepth, propertyNames)
 
-	// Build menu items as children of root
-	var children []dbus.Variant
-
-	// Network info (disabled, informational)
-	if networkName != "" && networkName != "-" {
-		children = append(children, makeMenuItem(MenuItemNetworkInfo, map[string]dbus.Variant{
-			"label":   dbus.MakeVariant(fmt.Sprintf("Network: %s", networkName)),
-			"enabled": dbus.MakeVariant(false),
-			"visible": dbus.MakeVariant(true),
-		}))
-	}
-
-	// Connection time (disabled, informational)
-	if connected && connectionTime != "" && connectionTime != "-" {
-		children = append(children, makeMenuItem(MenuItemConnectionTime, map[string]dbus.Variant{
-			"label":   dbus.MakeVariant(fmt.Sprintf("Connected: %s", connectionTime)),
-			"enabled": dbus.MakeVariant(false),
-			"visible": dbus.MakeVariant(true),
-		}))
-	}
-
-	// Status (disabled, informational)
-	statusText := "Status: Disconnected"
-	if connected {
-		statusText = "Status: Connected"
-	}
-	children = append(children, makeMenuItem(MenuItemStatus, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant(statusText),
-		"enabled": dbus.MakeVariant(false),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Separator
-	children = append(children, makeMenuItem(MenuItemSeparator1, map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Connect or Disconnect
-	if connected {
-		children = append(children, makeMenuItem(MenuItemConnect, map[string]dbus.Variant{
-			"label":   dbus.MakeVariant("Disconnect"),
-			"enabled": dbus.MakeVariant(true),
-			"visible": dbus.MakeVariant(true),
-		}))
-	} else {
-		children = append(children, makeMenuItem(MenuItemConnect, map[string]dbus.Variant{
-			"label":   dbus.MakeVariant("Connect"),
-			"enabled": dbus.MakeVariant(true),
-			"visible": dbus.MakeVariant(true),
-		}))
-	}
-
-	// Separator
-	children = append(children, makeMenuItem(MenuItemSeparator2, map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Refresh Status
-	children = append(children, makeMenuItem(MenuItemRefreshStatus, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Refresh Status"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Connection Info
-	children = append(children, makeMenuItem(MenuItemConnectionInfo, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Connection Info..."),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Separator
-	children = append(children, makeMenuItem(MenuItemSeparator3, map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Exit Node submenu
-	children = append(children, makeMenuItem(MenuItemExitNode, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Exit Node"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Resources submenu
-	children = append(children, makeMenuItem(MenuItemResources, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Resources..."),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Separator
-	children = append(children, makeMenuItem(MenuItemSeparator4, map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Open Web Admin
-	children = append(children, makeMenuItem(MenuItemOpenWebAdmin, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Open Web Admin"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Generate Diagnostic Report
-	children = append(children, makeMenuItem(MenuItemDiagReport, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Diagnostic Report..."),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Auto-connect
-	autoConnectLabel := "Enable Auto-connect on Startup"
-	if autoConnect {
-		autoConnectLabel = "Disable Auto-connect on Startup"
-	}
-	children = append(children, makeMenuItem(MenuItemAutoConnect, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant(autoConnectLabel),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Separator
-	children = append(children, makeMenuItem(MenuItemSeparator5, map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// About
-	children = append(children, makeMenuItem(MenuItemAbout, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("About"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Separator
-	children = append(children, makeMenuItem(MenuItemSeparator6, map[string]dbus.Variant{
-		"type":    dbus.MakeVariant("separator"),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Quit
-	children = append(children, makeMenuItem(MenuItemQuit, map[string]dbus.Variant{
-		"label":   dbus.MakeVariant("Quit"),
-		"enabled": dbus.MakeVariant(true),
-		"visible": dbus.MakeVariant(true),
-	}))
-
-	// Root menu item
-	root := menuLayoutItem{
-		ID: 0,
-		Properties: map[string]dbus.Variant{
-			"children-display": dbus.MakeVariant("submenu"),
-		},
-		Children: children,
+	node := st.buildMenu().find(parentId)
+	if node == nil {
+		return revision, menuLayoutItem{}, dbus.MakeFailedError(fmt.Errorf("menu item %d not found", parentId))
 	}
 
-	return revision, root, nil
+	return revision, node.layout(recursionDepth), nil
 }
 
 // Event handles menu item clicks
@@ -920,10 +811,10 @@ func (st *SystemTray) Event(id int32, eventId string, data dbus.Variant, timesta
 	switch id {
 	case MenuItemConnect: // Connect/Disconnect
 		st.mu.RLock()
-		connected := st.connected
+		state := st.state
 		st.mu.RUnlock()
 
-		if connected {
+		if wantsDisconnect(state) {
 			log.Println("Menu: Disconnect clicked")
 			go st.onDisconnect()
 		} else {
@@ -941,18 +832,30 @@ func (st *SystemTray) Event(id int32, eventId string, data dbus.Variant, timesta
 		log.Println("Menu: Connection Info clicked")
 		go st.onConnectionInfo()
 
-	case MenuItemExitNode: // Exit Node submenu - show list dialog
-		log.Println("Menu: Exit Node clicked")
-		if st.onExitNodeList != nil {
-			go st.onExitNodeList()
+	case MenuItemExitNodeStart: // Exit Node submenu - start
+		log.Println("Menu: Start Exit Node clicked")
+		if st.onExitNodeStart != nil {
+			go st.onExitNodeStart()
+		}
+
+	case MenuItemExitNodeStop: // Exit Node submenu - stop
+		log.Println("Menu: Stop Exit Node clicked")
+		if st.onExitNodeStop != nil {
+			go st.onExitNodeStop()
 		}
 
-	case MenuItemResources: // Resources
-		log.Println("Menu: Resources clicked")
+	case MenuItemResourcesShowAll: // Resources submenu - full list dialog
+		log.Println("Menu: Show All Resources clicked")
 		if st.onResourcesShow != nil {
 			go st.onResourcesShow()
 		}
 
+	case MenuItemResourcesProbe: // Resources submenu - reachability check
+		log.Println("Menu: Check Reachability clicked")
+		if st.onResourcesProbe != nil {
+			go st.onResourcesProbe()
+		}
+
 	case MenuItemOpenWebAdmin: // Open Web Admin
 		log.Println("Menu: Open Web Admin clicked")
 		if st.onOpenWebAdmin != nil {
@@ -987,6 +890,53 @@ func (st *SystemTray) Event(id int32, eventId string, data dbus.Variant, timesta
 	case MenuItemQuit: // Quit
 		log.Println("Menu: Quit clicked")
 		go st.onQuit()
+
+	default:
+		if id >= MenuItemExitNodeFirst && id <= MenuItemExitNodeLast {
+			if node, ok := st.exitNodeForID(id); ok && st.onExitNodeSelect != nil {
+				log.Printf("Menu: Exit node %s selected", node)
+				go st.onExitNodeSelect(node)
+			}
+		} else if id >= MenuItemResourceFirst && id <= MenuItemResourceLast {
+			if res, ok := st.resourceForID(id); ok && st.onResourceSelect != nil {
+				log.Printf("Menu: Resource %s selected", res.Name)
+				go st.onResourceSelect(res.Name)
+			}
+		} else if id >= MenuItemResourceLaunchFirst && id <= MenuItemResourceLaunchLast {
+			res, launcher, ok := st.resourceLaunchForID(id)
+			switch {
+			case !ok:
+			case launcher == "" && st.onResourceSelect != nil:
+				log.Printf("Menu: Resource %s selected", res.Name)
+				go st.onResourceSelect(res.Name)
+			case launcher != "" && st.onResourceLaunch != nil:
+				log.Printf("Menu: Resource %s: launch %s", res.Name, launcher)
+				go st.onResourceLaunch(res.Name, launcher)
+			}
+		} else if id >= MenuItemResourcePinFirst && id <= MenuItemResourcePinLast {
+			res, ok := st.resourceForID(id - MenuItemResourcePinFirst + MenuItemResourceFirst)
+			if ok && st.onResourcePin != nil {
+				pinned := !st.isFavorite(res.Name)
+				log.Printf("Menu: Resource %s pinned: %v", res.Name, pinned)
+				go st.onResourcePin(res.Name, pinned)
+			}
+		} else if id >= MenuItemFavoriteFirst && id <= MenuItemFavoriteLast {
+			name, action, ok := st.favoriteForID(id)
+			switch {
+			case !ok:
+			case action >= favoriteLaunchFirst:
+				if launcher, found := st.favoriteLauncher(name, action); found && st.onResourceLaunch != nil {
+					log.Printf("Menu: Pinned resource %s: launch %s", name, launcher)
+					go st.onResourceLaunch(name, launcher)
+				}
+			case action == FavoriteUnpin && st.onResourcePin != nil:
+				log.Printf("Menu: Resource %s unpinned", name)
+				go st.onResourcePin(name, false)
+			case action != FavoriteUnpin && st.onFavorite != nil:
+				log.Printf("Menu: Pinned resource %s: %s", name, action)
+				go st.onFavorite(name, action)
+			}
+		}
 	}
 
 	return nil
@@ -1011,13 +961,28 @@ func (st *SystemTray) Version() (uint32, *dbus.Error) {
 
 func (st *SystemTray) AboutToShow(id int32) (bool, *dbus.Error) {
 	// Trigger menu refresh callback when menu is about to be shown
-	if id == 0 && st.onMenuOpening != nil {
-		go st.onMenuOpening()
+	switch id {
+	case 0:
+		if st.onMenuOpening != nil {
+			go st.onMenuOpening()
+		}
+	case MenuItemExitNode:
+		// Cached entries are shown right away; LayoutUpdated follows the refresh
+		if st.onExitNodesOpen != nil {
+			go st.onExitNodesOpen()
+		}
+	case MenuItemResources:
+		if st.onResourcesOpen != nil {
+			go st.onResourcesOpen()
+		}
 	}
 	return true, nil
 }
 
 func (st *SystemTray) AboutToShowGroup(ids []int32) ([]int32, []int32, *dbus.Error) {
+	for _, id := range ids {
+		st.AboutToShow(id)
+	}
 	return ids, []int32{}, nil
 }
 
@@ -1068,15 +1033,43 @@ func (st *SystemTray) GetGroupProperties(ids []int32, propertyNames []string) ([
 // --- Properties handler ---
 
 func (st *SystemTray) getIconPixmapProp() interface{} {
-	st.mu.RLock()
-	defer st.mu.RUnlock()
-	return []iconPixmap{
-		{
-			Width:  st.iconWidth,
-			Height: st.iconHeight,
-			Data:   st.iconData,
-		},
-	}
+	icon, _ := st.IconPixmap()
+	return icon
+}
+
+func (st *SystemTray) getItemIsMenuProp() interface{} {
+	isMenu, _ := st.ItemIsMenu()
+	return isMenu
+}
+
+func (st *SystemTray) getIconNameProp() interface{} {
+	name, _ := st.IconName()
+	return name
+}
+
+func (st *SystemTray) getAttentionIconNameProp() interface{} {
+	name, _ := st.AttentionIconName()
+	return name
+}
+
+func (st *SystemTray) getIconThemePathProp() interface{} {
+	path, _ := st.IconThemePath()
+	return path
+}
+
+func (st *SystemTray) getOverlayIconPixmapProp() interface{} {
+	icon, _ := st.OverlayIconPixmap()
+	return icon
+}
+
+func (st *SystemTray) getAttentionIconPixmapProp() interface{} {
+	icon, _ := st.AttentionIconPixmap()
+	return icon
+}
+
+func (st *SystemTray) getStatusProp() interface{} {
+	status, _ := st.Status()
+	return status
 }
 
 func (st *SystemTray) getToolTipProp() interface{} {
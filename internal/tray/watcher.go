@@ -0,0 +1,111 @@
+package tray
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// StatusNotifierWatcher D-Bus names
+const (
+	watcherName      = "org.kde.StatusNotifierWatcher"
+	watcherPath      = dbus.ObjectPath("/StatusNotifierWatcher")
+	watcherInterface = "org.kde.StatusNotifierWatcher"
+)
+
+// registerTimeout bounds RegisterStatusNotifierItem so a hung panel cannot
+// block the tray
+const registerTimeout = 5 * time.Second
+
+// watchHost registers the item with the StatusNotifierWatcher and registers
+// again whenever a watcher appears, e.g. after the panel restarts. Without a
+// watcher the tray keeps running with no icon until one shows up.
+func (st *SystemTray) watchHost() error {
+	err := st.conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchObjectPath("/org/freedesktop/DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, watcherName),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", watcherName, err)
+	}
+	signals := make(chan *dbus.Signal, 8)
+	st.conn.Signal(signals)
+	go st.followWatcher(signals)
+
+	var hasOwner bool
+	err = st.conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, watcherName).Store(&hasOwner)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s: %w", watcherName, err)
+	}
+	if hasOwner {
+		st.register()
+	} else {
+		st.watcherGone()
+	}
+	return nil
+}
+
+// followWatcher re-registers when the watcher name gets a new owner. It ends
+// when the connection is closed.
+func (st *SystemTray) followWatcher(signals <-chan *dbus.Signal) {
+	for sig := range signals {
+		if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) < 3 {
+			continue
+		}
+		name, _ := sig.Body[0].(string)
+		newOwner, _ := sig.Body[2].(string)
+		if name != watcherName {
+			continue
+		}
+		if newOwner == "" {
+			st.watcherGone()
+			continue
+		}
+		log.Printf("StatusNotifierWatcher appeared (%s) - registering the tray icon", newOwner)
+		st.register()
+	}
+}
+
+// register announces the item to the current watcher
+func (st *SystemTray) register() {
+	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
+	defer cancel()
+
+	watcher := st.conn.Object(watcherName, watcherPath)
+	// The SNI spec says to pass the bus name for registration
+	call := watcher.CallWithContext(ctx, watcherInterface+".RegisterStatusNotifierItem", 0, st.serviceName)
+	if call.Err != nil {
+		log.Printf("Warning: Could not register with StatusNotifierWatcher: %v", call.Err)
+		return
+	}
+
+	st.mu.Lock()
+	st.registeredString = st.serviceName + string(st.objectPath)
+	registered := st.registeredString
+	st.mu.Unlock()
+	log.Printf("Registered with StatusNotifierWatcher at path: %s", registered)
+}
+
+// watcherGone switches to running without an icon
+func (st *SystemTray) watcherGone() {
+	st.mu.Lock()
+	st.registeredString = ""
+	st.mu.Unlock()
+	log.Printf("No StatusNotifierWatcher on the session bus - running without a tray icon. " +
+		"The control interface and twingate-tray commands keep working, and the icon " +
+		"appears once a panel with a system tray starts.")
+}
+
+// Registered reports whether the item is registered with a watcher, i.e.
+// whether a panel can show the icon
+func (st *SystemTray) Registered() bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.registeredString != ""
+}
@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// ANSI sequences used to take over and restore the terminal
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l" // Alternate screen, cursor hidden
+	leaveAltScreen = "\x1b[?25h\x1b[?1049l"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+	clearBelow     = "\x1b[J"
+)
+
+// keyPollInterval bounds how long suspend waits for the key reader to let go
+// of the terminal
+const keyPollInterval = 100 * time.Millisecond
+
+// terminal is the controlling terminal in raw mode
+type terminal struct {
+	in    *os.File
+	out   *bufio.Writer
+	saved syscall.Termios
+
+	// reading is held by the key reader while it polls, and by suspend for as
+	// long as another program owns the terminal
+	reading sync.Mutex
+}
+
+// openTerminal puts stdin into raw mode and switches to the alternate screen
+func openTerminal() (*terminal, error) {
+	t := &terminal{in: os.Stdin, out: bufio.NewWriterSize(os.Stdout, 64*1024)}
+	if err := ioctl(t.in.Fd(), syscall.TCGETS, unsafe.Pointer(&t.saved)); err != nil {
+		return nil, errors.New("stdin is not a terminal")
+	}
+	if err := t.enterRaw(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// resume gives the terminal back after suspend
+func (t *terminal) resume() error {
+	defer t.reading.Unlock()
+	return t.enterRaw()
+}
+
+// enterRaw enters raw mode and the alternate screen
+func (t *terminal) enterRaw() error {
+	raw := t.saved
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(t.in.Fd(), syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	t.out.WriteString(enterAltScreen)
+	return t.out.Flush()
+}
+
+// suspend restores the terminal so another program can use it. Keys are
+// not read until resume.
+func (t *terminal) suspend() {
+	t.reading.Lock()
+	t.out.WriteString(leaveAltScreen)
+	t.out.Flush()
+	ioctl(t.in.Fd(), syscall.TCSETS, unsafe.Pointer(&t.saved))
+}
+
+// size returns the terminal's columns and rows
+func (t *terminal) size() (int, int) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if err := ioctl(os.Stdout.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil || ws.Col == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// draw replaces the screen with lines
+func (t *terminal) draw(lines []string) {
+	t.out.WriteString(cursorHome)
+	for i, line := range lines {
+		if i > 0 {
+			t.out.WriteString("\r\n")
+		}
+		t.out.WriteString(line)
+		t.out.WriteString(clearLine)
+	}
+	t.out.WriteString(clearBelow)
+	t.out.Flush()
+}
+
+func ioctl(fd uintptr, req uint, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(req), uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// key is a decoded key press
+type key struct {
+	name string // "up", "enter", "esc", ...; empty for printable runes
+	r    rune
+}
+
+// escapeKeys maps the CSI and SS3 sequences of special keys
+var escapeKeys = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[4~": "end", "[5~": "pgup", "[6~": "pgdown",
+	"[3~": "delete", "[Z": "backtab",
+}
+
+// readKeys sends key presses until stdin fails. It only reads while the
+// terminal is not suspended.
+func (t *terminal) readKeys(keys chan<- key) {
+	buf := make([]byte, 256)
+	fd := int(t.in.Fd())
+	for {
+		t.reading.Lock()
+		ready, err := waitReadable(fd, keyPollInterval)
+		n := 0
+		if err == nil && ready {
+			n, err = t.in.Read(buf)
+		}
+		t.reading.Unlock()
+		if err != nil {
+			close(keys)
+			return
+		}
+		for _, k := range decodeKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// waitReadable waits up to timeout for fd to have input
+func waitReadable(fd int, timeout time.Duration) (bool, error) {
+	var set syscall.FdSet
+	bits := int(unsafe.Sizeof(set.Bits[0])) * 8
+	set.Bits[fd/bits] |= 1 << (uint(fd) % uint(bits))
+	tv := syscall.NsecToTimeval(timeout.Nanoseconds())
+	n, err := syscall.Select(fd+1, &set, nil, nil, &tv)
+	if err == syscall.EINTR {
+		return false, nil
+	}
+	return n > 0, err
+}
+
+// decodeKeys splits one read into key presses. An escape sequence is
+// assumed to arrive in a single read, so a lone ESC is the Escape key.
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b && len(b) > 1 && (b[1] == '[' || b[1] == 'O'):
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			if end < len(b) {
+				end++
+			}
+			if name, ok := escapeKeys[string(b[1:end])]; ok {
+				keys = append(keys, key{name: name})
+			}
+			b = b[end:]
+		case c == 0x1b:
+			keys = append(keys, key{name: "esc"})
+			b = b[1:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{name: "enter"})
+			b = b[1:]
+		case c == '\t':
+			keys = append(keys, key{name: "tab"})
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{name: "backspace"})
+			b = b[1:]
+		case c == 0x03:
+			keys = append(keys, key{name: "ctrl-c"})
+			b = b[1:]
+		case c < 0x20:
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, key{r: r})
+			b = b[size:]
+		}
+	}
+	return keys
+}
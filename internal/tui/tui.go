@@ -0,0 +1,417 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/twingate"
+)
+
+// Refresh intervals. The state is polled faster while it is changing.
+const (
+	tickInterval        = app.StatusPollInterval
+	statePollInterval   = 2 * time.Second
+	detailsPollInterval = 15 * time.Second
+)
+
+// Handlers connect the dashboard to the connection. The CLI passes functions
+// that go through the daemon when one is running, so the dashboard and the
+// tray agree on the state.
+type Handlers struct {
+	State      func(ctx context.Context) (app.ConnectionState, error)
+	Connect    func(ctx context.Context) error
+	Disconnect func(ctx context.Context) error
+
+	// Privileged is set when Connect and Disconnect run pkexec or sudo in
+	// this process, which may ask for a password on the terminal
+	Privileged bool
+}
+
+// view is a page of the dashboard
+type view int
+
+const (
+	viewOverview view = iota
+	viewResources
+	viewExitNodes
+	viewCount
+)
+
+func (v view) title() string {
+	switch v {
+	case viewResources:
+		return "Resources"
+	case viewExitNodes:
+		return "Exit nodes"
+	default:
+		return "Overview"
+	}
+}
+
+// model is the dashboard. It is only touched by the loop goroutine;
+// background work hands its results back through events.
+type model struct {
+	ctx    context.Context
+	h      Handlers
+	term   *terminal
+	events chan func()
+	logs   *logPane
+
+	view      view
+	cursor    [viewCount]int
+	filter    string
+	filtering bool
+
+	state        app.ConnectionState
+	stateErr     error
+	stateKnown   bool
+	info         *twingate.ConnectionInfo
+	resources    []twingate.Resource
+	resourcesErr error
+	exitNodes    *twingate.ExitNodeStatus
+	exitNodesErr error
+
+	loading     map[string]bool
+	lastState   time.Time
+	lastDetails time.Time
+	busy        string // Label of the running action
+}
+
+// Run shows the dashboard until the user quits or ctx is cancelled. Log
+// output goes to the dashboard's log pane while it runs.
+func Run(ctx context.Context, h Handlers) error {
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.suspend()
+
+	logs := newLogPane()
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(logs)
+	log.SetFlags(log.Ltime)
+	defer func() {
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+	}()
+
+	m := &model{
+		ctx:     ctx,
+		h:       h,
+		term:    term,
+		events:  make(chan func(), 16),
+		logs:    logs,
+		loading: make(map[string]bool),
+	}
+	return m.loop()
+}
+
+func (m *model) loop() error {
+	keys := make(chan key, 16)
+	go m.term.readKeys(keys)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	m.refreshState()
+	m.refreshDetails()
+	for {
+		m.draw()
+		select {
+		case <-m.ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok || m.handleKey(k) {
+				return nil
+			}
+		case fn := <-m.events:
+			fn()
+		case <-m.logs.wake:
+		case sig := <-signals:
+			if sig != syscall.SIGWINCH {
+				return nil
+			}
+		case <-ticker.C:
+			m.tick()
+		}
+	}
+}
+
+// tick starts the refreshes that are due
+func (m *model) tick() {
+	if m.state.IsTransitional() || time.Since(m.lastState) >= statePollInterval {
+		m.refreshState()
+	}
+	if time.Since(m.lastDetails) >= detailsPollInterval {
+		m.refreshDetails()
+	}
+}
+
+// fetch runs load in the background unless a load called name is already
+// running, then applies its result on the loop
+func (m *model) fetch(name string, load func(ctx context.Context) func()) {
+	if m.loading[name] {
+		return
+	}
+	m.loading[name] = true
+	go func() {
+		apply := load(m.ctx)
+		m.events <- func() {
+			m.loading[name] = false
+			apply()
+		}
+	}()
+}
+
+func (m *model) refreshState() {
+	m.lastState = time.Now()
+	m.fetch("state", func(ctx context.Context) func() {
+		state, err := m.h.State(ctx)
+		return func() {
+			if err == nil && m.stateKnown && state != m.state {
+				log.Printf("State: %s", state.Label())
+			}
+			if err != nil && m.stateErr == nil {
+				log.Printf("Failed to get status: %v", err)
+			}
+			m.stateErr = err
+			if err == nil {
+				changed := m.stateKnown && state != m.state
+				m.state, m.stateKnown = state, true
+				if changed {
+					m.refreshDetails()
+				}
+			}
+		}
+	})
+}
+
+func (m *model) refreshDetails() {
+	m.lastDetails = time.Now()
+	m.fetch("info", func(ctx context.Context) func() {
+		info := twingate.GetConnectionInfo(ctx)
+		return func() { m.info = &info }
+	})
+	m.fetch("resources", func(ctx context.Context) func() {
+		resources, err := twingate.GetResources(ctx)
+		return func() {
+			if err != nil && m.resourcesErr == nil {
+				log.Printf("Failed to get resources: %v", err)
+			}
+			m.resources, m.resourcesErr = resources, err
+		}
+	})
+	m.fetch("exitnodes", func(ctx context.Context) func() {
+		status, err := twingate.GetExitNodeStatus(ctx)
+		return func() {
+			if err != nil && m.exitNodesErr == nil {
+				log.Printf("Failed to get exit nodes: %v", err)
+			}
+			m.exitNodes, m.exitNodesErr = status, err
+		}
+	})
+}
+
+// act runs an action and logs its outcome. Privileged actions run with the
+// terminal handed back, so pkexec or sudo can ask for a password.
+func (m *model) act(label string, privileged bool, run func(ctx context.Context) error) {
+	if m.busy != "" {
+		log.Printf("Wait for %q to finish", m.busy)
+		return
+	}
+	m.busy = label
+	log.Printf("%s...", label)
+
+	if privileged {
+		m.term.suspend()
+		fmt.Printf("%s (pkexec or sudo may ask for your password)...\n", label)
+		err := run(m.ctx)
+		if err := m.term.resume(); err != nil {
+			log.Printf("Failed to restore the terminal: %v", err)
+		}
+		m.finish(label, err)
+		return
+	}
+
+	go func() {
+		err := run(m.ctx)
+		m.events <- func() { m.finish(label, err) }
+	}()
+}
+
+func (m *model) finish(label string, err error) {
+	m.busy = ""
+	if err != nil {
+		log.Printf("%s failed: %v", label, err)
+	} else {
+		log.Printf("%s: done", label)
+	}
+	m.refreshState()
+	m.refreshDetails()
+}
+
+// handleKey applies a key press and reports whether to quit
+func (m *model) handleKey(k key) bool {
+	if k.name == "ctrl-c" {
+		return true
+	}
+	if m.filtering && m.editFilter(k) {
+		return false
+	}
+
+	switch {
+	case k.r == 'q':
+		return true
+	case k.name == "tab" || k.name == "right":
+		m.view = (m.view + 1) % viewCount
+	case k.name == "backtab" || k.name == "left":
+		m.view = (m.view + viewCount - 1) % viewCount
+	case k.r >= '1' && k.r < '1'+rune(viewCount):
+		m.view = view(k.r - '1')
+	case k.r == 'r':
+		log.Print("Refreshing...")
+		m.refreshState()
+		m.refreshDetails()
+	case k.r == 'c':
+		m.connect()
+	case k.r == 'd':
+		m.disconnect()
+	case k.name == "up" || k.r == 'k':
+		m.move(-1)
+	case k.name == "down" || k.r == 'j':
+		m.move(1)
+	case k.name == "pgup":
+		m.move(-m.pageSize())
+	case k.name == "pgdown":
+		m.move(m.pageSize())
+	case k.name == "home" || k.r == 'g':
+		m.cursor[m.view] = 0
+	case k.name == "end" || k.r == 'G':
+		m.move(1 << 30)
+	default:
+		switch m.view {
+		case viewResources:
+			m.resourceKey(k)
+		case viewExitNodes:
+			m.exitNodeKey(k)
+		}
+	}
+	return false
+}
+
+// editFilter applies a key to the resource filter and reports whether it
+// was used
+func (m *model) editFilter(k key) bool {
+	switch {
+	case k.name == "enter":
+		m.filtering = false
+	case k.name == "esc":
+		m.filter, m.filtering = "", false
+	case k.name == "backspace":
+		if r := []rune(m.filter); len(r) > 0 {
+			m.filter = string(r[:len(r)-1])
+		}
+	case k.name == "" && k.r != 0:
+		m.filter += string(k.r)
+	default:
+		return false // Navigation keys still move the cursor
+	}
+	m.cursor[viewResources] = 0
+	return true
+}
+
+// move moves the current view's cursor by delta, within its rows
+func (m *model) move(delta int) {
+	n := m.rowCount()
+	c := m.cursor[m.view] + delta
+	c = min(c, n-1)
+	m.cursor[m.view] = max(c, 0)
+}
+
+func (m *model) connect() {
+	if m.state == app.StateConnected {
+		log.Print("Already connected")
+		return
+	}
+	m.act("Connecting", m.h.Privileged, m.h.Connect)
+}
+
+func (m *model) disconnect() {
+	if m.stateKnown && m.state == app.StateDisconnected {
+		log.Print("Already disconnected")
+		return
+	}
+	m.act("Disconnecting", m.h.Privileged, m.h.Disconnect)
+}
+
+func (m *model) resourceKey(k key) {
+	switch {
+	case k.r == '/':
+		m.filtering = true
+	case k.name == "esc":
+		m.filter = ""
+		m.cursor[viewResources] = 0
+	case k.name == "enter" || k.r == 'a':
+		visible := m.visibleResources()
+		if len(visible) == 0 {
+			return
+		}
+		res := visible[min(m.cursor[viewResources], len(visible)-1)]
+		if !res.NeedsAuth {
+			log.Printf("%s does not need authentication", res.Name)
+			return
+		}
+		m.act("Authenticating "+res.Name, false, func(ctx context.Context) error {
+			return twingate.AuthenticateResource(ctx, res.Name)
+		})
+	}
+}
+
+func (m *model) exitNodeKey(k key) {
+	status := m.exitNodes
+	if status == nil || len(status.AvailableNodes) == 0 {
+		return
+	}
+	switch {
+	case k.name == "enter":
+		node := status.AvailableNodes[min(m.cursor[viewExitNodes], len(status.AvailableNodes)-1)]
+		if status.Enabled && node == status.CurrentNode {
+			log.Printf("Already using exit node %s", node)
+			return
+		}
+		m.act("Switching to exit node "+node, true, func(ctx context.Context) error {
+			return twingate.SwitchExitNode(ctx, node)
+		})
+	case k.r == 's' && status.Enabled:
+		m.act("Stopping exit node", true, twingate.StopExitNode)
+	case k.r == 's':
+		m.act("Starting exit node", true, twingate.StartExitNode)
+	}
+}
+
+// visibleResources returns the resources matching the filter
+func (m *model) visibleResources() []twingate.Resource {
+	if m.filter == "" {
+		return m.resources
+	}
+	needle := strings.ToLower(m.filter)
+	var visible []twingate.Resource
+	for _, r := range m.resources {
+		if strings.Contains(strings.ToLower(r.Name), needle) ||
+			strings.Contains(strings.ToLower(r.Address), needle) {
+			visible = append(visible, r)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,365 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bisand/twingate-tray/internal/app"
+	"github.com/bisand/twingate-tray/internal/netif"
+)
+
+// SGR attributes
+const (
+	styleBold    = "1"
+	styleDim     = "2"
+	styleReverse = "7"
+	styleRed     = "31"
+	styleGreen   = "32"
+	styleYellow  = "33"
+	styleCyan    = "36"
+)
+
+// Layout limits
+const (
+	minLogRows = 3
+	maxLogRows = 8
+	logHistory = 500 // Lines kept in the log pane
+)
+
+// styled wraps s in an SGR sequence
+func styled(attrs, s string) string {
+	return "\x1b[" + attrs + "m" + s + "\x1b[0m"
+}
+
+// fit truncates s to width columns, ending in an ellipsis when cut, and pads
+// it with spaces. Every rune is assumed to be one column wide.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// layout is the number of rows each part of the screen gets
+func (m *model) layout() (width, body, logs int) {
+	width, height := m.term.size()
+	logs = min(max(height/4, minLogRows), maxLogRows)
+	// Header, tabs, body separator, log title and footer
+	body = max(height-logs-5, 1)
+	return width, body, logs
+}
+
+func (m *model) pageSize() int {
+	_, body, _ := m.layout()
+	return max(body-1, 1)
+}
+
+// rowCount returns the number of selectable rows in the current view
+func (m *model) rowCount() int {
+	switch m.view {
+	case viewResources:
+		return len(m.visibleResources())
+	case viewExitNodes:
+		if m.exitNodes != nil {
+			return len(m.exitNodes.AvailableNodes)
+		}
+		return 0
+	default:
+		return len(m.overviewLines())
+	}
+}
+
+func (m *model) draw() {
+	width, body, logRows := m.layout()
+	lines := make([]string, 0, body+logRows+5)
+
+	lines = append(lines, m.header(width), m.tabs(width))
+
+	var rows []string
+	switch m.view {
+	case viewResources:
+		rows = m.resourceRows(width, body)
+	case viewExitNodes:
+		rows = m.exitNodeRows(width, body)
+	default:
+		rows = m.overviewRows(width, body)
+	}
+	for len(rows) < body {
+		rows = append(rows, "")
+	}
+	lines = append(lines, rows...)
+
+	lines = append(lines, styled(styleDim, strings.Repeat("─", width)))
+	lines = append(lines, styled(styleBold, fit(" Log", width)))
+	logs := m.logs.tail(logRows)
+	for len(logs) < logRows {
+		logs = append(logs, "")
+	}
+	for _, l := range logs {
+		lines = append(lines, fit(" "+l, width))
+	}
+
+	lines = append(lines, m.footer(width))
+	m.term.draw(lines)
+}
+
+func (m *model) header(width int) string {
+	state := "Checking..."
+	color := styleDim
+	switch {
+	case m.stateErr != nil:
+		state, color = "Unknown", styleRed
+	case !m.stateKnown:
+	case m.state == app.StateConnected:
+		state, color = m.state.Label(), styleGreen
+	case m.state == app.StateError:
+		state, color = m.state.Label(), styleRed
+	case m.state.IsTransitional():
+		state, color = m.state.Label(), styleYellow
+	default:
+		state = m.state.Label()
+	}
+
+	var details []string
+	if m.info != nil {
+		if m.state == app.StateConnected && m.info.ConnectedSince != "-" {
+			details = append(details, "since "+m.info.ConnectedSince)
+		}
+		if m.info.Network != "-" {
+			details = append(details, m.info.Network)
+		}
+		if m.info.UserEmail != "-" {
+			details = append(details, m.info.UserEmail)
+		}
+	}
+	if m.busy != "" {
+		details = append(details, m.busy+"...")
+	}
+
+	title := " Twingate  "
+	rest := "  " + strings.Join(details, "  ·  ")
+	avail := width - len([]rune(title)) - len([]rune(state)) - 2
+	return styled(styleBold, title) + styled(styleBold+";"+color, "● "+state) + fit(rest, avail)
+}
+
+func (m *model) tabs(width int) string {
+	var b strings.Builder
+	used := 0
+	for v := view(0); v < viewCount; v++ {
+		label := fmt.Sprintf(" %d %s ", v+1, v.title())
+		used += len([]rune(label)) + 1
+		if v == m.view {
+			b.WriteString(styled(styleReverse, label))
+		} else {
+			b.WriteString(label)
+		}
+		b.WriteString(" ")
+	}
+	if used < width {
+		b.WriteString(styled(styleDim, strings.Repeat("─", width-used)))
+	}
+	return b.String()
+}
+
+func (m *model) footer(width int) string {
+	if m.filtering {
+		return styled(styleCyan, fit(" Filter: "+m.filter+"_", width))
+	}
+	help := " q quit  tab view  c connect  d disconnect  r refresh"
+	switch m.view {
+	case viewResources:
+		help += "  / filter  enter authenticate"
+	case viewExitNodes:
+		help += "  enter switch  s start/stop"
+	}
+	return styled(styleDim, fit(help, width))
+}
+
+// window returns the first row to show so the cursor stays in view
+func window(cursor, rows, height int) int {
+	return max(min(cursor-height+1, rows-height), 0)
+}
+
+// field is a label and value on the overview
+type field struct {
+	label, value string
+}
+
+// overviewLines returns the ConnectionInfo fields shown on the overview; an
+// empty label separates groups
+func (m *model) overviewLines() []field {
+	info := m.info
+	if info == nil {
+		return []field{{"", "Loading connection information..."}}
+	}
+	fields := []field{
+		{"Status", info.Status},
+		{"Connected since", info.ConnectedSince},
+		{"Hostname", info.Hostname},
+		{},
+		{"User", info.UserEmail},
+		{"Network", info.Network},
+		{"Network URL", info.NetworkURL},
+		{},
+		{"Interface", fmt.Sprintf("%s (%s)", info.Interface, info.InterfaceState)},
+		{"IP address", info.IPAddress},
+		{"IPv6 address", info.IPv6Address},
+		{"MTU", info.MTU},
+	}
+	if info.Link != nil {
+		stats := info.Link.Stats
+		fields = append(fields,
+			field{"Flags", info.Link.Flags.String()},
+			field{"Received", fmt.Sprintf("%s (%d packets)", netif.FormatBytes(stats.RxBytes), stats.RxPackets)},
+			field{"Sent", fmt.Sprintf("%s (%d packets)", netif.FormatBytes(stats.TxBytes), stats.TxPackets)},
+		)
+	}
+	fields = append(fields,
+		field{"DNS servers", info.DNSServers},
+		field{"DNS domain", info.DNSDomain},
+		field{"Secure DNS", info.SecureDNS},
+		field{},
+		field{"Routes", info.Routes},
+		field{},
+		field{"Daemon PID", info.DaemonPID},
+		field{"Daemon memory", info.DaemonMemory},
+		field{"Client version", info.ClientVersion},
+	)
+	return fields
+}
+
+func (m *model) overviewRows(width, height int) []string {
+	fields := m.overviewLines()
+	// The overview scrolls rather than selecting, so the cursor is the top row
+	top := min(m.cursor[viewOverview], max(len(fields)-height, 0))
+	m.cursor[viewOverview] = top
+	var rows []string
+	for _, f := range fields[top:min(top+height, len(fields))] {
+		if f.label == "" {
+			rows = append(rows, fit("  "+f.value, width))
+			continue
+		}
+		rows = append(rows, styled(styleDim, fmt.Sprintf("  %-17s", f.label+":"))+fit(f.value, width-19))
+	}
+	return rows
+}
+
+func (m *model) resourceRows(width, height int) []string {
+	visible := m.visibleResources()
+	switch {
+	case m.resourcesErr != nil:
+		return []string{styled(styleRed, fit("  "+m.resourcesErr.Error(), width))}
+	case m.resources == nil && m.loading["resources"]:
+		return []string{fit("  Loading resources...", width)}
+	case len(visible) == 0 && m.filter != "":
+		return []string{fit(fmt.Sprintf("  No resources match %q", m.filter), width)}
+	case len(visible) == 0:
+		return []string{fit("  No resources available", width)}
+	}
+
+	nameWidth := max(min(width*2/5, 40), 10)
+	countLine := fmt.Sprintf("  %d of %d resources", len(visible), len(m.resources))
+	if m.filter != "" {
+		countLine += fmt.Sprintf(" matching %q", m.filter)
+	}
+	rows := []string{styled(styleDim, fit(countLine, width))}
+
+	cursor := min(m.cursor[viewResources], len(visible)-1)
+	top := window(cursor, len(visible), height-1)
+	for i := top; i < min(top+height-1, len(visible)); i++ {
+		r := visible[i]
+		auth := ""
+		if r.NeedsAuth {
+			auth = "locked"
+		}
+		line := fit(fmt.Sprintf("  %s %-8s %s", fit(r.Name, nameWidth), auth, r.Address), width)
+		switch {
+		case i == cursor:
+			line = styled(styleReverse, line)
+		case r.NeedsAuth:
+			line = styled(styleYellow, line)
+		}
+		rows = append(rows, line)
+	}
+	return rows
+}
+
+func (m *model) exitNodeRows(width, height int) []string {
+	status := m.exitNodes
+	switch {
+	case m.exitNodesErr != nil:
+		return []string{styled(styleRed, fit("  "+m.exitNodesErr.Error(), width))}
+	case status == nil:
+		return []string{fit("  Loading exit nodes...", width)}
+	case len(status.AvailableNodes) == 0:
+		return []string{fit("  No exit nodes available for your network", width)}
+	}
+
+	summary := "  Exit node routing is off"
+	if status.Enabled {
+		summary = "  Routing traffic through " + status.CurrentNode
+	}
+	rows := []string{styled(styleDim, fit(summary, width))}
+
+	nodes := status.AvailableNodes
+	cursor := min(m.cursor[viewExitNodes], len(nodes)-1)
+	top := window(cursor, len(nodes), height-1)
+	for i := top; i < min(top+height-1, len(nodes)); i++ {
+		node := nodes[i]
+		marker := "○"
+		if status.Enabled && node == status.CurrentNode {
+			marker = "●"
+		}
+		line := fit(fmt.Sprintf("  %s %s", marker, node), width)
+		if i == cursor {
+			line = styled(styleReverse, line)
+		}
+		rows = append(rows, line)
+	}
+	return rows
+}
+
+// logPane collects log output for the dashboard. It is written from any
+// goroutine and wakes the loop to redraw.
+type logPane struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string
+	wake    chan struct{}
+}
+
+func newLogPane() *logPane {
+	return &logPane{wake: make(chan struct{}, 1)}
+}
+
+func (l *logPane) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	text := l.partial + string(p)
+	parts := strings.Split(text, "\n")
+	l.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		l.lines = append(l.lines, strings.ReplaceAll(line, "\t", "  "))
+	}
+	if len(l.lines) > logHistory {
+		l.lines = append([]string(nil), l.lines[len(l.lines)-logHistory:]...)
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// tail returns the last n lines
+func (l *logPane) tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines[max(len(l.lines)-n, 0):]...)
+}
@@ -1,8 +1,9 @@
 package twingate
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
@@ -10,42 +11,81 @@ import (
 type NetworkInfo struct {
 	Name string
 	URL  string
+	User string // Account email
 }
 
-// CheckStatus returns true if connected to Twingate, false otherwise
-func CheckStatus() (bool, error) {
-	output, err := runCommand("twingate", "status")
-	if err != nil {
-		// Don't treat command errors as fatal - just log and return disconnected
-		// The command might fail due to temporary issues
-		return false, fmt.Errorf("twingate status command failed: %w", err)
-	}
+// Status is the client state reported by `twingate status`
+type Status string
+
+// Known client states
+const (
+	StatusOnline         Status = "online"
+	StatusOffline        Status = "offline"
+	StatusConnecting     Status = "connecting"
+	StatusAuthenticating Status = "authenticating"
+	StatusNotRunning     Status = "not-running"
+	StatusUnknown        Status = "unknown"
+)
 
+// ParseStatus maps the output of `twingate status` to a Status
+func ParseStatus(output string) Status {
 	outputTrimmed := strings.TrimSpace(strings.ToLower(output))
 
-	// Check if output indicates online status
 	// Support both "online" and "Online" (case-insensitive)
-	isOnline := strings.HasPrefix(outputTrimmed, "online")
+	switch {
+	case strings.HasPrefix(outputTrimmed, "online"):
+		return StatusOnline
+	case strings.HasPrefix(outputTrimmed, "offline"):
+		return StatusOffline
+	case strings.HasPrefix(outputTrimmed, "not-running"), strings.HasPrefix(outputTrimmed, "not running"):
+		return StatusNotRunning
+	case strings.HasPrefix(outputTrimmed, "connecting"), strings.HasPrefix(outputTrimmed, "starting"):
+		return StatusConnecting
+	case strings.HasPrefix(outputTrimmed, "authenticating"), strings.HasPrefix(outputTrimmed, "auth"):
+		return StatusAuthenticating
+	default:
+		return StatusUnknown
+	}
+}
+
+// GetStatus returns the parsed client state
+func (c *Client) GetStatus(ctx context.Context) (Status, error) {
+	output, err := c.runCommand(ctx, "twingate", "status")
+	status := ParseStatus(output)
+	if err != nil && (status == StatusUnknown || IsTimeout(err)) {
+		// Don't treat command errors as fatal - just log and return disconnected
+		// The command might fail due to temporary issues
+		return StatusUnknown, fmt.Errorf("twingate status command failed: %w", err)
+	}
+	// Some client versions exit non-zero while reporting "not-running"
+	return status, nil
+}
 
-	return isOnline, nil
+// CheckStatus returns true if connected to Twingate, false otherwise
+func (c *Client) CheckStatus(ctx context.Context) (bool, error) {
+	status, err := c.GetStatus(ctx)
+	return status == StatusOnline, err
 }
 
 // GetNetworkInfo retrieves the current network name and URL
-func GetNetworkInfo() (*NetworkInfo, error) {
-	output, err := runCommand("twingate", "account", "list", "-d")
+func (c *Client) GetNetworkInfo(ctx context.Context) (*NetworkInfo, error) {
+	output, err := c.runCommand(ctx, "twingate", "account", "list", "-d")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network info: %w", err)
 	}
 
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	if len(lines) < 2 {
-		return &NetworkInfo{Name: "-", URL: "-"}, nil
+		return &NetworkInfo{Name: "-", URL: "-", User: "-"}, nil
 	}
 
 	// Parse first data line (skip header)
 	fields := strings.Split(lines[1], "\t")
-	info := &NetworkInfo{Name: "-", URL: "-"}
+	info := &NetworkInfo{Name: "-", URL: "-", User: "-"}
 
+	if len(fields) >= 1 && strings.TrimSpace(fields[0]) != "" {
+		info.User = strings.TrimSpace(fields[0])
+	}
 	if len(fields) >= 2 {
 		info.Name = strings.TrimSpace(fields[1])
 	}
@@ -57,8 +97,8 @@ func GetNetworkInfo() (*NetworkInfo, error) {
 }
 
 // IsAutoConnectEnabled checks if the Twingate service is set to start automatically
-func IsAutoConnectEnabled() bool {
-	output, err := runCommand("systemctl", "is-enabled", "twingate")
+func (c *Client) IsAutoConnectEnabled(ctx context.Context) bool {
+	output, err := c.runCommand(ctx, "systemctl", "is-enabled", "twingate")
 	if err != nil {
 		return false
 	}
@@ -66,59 +106,45 @@ func IsAutoConnectEnabled() bool {
 }
 
 // SetAutoConnect enables or disables auto-connect by enabling/disabling the systemd service
-func SetAutoConnect(enabled bool) error {
-	var cmd *exec.Cmd
+func (c *Client) SetAutoConnect(ctx context.Context, enabled bool) error {
+	action := "disable"
 	if enabled {
-		cmd = exec.Command("pkexec", "systemctl", "enable", "twingate")
-	} else {
-		cmd = exec.Command("pkexec", "systemctl", "disable", "twingate")
+		action = "enable"
 	}
-
-	if err := cmd.Run(); err != nil {
-		// Try with sudo as fallback
-		if enabled {
-			cmd = exec.Command("sudo", "systemctl", "enable", "twingate")
-		} else {
-			cmd = exec.Command("sudo", "systemctl", "disable", "twingate")
-		}
-		return cmd.Run()
-	}
-
-	return nil
+	return c.runPrivilegedCommand(ctx, "systemctl", action, "twingate")
 }
 
 // Connect connects to Twingate
-func Connect() error {
+func (c *Client) Connect(ctx context.Context) error {
 	// Try pkexec first, fall back to sudo
-	if err := runPrivilegedCommand("twingate", "start"); err != nil {
+	if err := c.runPrivilegedCommand(ctx, "twingate", "start"); err != nil {
 		return fmt.Errorf("failed to start twingate: %w", err)
 	}
 
 	// Also try desktop-restart
-	runCommand("twingate", "desktop-restart")
+	c.runCommand(ctx, "twingate", "desktop-restart")
 
 	return nil
 }
 
 // Disconnect disconnects from Twingate
-func Disconnect() error {
+func (c *Client) Disconnect(ctx context.Context) error {
 	// Try pkexec first, fall back to sudo
-	if err := runPrivilegedCommand("twingate", "stop"); err != nil {
+	if err := c.runPrivilegedCommand(ctx, "twingate", "stop"); err != nil {
 		return fmt.Errorf("failed to stop twingate: %w", err)
 	}
 
 	// Also try desktop-stop
-	runCommand("twingate", "desktop-stop")
+	c.runCommand(ctx, "twingate", "desktop-stop")
 
 	return nil
 }
 
 // GenerateDiagnosticReport generates a diagnostic report
-func GenerateDiagnosticReport() error {
-	cmd := exec.Command("twingate", "report")
-	output, err := cmd.CombinedOutput()
+func (c *Client) GenerateDiagnosticReport(ctx context.Context) error {
+	output, err := c.runCommand(ctx, "twingate", "report")
 	if err != nil {
-		return fmt.Errorf("failed to generate diagnostic report: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("failed to generate diagnostic report: %w\nOutput: %s", err, output)
 	}
 
 	// Show success notification with report location
@@ -126,23 +152,37 @@ func GenerateDiagnosticReport() error {
 	return nil
 }
 
-// runCommand executes a simple command and returns its output
-func runCommand(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+// runCommand executes a simple command and returns its output.
+// The command is bounded by the deadline configured for its program.
+func (c *Client) runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	timeout := c.Timeout(name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := c.runner.Run(ctx, name, args...)
+
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) && timeoutErr.Timeout == 0 {
+		timeoutErr.Timeout = timeout
+	}
+	return output, err
 }
 
 // runPrivilegedCommand runs a command with elevated privileges
 // Tries pkexec first, then falls back to sudo
-func runPrivilegedCommand(name string, args ...string) error {
+func (c *Client) runPrivilegedCommand(ctx context.Context, name string, args ...string) error {
 	// Try pkexec first (preferred on most modern Linux DEs)
-	cmd := exec.Command("pkexec", append([]string{name}, args...)...)
-	if err := cmd.Run(); err == nil {
+	_, err := c.runCommand(ctx, "pkexec", append([]string{name}, args...)...)
+	if err == nil {
 		return nil
 	}
 
+	// A timeout means the command hung, not that pkexec is unavailable
+	if IsTimeout(err) || ctx.Err() != nil {
+		return err
+	}
+
 	// Fall back to sudo
-	cmd = exec.Command("sudo", append([]string{name}, args...)...)
-	return cmd.Run()
+	_, err = c.runCommand(ctx, "sudo", append([]string{name}, args...)...)
+	return err
 }
@@ -0,0 +1,158 @@
+package twingate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/netif"
+)
+
+// Default command deadlines
+const (
+	// DefaultCommandTimeout bounds twingate, systemctl and resolvectl calls
+	DefaultCommandTimeout = 10 * time.Second
+
+	// PrivilegedCommandTimeout bounds pkexec/sudo calls, which wait for the
+	// user to authenticate
+	PrivilegedCommandTimeout = 2 * time.Minute
+)
+
+// DefaultInterface is the network interface created by the Twingate client
+const DefaultInterface = "sdwan0"
+
+// ServiceUnit is the systemd unit that runs the Twingate client
+const ServiceUnit = "twingate.service"
+
+// Client performs Twingate operations through a Runner. Use NewClient with a
+// FakeRunner to exercise the parsing logic against recorded CLI output.
+type Client struct {
+	runner Runner
+
+	mu             sync.RWMutex
+	defaultTimeout time.Duration
+	timeouts       map[string]time.Duration
+	iface          string
+
+	// inspect reads the network interface; tests can replace it with fixture data
+	inspect func(name string) (*netif.Link, error)
+}
+
+// NewClient creates a Client that executes commands through runner
+func NewClient(runner Runner) *Client {
+	return &Client{
+		runner:         runner,
+		iface:          DefaultInterface,
+		inspect:        netif.Inspect,
+		defaultTimeout: DefaultCommandTimeout,
+		timeouts: map[string]time.Duration{
+			"pkexec": PrivilegedCommandTimeout,
+			"sudo":   PrivilegedCommandTimeout,
+		},
+	}
+}
+
+// SetTimeout sets the deadline for every invocation of the given program
+// (e.g. "twingate" or "resolvectl"). A zero duration restores the default.
+func (c *Client) SetTimeout(program string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d <= 0 {
+		delete(c.timeouts, program)
+		return
+	}
+	c.timeouts[program] = d
+}
+
+// SetDefaultTimeout sets the deadline for programs without a specific timeout
+func (c *Client) SetDefaultTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d > 0 {
+		c.defaultTimeout = d
+	}
+}
+
+// Timeout returns the deadline applied to invocations of program
+func (c *Client) Timeout(program string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if d, ok := c.timeouts[program]; ok {
+		return d
+	}
+	return c.defaultTimeout
+}
+
+// SetInterface sets the name of the Twingate network interface
+func (c *Client) SetInterface(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name != "" {
+		c.iface = name
+	}
+}
+
+// Interface returns the name of the Twingate network interface
+func (c *Client) Interface() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.iface
+}
+
+// Default is the Client used by the package-level functions
+var Default = NewClient(ExecRunner{})
+
+// GetStatus returns the parsed client state
+func GetStatus(ctx context.Context) (Status, error) { return Default.GetStatus(ctx) }
+
+// CheckStatus returns true if connected to Twingate, false otherwise
+func CheckStatus(ctx context.Context) (bool, error) { return Default.CheckStatus(ctx) }
+
+// GetNetworkInfo retrieves the current network name and URL
+func GetNetworkInfo(ctx context.Context) (*NetworkInfo, error) { return Default.GetNetworkInfo(ctx) }
+
+// IsAutoConnectEnabled checks if the Twingate service is set to start automatically
+func IsAutoConnectEnabled(ctx context.Context) bool { return Default.IsAutoConnectEnabled(ctx) }
+
+// SetAutoConnect enables or disables auto-connect by enabling/disabling the systemd service
+func SetAutoConnect(ctx context.Context, enabled bool) error {
+	return Default.SetAutoConnect(ctx, enabled)
+}
+
+// Connect connects to Twingate
+func Connect(ctx context.Context) error { return Default.Connect(ctx) }
+
+// Disconnect disconnects from Twingate
+func Disconnect(ctx context.Context) error { return Default.Disconnect(ctx) }
+
+// GenerateDiagnosticReport generates a diagnostic report
+func GenerateDiagnosticReport(ctx context.Context) error {
+	return Default.GenerateDiagnosticReport(ctx)
+}
+
+// GetResources returns a list of available Twingate resources
+func GetResources(ctx context.Context) ([]Resource, error) { return Default.GetResources(ctx) }
+
+// AuthenticateResource initiates authentication for a locked resource
+func AuthenticateResource(ctx context.Context, resourceName string) error {
+	return Default.AuthenticateResource(ctx, resourceName)
+}
+
+// GetExitNodeStatus returns current exit node status
+func GetExitNodeStatus(ctx context.Context) (*ExitNodeStatus, error) {
+	return Default.GetExitNodeStatus(ctx)
+}
+
+// StartExitNode starts routing all traffic through Twingate
+func StartExitNode(ctx context.Context) error { return Default.StartExitNode(ctx) }
+
+// StopExitNode stops routing all traffic through Twingate
+func StopExitNode(ctx context.Context) error { return Default.StopExitNode(ctx) }
+
+// SwitchExitNode switches to a different exit node
+func SwitchExitNode(ctx context.Context, nodeName string) error {
+	return Default.SwitchExitNode(ctx, nodeName)
+}
+
+// GetConnectionInfo gathers connection information from all sources
+func GetConnectionInfo(ctx context.Context) ConnectionInfo { return Default.ConnectionInfo(ctx) }
@@ -0,0 +1,157 @@
+package twingate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bisand/twingate-tray/internal/netif"
+)
+
+// fixture returns recorded CLI output from testdata
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// newTestClient returns a client on runner that never reads a real interface
+func newTestClient(runner Runner) *Client {
+	c := NewClient(runner)
+	c.inspect = func(name string) (*netif.Link, error) {
+		return nil, errors.New("no interface in tests")
+	}
+	return c
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{Output: "online\n", Delay: time.Minute}, "twingate", "status")
+	c := newTestClient(runner)
+	c.SetTimeout("twingate", 20*time.Millisecond)
+
+	_, err := c.GetStatus(context.Background())
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("GetStatus error = %v, want a *TimeoutError", err)
+	}
+	if timeoutErr.Command != "twingate status" || timeoutErr.Timeout != 20*time.Millisecond {
+		t.Errorf("TimeoutError = %+v, want twingate status after 20ms", timeoutErr)
+	}
+	if !IsTimeout(err) {
+		t.Error("IsTimeout = false for a timed out command")
+	}
+}
+
+func TestRunCommandCallerDeadline(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{Delay: time.Minute}, "twingate", "status")
+	c := newTestClient(runner)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetStatus(ctx); !IsTimeout(err) {
+		t.Errorf("GetStatus error = %v, want a timeout", err)
+	}
+}
+
+func TestPrivilegedCommandFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		pkexec FakeResponse
+		sudo   FakeResponse
+		calls  []string
+		err    bool
+	}{
+		{
+			name:   "pkexec succeeds",
+			pkexec: FakeResponse{},
+			calls:  []string{"pkexec twingate start", "twingate desktop-restart"},
+		},
+		{
+			name:   "pkexec missing",
+			pkexec: FakeResponse{ExitCode: 127},
+			sudo:   FakeResponse{},
+			calls:  []string{"pkexec twingate start", "sudo twingate start", "twingate desktop-restart"},
+		},
+		{
+			name:   "both fail",
+			pkexec: FakeResponse{ExitCode: 126},
+			sudo:   FakeResponse{ExitCode: 1},
+			calls:  []string{"pkexec twingate start", "sudo twingate start"},
+			err:    true,
+		},
+		{
+			name:   "pkexec hangs",
+			pkexec: FakeResponse{Delay: time.Minute},
+			calls:  []string{"pkexec twingate start"},
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewFakeRunner().
+				On(tt.pkexec, "pkexec", "twingate", "start").
+				On(tt.sudo, "sudo", "twingate", "start").
+				On(FakeResponse{}, "twingate", "desktop-restart")
+			c := newTestClient(runner)
+			c.SetTimeout("pkexec", 20*time.Millisecond)
+
+			err := c.Connect(context.Background())
+			if (err != nil) != tt.err {
+				t.Errorf("Connect error = %v, want error: %v", err, tt.err)
+			}
+			if got := runner.Calls(); !slices.Equal(got, tt.calls) {
+				t.Errorf("calls = %q, want %q", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestGetNetworkInfo(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{Output: fixture(t, "account-list.tsv")}, "twingate", "account", "list", "-d")
+	info, err := newTestClient(runner).GetNetworkInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetNetworkInfo: %v", err)
+	}
+	want := NetworkInfo{Name: "acme", URL: "https://acme.twingate.com", User: "alice@example.com"}
+	if *info != want {
+		t.Errorf("GetNetworkInfo = %+v, want %+v", *info, want)
+	}
+}
+
+func TestConnectionInfo(t *testing.T) {
+	runner := NewFakeRunner().
+		On(FakeResponse{Output: fixture(t, "status-verbose.txt")}, "twingate", "status", "-v", "-d").
+		On(FakeResponse{Output: fixture(t, "account-list.tsv")}, "twingate", "account", "list", "-d").
+		On(FakeResponse{Output: fixture(t, "resources.tsv")}, "twingate", "resources", "-d").
+		On(FakeResponse{Output: "twingate 2024.1.0\n"}, "twingate", "version")
+	info := newTestClient(runner).ConnectionInfo(context.Background())
+
+	if info.Status != "Online" || info.SecureDNS != "DoH (enabled)" {
+		t.Errorf("status = %q, secure DNS = %q", info.Status, info.SecureDNS)
+	}
+	if info.UserEmail != "alice@example.com" || info.Network != "acme" {
+		t.Errorf("account = %q on %q", info.UserEmail, info.Network)
+	}
+	if info.ClientVersion != "twingate 2024.1.0" {
+		t.Errorf("version = %q", info.ClientVersion)
+	}
+	want := []ResourceEntry{
+		{Name: "Database", Address: "db.internal:5432", AuthStatus: "locked"},
+		{Name: "Wiki", Address: "wiki.acme.internal", AuthStatus: "authenticated"},
+		{Name: "Lab", Address: "10.0.0.0/24", AuthStatus: "-"},
+	}
+	if !slices.Equal(info.Resources, want) {
+		t.Errorf("resources = %+v, want %+v", info.Resources, want)
+	}
+	// Failed sources keep their placeholder
+	if info.Interface != "-" || info.DNSServers != "-" || info.DaemonPID != "-" {
+		t.Errorf("missing sources = %q, %q, %q, want -", info.Interface, info.DNSServers, info.DaemonPID)
+	}
+}
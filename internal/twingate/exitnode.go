@@ -1,6 +1,7 @@
 package twingate
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -13,11 +14,11 @@ type ExitNodeStatus struct {
 }
 
 // GetExitNodeStatus returns current exit node status
-func GetExitNodeStatus() (*ExitNodeStatus, error) {
+func (c *Client) GetExitNodeStatus(ctx context.Context) (*ExitNodeStatus, error) {
 	status := &ExitNodeStatus{}
 
 	// Check if exit node routing is active by listing nodes
-	output, err := runCommand("twingate", "exit-node", "list", "-d")
+	output, err := c.runCommand(ctx, "twingate", "exit-node", "list", "-d")
 
 	// Handle the output even if there's an error, since "no exit nodes" returns exit code 1
 	output = strings.TrimSpace(output)
@@ -57,24 +58,24 @@ func GetExitNodeStatus() (*ExitNodeStatus, error) {
 }
 
 // StartExitNode starts routing all traffic through Twingate
-func StartExitNode() error {
-	if err := runPrivilegedCommand("twingate", "exit-node", "start"); err != nil {
+func (c *Client) StartExitNode(ctx context.Context) error {
+	if err := c.runPrivilegedCommand(ctx, "twingate", "exit-node", "start"); err != nil {
 		return fmt.Errorf("failed to start exit node: %w", err)
 	}
 	return nil
 }
 
 // StopExitNode stops routing all traffic through Twingate
-func StopExitNode() error {
-	if err := runPrivilegedCommand("twingate", "exit-node", "stop"); err != nil {
+func (c *Client) StopExitNode(ctx context.Context) error {
+	if err := c.runPrivilegedCommand(ctx, "twingate", "exit-node", "stop"); err != nil {
 		return fmt.Errorf("failed to stop exit node: %w", err)
 	}
 	return nil
 }
 
 // SwitchExitNode switches to a different exit node
-func SwitchExitNode(nodeName string) error {
-	if err := runPrivilegedCommand("twingate", "exit-node", "switch", nodeName); err != nil {
+func (c *Client) SwitchExitNode(ctx context.Context, nodeName string) error {
+	if err := c.runPrivilegedCommand(ctx, "twingate", "exit-node", "switch", nodeName); err != nil {
 		return fmt.Errorf("failed to switch exit node to %s: %w", nodeName, err)
 	}
 	return nil
@@ -0,0 +1,76 @@
+package twingate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetExitNodeStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		resp FakeResponse
+		want ExitNodeStatus
+	}{
+		{
+			name: "active node",
+			resp: FakeResponse{Output: fixture(t, "exit-node-list.tsv")},
+			want: ExitNodeStatus{
+				Enabled:        true,
+				CurrentNode:    "Frankfurt",
+				AvailableNodes: []string{"Oslo", "Frankfurt", "Virginia"},
+			},
+		},
+		{
+			name: "no active node",
+			resp: FakeResponse{Output: "Name\tLocation\tActive\nOslo\tNO\tfalse\n"},
+			want: ExitNodeStatus{AvailableNodes: []string{"Oslo"}},
+		},
+		{
+			// The client exits 1 when the network has no exit nodes
+			name: "no exit nodes",
+			resp: FakeResponse{Output: fixture(t, "exit-node-none.txt"), ExitCode: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewFakeRunner().On(tt.resp, "twingate", "exit-node", "list", "-d")
+			got, err := newTestClient(runner).GetExitNodeStatus(context.Background())
+			if err != nil {
+				t.Fatalf("GetExitNodeStatus: %v", err)
+			}
+			if got.Enabled != tt.want.Enabled || got.CurrentNode != tt.want.CurrentNode ||
+				!slices.Equal(got.AvailableNodes, tt.want.AvailableNodes) {
+				t.Errorf("GetExitNodeStatus = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetExitNodeStatusErrors(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{Output: "not connected\n", ExitCode: 1}, "twingate", "exit-node", "list", "-d")
+	_, err := newTestClient(runner).GetExitNodeStatus(context.Background())
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("GetExitNodeStatus error = %v, want an *ExitError", err)
+	}
+
+	runner = NewFakeRunner().On(FakeResponse{Delay: time.Minute}, "twingate", "exit-node", "list", "-d")
+	c := newTestClient(runner)
+	c.SetTimeout("twingate", 20*time.Millisecond)
+	if _, err := c.GetExitNodeStatus(context.Background()); !IsTimeout(err) {
+		t.Errorf("GetExitNodeStatus error = %v, want a timeout", err)
+	}
+}
+
+func TestSwitchExitNode(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{}, "pkexec", "twingate", "exit-node", "switch", "Oslo")
+	if err := newTestClient(runner).SwitchExitNode(context.Background(), "Oslo"); err != nil {
+		t.Fatalf("SwitchExitNode: %v", err)
+	}
+	if got, want := runner.Calls(), []string{"pkexec twingate exit-node switch Oslo"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package twingate
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -14,8 +15,8 @@ type Resource struct {
 }
 
 // GetResources returns a list of available Twingate resources
-func GetResources() ([]Resource, error) {
-	output, err := runCommand("twingate", "resources", "-d")
+func (c *Client) GetResources(ctx context.Context) ([]Resource, error) {
+	output, err := c.runCommand(ctx, "twingate", "resources", "-d")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get resources: %w", err)
 	}
@@ -61,8 +62,8 @@ func GetResources() ([]Resource, error) {
 }
 
 // AuthenticateResource initiates authentication for a locked resource
-func AuthenticateResource(resourceName string) error {
-	_, err := runCommand("twingate", "auth", resourceName)
+func (c *Client) AuthenticateResource(ctx context.Context, resourceName string) error {
+	_, err := c.runCommand(ctx, "twingate", "auth", resourceName)
 	if err != nil {
 		return fmt.Errorf("failed to authenticate resource %s: %w", resourceName, err)
 	}
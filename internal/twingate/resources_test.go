@@ -0,0 +1,63 @@
+package twingate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetResources(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Resource
+	}{
+		{
+			name:   "recorded list",
+			output: fixture(t, "resources.tsv"),
+			want: []Resource{
+				{Name: "Database", Address: "db.internal:5432", AuthStatus: "locked", NeedsAuth: true},
+				{Name: "Wiki", Address: "wiki.acme.internal", AuthStatus: "authenticated"},
+				{Name: "Lab", Address: "10.0.0.0/24", AuthStatus: "-"},
+			},
+		},
+		{
+			name:   "three columns",
+			output: "Resource Name\tAddress\tAuth Status\nDatabase\tdb.internal\tLocked (sign in required)\n",
+			want: []Resource{
+				{Name: "Database", Address: "db.internal", AuthStatus: "Locked (sign in required)", NeedsAuth: true},
+			},
+		},
+		{
+			name:   "header only",
+			output: "Resource Name\tAddress\tAlias\tAuth Status\n",
+		},
+		{
+			name:   "rows without an address are skipped",
+			output: "Resource Name\tAddress\nDatabase\n\tdb.internal\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewFakeRunner().On(FakeResponse{Output: tt.output}, "twingate", "resources", "-d")
+			got, err := newTestClient(runner).GetResources(context.Background())
+			if err != nil {
+				t.Fatalf("GetResources: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetResources = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetResourcesExitError(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{Output: "not connected\n", ExitCode: 1}, "twingate", "resources", "-d")
+	_, err := newTestClient(runner).GetResources(context.Background())
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Errorf("GetResources error = %v, want exit status 1", err)
+	}
+}
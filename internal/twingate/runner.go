@@ -0,0 +1,172 @@
+package twingate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Runner executes external commands on behalf of a Client.
+// Run returns the combined stdout/stderr of the command. A command that
+// starts but exits with a non-zero status returns its output together
+// with an *ExitError. A command that outlives the context deadline is
+// killed and returns a *TimeoutError.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) (string, error)
+}
+
+// ExitError reports a command that ran but exited with a non-zero status
+type ExitError struct {
+	Command string
+	Code    int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s: exit status %d", e.Command, e.Code)
+}
+
+// TimeoutError reports a command that was killed because it exceeded its deadline
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration // Zero if the deadline came from the caller's context
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("%s: timed out after %s", e.Command, e.Timeout)
+	}
+	return fmt.Sprintf("%s: timed out", e.Command)
+}
+
+// IsTimeout reports whether err was caused by a command exceeding its deadline
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// ExecRunner runs commands with os/exec. It is the Runner used by the
+// package-level functions.
+type ExecRunner struct{}
+
+// Run executes the command and returns its combined output. Each command runs
+// in its own process group so that a timeout also kills any children it forked
+// (pkexec, sudo and systemctl all spawn helpers).
+func (ExecRunner) Run(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait forever on grandchildren that keep the output pipe open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	if err != nil && ctx.Err() != nil {
+		return string(output), contextError(ctx, name, args)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), &ExitError{Command: commandLine(name, args), Code: exitErr.ExitCode()}
+	}
+	return string(output), err
+}
+
+// contextError converts a finished context into the error returned by a Runner
+func contextError(ctx context.Context, name string, args []string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Command: commandLine(name, args)}
+	}
+	return ctx.Err()
+}
+
+// FakeResponse is a recorded command result replayed by FakeRunner
+type FakeResponse struct {
+	Output   string
+	ExitCode int
+	Err      error         // Returned as-is when set, e.g. to simulate a missing binary
+	Delay    time.Duration // Simulated run time; a hung command outlives its deadline
+}
+
+// FakeRunner replays recorded command output instead of executing anything.
+// Responses are matched on the full command line. When several responses are
+// scripted for the same command they are returned in order, and the last one
+// is repeated once the script runs out.
+type FakeRunner struct {
+	mu        sync.Mutex
+	responses map[string][]FakeResponse
+	calls     []string
+}
+
+// NewFakeRunner creates an empty FakeRunner
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{responses: make(map[string][]FakeResponse)}
+}
+
+// On scripts a response for the given command line and returns the runner
+// so calls can be chained
+func (f *FakeRunner) On(resp FakeResponse, name string, args ...string) *FakeRunner {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := commandLine(name, args)
+	f.responses[key] = append(f.responses[key], resp)
+	return f
+}
+
+// Run replays the next scripted response for the command. Unscripted
+// commands fail with exit code 127, like a shell that cannot find them.
+func (f *FakeRunner) Run(ctx context.Context, name string, args ...string) (string, error) {
+	key := commandLine(name, args)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, key)
+	queue := f.responses[key]
+	var resp FakeResponse
+	scripted := len(queue) > 0
+	if scripted {
+		resp = queue[0]
+		if len(queue) > 1 {
+			f.responses[key] = queue[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	if !scripted {
+		return "", &ExitError{Command: key, Code: 127}
+	}
+
+	if resp.Delay > 0 {
+		timer := time.NewTimer(resp.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", contextError(ctx, name, args)
+		}
+	}
+
+	if resp.Err != nil {
+		return resp.Output, resp.Err
+	}
+	if resp.ExitCode != 0 {
+		return resp.Output, &ExitError{Command: key, Code: resp.ExitCode}
+	}
+	return resp.Output, nil
+}
+
+// Calls returns the command lines executed so far, in order
+func (f *FakeRunner) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// commandLine joins a command and its arguments into a single string
+func commandLine(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
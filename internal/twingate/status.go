@@ -1,15 +1,15 @@
 package twingate
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
-	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/bisand/twingate-tray/internal/netif"
 	"golang.design/x/clipboard"
 )
 
@@ -17,6 +17,7 @@ import (
 type ConnectionInfo struct {
 	Status         string
 	ConnectedSince string
+	ConnectedAt    time.Time // Zero when unknown
 	Network        string
 	NetworkURL     string
 	UserEmail      string
@@ -31,6 +32,7 @@ type ConnectionInfo struct {
 	DNSServers     string
 	DNSDomain      string
 	Routes         string
+	Link           *netif.Link // Nil when the interface does not exist
 	Resources      []ResourceEntry
 	DaemonPID      string
 	DaemonMemory   string
@@ -43,9 +45,9 @@ type ResourceEntry struct {
 	AuthStatus string
 }
 
-// gatherConnectionInfo collects connection information from various sources.
+// ConnectionInfo collects connection information from various sources.
 // Each field is gathered independently so partial failures don't block the dialog.
-func gatherConnectionInfo() ConnectionInfo {
+func (c *Client) ConnectionInfo(ctx context.Context) ConnectionInfo {
 	info := ConnectionInfo{
 		Status:         "Unknown",
 		ConnectedSince: "-",
@@ -67,13 +69,15 @@ func gatherConnectionInfo() ConnectionInfo {
 		DaemonMemory:   "-",
 	}
 
+	iface := c.Interface()
+
 	// Hostname
 	if h, err := os.Hostname(); err == nil {
 		info.Hostname = h
 	}
 
 	// 1. Status (verbose)
-	if out, err := runCommandOutput("twingate", "status", "-v", "-d"); err == nil {
+	if out, err := c.runCommand(ctx, "twingate", "status", "-v", "-d"); err == nil {
 		lines := strings.Split(strings.TrimSpace(out), "\n")
 		for _, line := range lines {
 			line = strings.TrimSpace(line)
@@ -89,7 +93,7 @@ func gatherConnectionInfo() ConnectionInfo {
 	}
 
 	// 2. Account info
-	if out, err := runCommandOutput("twingate", "account", "list", "-d"); err == nil {
+	if out, err := c.runCommand(ctx, "twingate", "account", "list", "-d"); err == nil {
 		lines := strings.Split(strings.TrimSpace(out), "\n")
 		for _, line := range lines[1:] {
 			fields := splitTSV(line)
@@ -102,44 +106,40 @@ func gatherConnectionInfo() ConnectionInfo {
 	}
 
 	// 3. Version
-	if out, err := runCommandOutput("twingate", "version"); err == nil {
+	if out, err := c.runCommand(ctx, "twingate", "version"); err == nil {
 		firstLine := strings.SplitN(strings.TrimSpace(out), "\n", 2)[0]
 		if strings.HasPrefix(strings.ToLower(firstLine), "twingate") {
 			info.ClientVersion = strings.TrimSpace(firstLine)
 		}
 	}
 
-	// 4. Network interface info (sdwan0 is the Twingate interface)
-	if out, err := runCommandOutput("ip", "addr", "show", "sdwan0"); err == nil {
-		info.Interface = "sdwan0"
-
-		// Extract inet address
-		reV4 := regexp.MustCompile(`inet\s+(\S+)`)
-		if m := reV4.FindStringSubmatch(out); len(m) > 1 {
-			info.IPAddress = m[1]
+	// 4. Network interface info from rtnetlink (sdwan0 unless configured otherwise)
+	if link, err := c.inspect(iface); err == nil {
+		info.Interface = iface
+		info.Link = link
+		info.InterfaceState = link.OperState
+		info.MTU = strconv.Itoa(link.MTU)
+		if addrs := joinAddresses(link.IPv4()); addrs != "" {
+			info.IPAddress = addrs
 		}
-
-		// Extract inet6 address (link-local)
-		reV6 := regexp.MustCompile(`inet6\s+(\S+)`)
-		if m := reV6.FindStringSubmatch(out); len(m) > 1 {
-			info.IPv6Address = m[1]
+		if addrs := joinAddresses(link.IPv6()); addrs != "" {
+			info.IPv6Address = addrs
 		}
 
-		// Extract state from first line
-		reState := regexp.MustCompile(`state\s+(\S+)`)
-		if m := reState.FindStringSubmatch(out); len(m) > 1 {
-			info.InterfaceState = m[1]
+		// Routes outside the local table, as `ip route show dev` lists them
+		var routes []string
+		for _, r := range link.Routes {
+			if r.Table != syscall.RT_TABLE_LOCAL {
+				routes = append(routes, r.String())
+			}
 		}
-
-		// Extract MTU
-		reMTU := regexp.MustCompile(`mtu\s+(\d+)`)
-		if m := reMTU.FindStringSubmatch(out); len(m) > 1 {
-			info.MTU = m[1]
+		if len(routes) > 0 {
+			info.Routes = strings.Join(routes, ", ")
 		}
 	}
 
 	// 5. DNS info
-	if out, err := runCommandOutput("resolvectl", "status", "sdwan0"); err == nil {
+	if out, err := c.runCommand(ctx, "resolvectl", "status", iface); err == nil {
 		lines := strings.Split(out, "\n")
 		var dnsServers []string
 		for _, line := range lines {
@@ -167,23 +167,8 @@ func gatherConnectionInfo() ConnectionInfo {
 		}
 	}
 
-	// 6. Routes
-	if out, err := runCommandOutput("ip", "route", "show", "dev", "sdwan0"); err == nil {
-		lines := strings.Split(strings.TrimSpace(out), "\n")
-		var routes []string
-		for _, line := range lines {
-			fields := strings.Fields(line)
-			if len(fields) > 0 {
-				routes = append(routes, fields[0])
-			}
-		}
-		if len(routes) > 0 {
-			info.Routes = strings.Join(routes, ", ")
-		}
-	}
-
-	// 7. Resources
-	if out, err := runCommandOutput("twingate", "resources", "-d"); err == nil {
+	// 6. Resources
+	if out, err := c.runCommand(ctx, "twingate", "resources", "-d"); err == nil {
 		lines := strings.Split(strings.TrimSpace(out), "\n")
 		for _, line := range lines[1:] {
 			fields := splitTSV(line)
@@ -204,8 +189,8 @@ func gatherConnectionInfo() ConnectionInfo {
 		}
 	}
 
-	// 8. Connected since + daemon info (from systemd)
-	if out, err := runCommandOutput("systemctl", "show", "twingate",
+	// 7. Connected since + daemon info (from systemd)
+	if out, err := c.runCommand(ctx, "systemctl", "show", "twingate",
 		"--property=ActiveEnterTimestamp,MainPID,MemoryCurrent"); err == nil {
 		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
 			parts := strings.SplitN(line, "=", 2)
@@ -215,6 +200,7 @@ func gatherConnectionInfo() ConnectionInfo {
 			switch parts[0] {
 			case "ActiveEnterTimestamp":
 				if t, err := parseSystemdTimestamp(parts[1]); err == nil {
+					info.ConnectedAt = t
 					duration := time.Since(t)
 					info.ConnectedSince = fmt.Sprintf("%s (%s)",
 						t.Format("2006-01-02 15:04:05"), formatDuration(duration))
@@ -228,7 +214,7 @@ func gatherConnectionInfo() ConnectionInfo {
 			case "MemoryCurrent":
 				if parts[1] != "[not set]" && parts[1] != "" {
 					if mem, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64); err == nil {
-						info.DaemonMemory = formatBytes(mem)
+						info.DaemonMemory = netif.FormatBytes(mem)
 					}
 				}
 			}
@@ -238,8 +224,8 @@ func gatherConnectionInfo() ConnectionInfo {
 	return info
 }
 
-// formatPlainText formats the ConnectionInfo as a plain-text string suitable for copying.
-func (info *ConnectionInfo) formatPlainText() string {
+// PlainText formats the ConnectionInfo as a plain-text string suitable for copying.
+func (info *ConnectionInfo) PlainText() string {
 	var b strings.Builder
 
 	b.WriteString("=== Twingate Connection Information ===\n\n")
@@ -258,6 +244,12 @@ func (info *ConnectionInfo) formatPlainText() string {
 	b.WriteString(fmt.Sprintf("  IP address:       %s\n", info.IPAddress))
 	b.WriteString(fmt.Sprintf("  IPv6 address:     %s\n", info.IPv6Address))
 	b.WriteString(fmt.Sprintf("  MTU:              %s\n", info.MTU))
+	if info.Link != nil {
+		stats := info.Link.Stats
+		b.WriteString(fmt.Sprintf("  Flags:            %s\n", info.Link.Flags))
+		b.WriteString(fmt.Sprintf("  Received:         %s (%d packets)\n", netif.FormatBytes(stats.RxBytes), stats.RxPackets))
+		b.WriteString(fmt.Sprintf("  Sent:             %s (%d packets)\n", netif.FormatBytes(stats.TxBytes), stats.TxPackets))
+	}
 	b.WriteString(fmt.Sprintf("  DNS servers:      %s\n", info.DNSServers))
 	b.WriteString(fmt.Sprintf("  DNS domain:       %s\n", info.DNSDomain))
 	b.WriteString(fmt.Sprintf("  Secure DNS:       %s\n", info.SecureDNS))
@@ -287,61 +279,15 @@ func (info *ConnectionInfo) formatPlainText() string {
 	return b.String()
 }
 
-// showStatusDialog displays the connection information dialog using zenity.
-// Uses --text-info for a scrollable, selectable text view with a Copy button.
-func showStatusDialog(info ConnectionInfo) {
-	text := info.formatPlainText()
-
-	for {
-		cmd := exec.Command("zenity", "--text-info",
-			"--title=Twingate Connection Information",
-			"--width=550",
-			"--height=500",
-			"--font=monospace 10",
-			"--ok-label=OK",
-			"--extra-button=Copy to Clipboard",
-		)
-		cmd.Stdin = strings.NewReader(text)
-		output, err := cmd.Output()
-
-		// Check if the "Copy to Clipboard" button was clicked
-		// zenity returns the extra button label on stdout with exit code 1
-		buttonClicked := strings.TrimSpace(string(output))
-		if buttonClicked == "Copy to Clipboard" {
-			copyToClipboard(text)
-			// Send notification
-			cmd := exec.Command("notify-send", "-a", "Twingate Tray", "-t", "5000", "Twingate", "Connection info copied to clipboard")
-			_ = cmd.Run() // Ignore errors - notification is optional
-			// Re-show the dialog so the user can dismiss with OK
-			continue
-		}
-
-		if err != nil && buttonClicked == "" {
-			// Normal exit (OK or window close) or error
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				if exitErr.ExitCode() != 1 && exitErr.ExitCode() != 5 {
-					log.Printf("Status dialog error: %v", err)
-				}
-			}
-		}
-		break
-	}
-}
-
-// copyToClipboard copies text to the system clipboard using golang.design/x/clipboard.
-func copyToClipboard(text string) {
-	err := clipboard.Init()
-	if err != nil {
-		log.Printf("Failed to initialize clipboard: %v", err)
-		return
+// CopyToClipboard copies text to the system clipboard using golang.design/x/clipboard.
+func CopyToClipboard(text string) error {
+	if err := clipboard.Init(); err != nil {
+		return fmt.Errorf("failed to initialize clipboard: %w", err)
 	}
 	clipboard.Write(clipboard.FmtText, []byte(text))
+	return nil
 }
 
-// runCommandOutput executes a command and returns its combined output.
-// This is a convenience wrapper around runCommand from twingate.go.
-var runCommandOutput = runCommand
-
 // splitTSV splits a tab-separated line into fields, trimming whitespace
 func splitTSV(line string) []string {
 	parts := strings.Split(line, "\t")
@@ -355,6 +301,15 @@ func splitTSV(line string) []string {
 	return fields
 }
 
+// joinAddresses lists addresses with their prefix length, e.g. "100.96.0.5/32"
+func joinAddresses(addrs []netif.Address) string {
+	var out []string
+	for _, a := range addrs {
+		out = append(out, a.Prefix.String())
+	}
+	return strings.Join(out, ", ")
+}
+
 // contains checks if a string slice contains a value
 func contains(slice []string, val string) bool {
 	for _, s := range slice {
@@ -405,23 +360,3 @@ func formatDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%d days", days)
 }
-
-// formatBytes formats a byte count into a human-readable string
-func formatBytes(bytes uint64) string {
-	switch {
-	case bytes >= 1<<30:
-		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
-	case bytes >= 1<<20:
-		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
-	case bytes >= 1<<10:
-		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
-	default:
-		return fmt.Sprintf("%d B", bytes)
-	}
-}
-
-// ShowConnectionInfo gathers and displays the connection information dialog
-func ShowConnectionInfo() {
-	info := gatherConnectionInfo()
-	showStatusDialog(info)
-}
@@ -0,0 +1,79 @@
+package twingate
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		output string
+		want   Status
+	}{
+		{"online\n", StatusOnline},
+		{"Online", StatusOnline},
+		{"  offline\n", StatusOffline},
+		{"not-running\n", StatusNotRunning},
+		{"Not running", StatusNotRunning},
+		{"connecting", StatusConnecting},
+		{"starting", StatusConnecting},
+		{"authenticating", StatusAuthenticating},
+		{"auth required", StatusAuthenticating},
+		{"", StatusUnknown},
+		{"something else", StatusUnknown},
+	}
+	for _, tt := range tests {
+		if got := ParseStatus(tt.output); got != tt.want {
+			t.Errorf("ParseStatus(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		resp FakeResponse
+		want Status
+		err  bool
+	}{
+		{"online", FakeResponse{Output: fixture(t, "status.txt")}, StatusOnline, false},
+		{"not running exits non-zero", FakeResponse{Output: fixture(t, "status-not-running.txt"), ExitCode: 1}, StatusNotRunning, false},
+		{"unreadable output", FakeResponse{Output: "segfault\n", ExitCode: 139}, StatusUnknown, true},
+		{"missing binary", FakeResponse{Err: errors.New(`exec: "twingate": executable file not found in $PATH`)}, StatusUnknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewFakeRunner().On(tt.resp, "twingate", "status")
+			got, err := newTestClient(runner).GetStatus(context.Background())
+			if got != tt.want || (err != nil) != tt.err {
+				t.Errorf("GetStatus = %q, %v; want %q, error: %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestGetStatusExitError(t *testing.T) {
+	runner := NewFakeRunner().On(FakeResponse{ExitCode: 2}, "twingate", "status")
+	_, err := newTestClient(runner).GetStatus(context.Background())
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("GetStatus error = %v, want an *ExitError", err)
+	}
+	if exitErr.Command != "twingate status" || exitErr.Code != 2 {
+		t.Errorf("ExitError = %+v, want twingate status with code 2", exitErr)
+	}
+	if IsTimeout(err) {
+		t.Error("IsTimeout = true for a command that exited")
+	}
+}
+
+func TestGetStatusUnscripted(t *testing.T) {
+	// The fake fails like a shell that cannot find the command
+	_, err := newTestClient(NewFakeRunner()).GetStatus(context.Background())
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 127 {
+		t.Errorf("GetStatus error = %v, want exit status 127", err)
+	}
+}
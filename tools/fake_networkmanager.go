@@ -0,0 +1,64 @@
+//go:build ignore
+
+// Fake NetworkManager for trying network rules on a private bus.
+//
+//	dbus-daemon --session --print-address --fork > /tmp/fake-system-bus
+//	export DBUS_SYSTEM_BUS_ADDRESS=$(head -1 /tmp/fake-system-bus)
+//	go run tools/fake_networkmanager.go
+//	twingate-tray   # in another shell with the same DBUS_SYSTEM_BUS_ADDRESS
+//
+// Then type commands on stdin:
+//
+//	wifi <ssid>        Wi-Fi connection with the given SSID
+//	ethernet <name>    Wired connection
+//	vpn <name>         VPN connection
+//	none               No primary connection
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/bisand/twingate-tray/internal/netmon"
+	"github.com/bisand/twingate-tray/internal/netmon/netmontest"
+)
+
+func main() {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to the system bus: %v", err)
+	}
+	fake, err := netmontest.Export(conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Fake NetworkManager running; commands: wifi <ssid>, ethernet <name>, vpn <name>, none")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for n := 1; scanner.Scan(); n++ {
+		kind, name, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		var network netmon.Network
+		switch kind {
+		case "":
+			continue
+		case "none":
+		case "wifi":
+			network = netmon.Network{ID: name, UUID: fmt.Sprintf("fake-%d", n), Type: "wifi", SSID: name}
+		case "ethernet", "vpn":
+			network = netmon.Network{ID: name, UUID: fmt.Sprintf("fake-%d", n), Type: kind}
+		default:
+			log.Printf("Unknown command: %s", kind)
+			continue
+		}
+		if err := fake.SetPrimary(network); err != nil {
+			log.Printf("Error: %v", err)
+			continue
+		}
+		log.Printf("Primary connection: %s", network)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build ignore
+
+// Fake resource for trying reachability probes without a tunnel.
+//
+//	go run tools/fake_resource.go
+//
+// It prints an address such as 127.0.0.1:40123 that accepts TCP connections.
+// Give that address to a fake twingate binary's resource list, then type
+// commands on stdin:
+//
+//	down    Stop accepting, so the resource is unreachable
+//	up      Accept again on the same port
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+)
+
+func main() {
+	ln, err := listen(0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	log.Printf("Fake resource listening on 127.0.0.1:%d; commands: down, up", port)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		switch strings.TrimSpace(scanner.Text()) {
+		case "":
+		case "down":
+			if ln == nil {
+				continue
+			}
+			ln.Close()
+			ln = nil
+			log.Print("Resource down")
+		case "up":
+			if ln != nil {
+				continue
+			}
+			if ln, err = listen(port); err != nil {
+				log.Printf("Error: %v", err)
+				continue
+			}
+			log.Print("Resource up")
+		default:
+			log.Printf("Unknown command: %s", scanner.Text())
+		}
+	}
+}
+
+// listen accepts and immediately closes connections on port of 127.0.0.1
+func listen(port int) (net.Listener, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start fake resource: %w", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln, nil
+}